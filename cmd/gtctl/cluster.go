@@ -45,6 +45,15 @@ func NewClusterCommand(l logger.Logger) *cobra.Command {
 	cmd.AddCommand(NewGetClusterCommand(l))
 	cmd.AddCommand(NewListClustersCommand(l))
 	cmd.AddCommand(NewConnectCommand(l))
+	cmd.AddCommand(NewStartClusterCommand(l))
+	cmd.AddCommand(NewStopClusterCommand(l))
+	cmd.AddCommand(NewUpgradeClusterCommand(l))
+	cmd.AddCommand(NewLogsClusterCommand(l))
+	cmd.AddCommand(NewSuperviseClusterCommand(l))
+	cmd.AddCommand(NewBackupClusterCommand(l))
+	cmd.AddCommand(NewRestoreClusterCommand(l))
+	cmd.AddCommand(NewCertsClusterCommand(l))
+	cmd.AddCommand(NewUsersClusterCommand(l))
 
 	return cmd
 }
@@ -0,0 +1,90 @@
+/*
+ * Copyright 2023 Greptime Team
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package main
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	opt "github.com/GreptimeTeam/gtctl/pkg/cluster"
+	"github.com/GreptimeTeam/gtctl/pkg/cluster/baremetal"
+	"github.com/GreptimeTeam/gtctl/pkg/logger"
+)
+
+type clusterBackupCliOptions struct {
+	Output                 string
+	Stop                   bool
+	Timeout                int
+	EnableCache            bool
+	UseGreptimeCNArtifacts bool
+}
+
+func NewBackupClusterCommand(l logger.Logger) *cobra.Command {
+	var options clusterBackupCliOptions
+
+	cmd := &cobra.Command{
+		Use:   "backup",
+		Short: "Back up a GreptimeDB cluster",
+		Long:  `Back up the data, configs and metadata of a GreptimeDB cluster in bare-metal mode into a tar.zst archive`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if len(args) == 0 {
+				return fmt.Errorf("cluster name should be set")
+			}
+			if len(options.Output) == 0 {
+				return fmt.Errorf("the output file should be set by '--output'")
+			}
+
+			var (
+				ctx         = context.Background()
+				cancel      context.CancelFunc
+				clusterName = args[0]
+			)
+
+			if options.Timeout > 0 {
+				ctx, cancel = context.WithTimeout(ctx, time.Duration(options.Timeout)*time.Second)
+				defer cancel()
+			}
+
+			cluster, err := baremetal.NewCluster(l, clusterName,
+				baremetal.WithCreateNoDirs(),
+				baremetal.WithPersistedConfig(),
+				baremetal.WithEnableCache(options.EnableCache))
+			if err != nil {
+				return err
+			}
+
+			bm, _ := cluster.(*baremetal.Cluster)
+			return bm.Backup(ctx, &opt.BackupOptions{
+				Name:                   clusterName,
+				Output:                 options.Output,
+				Stop:                   options.Stop,
+				UseGreptimeCNArtifacts: options.UseGreptimeCNArtifacts,
+			})
+		},
+	}
+
+	cmd.Flags().StringVarP(&options.Output, "output", "o", "", "The path of the archive, e.g. 'mycluster.tar.zst'.")
+	cmd.Flags().BoolVar(&options.Stop, "stop", false, "If true, stop the running cluster before backing up and start it again in background afterwards.")
+	cmd.Flags().IntVar(&options.Timeout, "timeout", 600, "Timeout in seconds for the command to complete, -1 means no timeout, default is 10 min.")
+	cmd.Flags().BoolVar(&options.EnableCache, "enable-cache", true, "If true, enable cache for downloading artifacts(charts and binaries).")
+	cmd.Flags().BoolVar(&options.UseGreptimeCNArtifacts, "use-greptime-cn-artifacts", false, "If true, use greptime-cn artifacts(charts and binaries).")
+
+	return cmd
+}
@@ -0,0 +1,83 @@
+/*
+ * Copyright 2023 Greptime Team
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/spf13/cobra"
+
+	opt "github.com/GreptimeTeam/gtctl/pkg/cluster"
+	"github.com/GreptimeTeam/gtctl/pkg/cluster/baremetal"
+	"github.com/GreptimeTeam/gtctl/pkg/logger"
+)
+
+type clusterCertsRotateCliOptions struct {
+	CA bool
+}
+
+func NewCertsClusterCommand(l logger.Logger) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "certs",
+		Short: "Manage the certificates of GreptimeDB cluster",
+		Long:  `Manage the self-signed certificates of GreptimeDB cluster with TLS in bare-metal mode`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if err := cmd.Help(); err != nil {
+				return err
+			}
+
+			return errors.New("subcommand is required")
+		},
+	}
+
+	cmd.AddCommand(newRotateCertsClusterCommand(l))
+
+	return cmd
+}
+
+func newRotateCertsClusterCommand(l logger.Logger) *cobra.Command {
+	var options clusterCertsRotateCliOptions
+
+	cmd := &cobra.Command{
+		Use:   "rotate",
+		Short: "Rotate the certificates of GreptimeDB cluster",
+		Long:  `Issue new server certificates of GreptimeDB cluster with TLS, the running cluster serves with them after it's restarted`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if len(args) == 0 {
+				return fmt.Errorf("cluster name should be set")
+			}
+
+			clusterName := args[0]
+			cluster, err := baremetal.NewCluster(l, clusterName, baremetal.WithCreateNoDirs())
+			if err != nil {
+				return err
+			}
+
+			bm, _ := cluster.(*baremetal.Cluster)
+			return bm.RotateCerts(context.Background(), &opt.RotateCertsOptions{
+				Name:     clusterName,
+				RotateCA: options.CA,
+			})
+		},
+	}
+
+	cmd.Flags().BoolVar(&options.CA, "ca", false, "If true, recreate the CA before issuing the certificates, the clients other than gtctl have to trust the new CA.")
+
+	return cmd
+}
@@ -23,6 +23,7 @@ import (
 	"github.com/spf13/cobra"
 
 	opt "github.com/GreptimeTeam/gtctl/pkg/cluster"
+	"github.com/GreptimeTeam/gtctl/pkg/cluster/baremetal"
 	"github.com/GreptimeTeam/gtctl/pkg/cluster/kubernetes"
 	"github.com/GreptimeTeam/gtctl/pkg/logger"
 )
@@ -30,6 +31,11 @@ import (
 type clusterConnectCliOptions struct {
 	Namespace string
 	Protocol  string
+	User      string
+
+	// The options for connecting GreptimeDB cluster in bare-metal.
+	BareMetal bool
+	Replica   int
 }
 
 func NewConnectCommand(l logger.Logger) *cobra.Command {
@@ -50,7 +56,15 @@ func NewConnectCommand(l logger.Logger) *cobra.Command {
 				protocol    opt.ConnectProtocol
 			)
 
-			cluster, err := kubernetes.NewCluster(l)
+			var (
+				cluster opt.Operations
+				err     error
+			)
+			if options.BareMetal {
+				cluster, err = baremetal.NewCluster(l, clusterName, baremetal.WithCreateNoDirs())
+			} else {
+				cluster, err = kubernetes.NewCluster(l)
+			}
 			if err != nil {
 				return err
 			}
@@ -67,6 +81,8 @@ func NewConnectCommand(l logger.Logger) *cobra.Command {
 				Namespace: options.Namespace,
 				Name:      clusterName,
 				Protocol:  protocol,
+				Replica:   options.Replica,
+				User:      options.User,
 			}
 
 			return cluster.Connect(ctx, connectOptions)
@@ -75,6 +91,9 @@ func NewConnectCommand(l logger.Logger) *cobra.Command {
 
 	cmd.Flags().StringVarP(&options.Namespace, "namespace", "n", "default", "Namespace of GreptimeDB cluster.")
 	cmd.Flags().StringVarP(&options.Protocol, "protocol", "p", "mysql", "Specify a database protocol, like mysql or pg.")
+	cmd.Flags().BoolVar(&options.BareMetal, "bare-metal", false, "Connect to the greptimedb cluster on bare-metal environment.")
+	cmd.Flags().StringVarP(&options.User, "user", "u", "", "The user added by 'gtctl cluster users add' to connect as, its password is the stored one.")
+	cmd.Flags().IntVar(&options.Replica, "replica", 0, "The index of frontend replica to connect, only works in bare-metal environment.")
 
 	return cmd
 }
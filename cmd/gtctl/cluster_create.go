@@ -63,6 +63,10 @@ type clusterCreateCliOptions struct {
 	GreptimeBinVersion string
 	EnableCache        bool
 	UseMemoryMeta      bool
+	Detach             bool
+	Resume             bool
+	AutoPorts          bool
+	Standalone         bool
 
 	// Common options.
 	Timeout int
@@ -111,6 +115,9 @@ func NewCreateClusterCommand(l logger.Logger) *cobra.Command {
 	cmd.Flags().StringVar(&options.EtcdClusterValuesFile, "etcd-cluster-values-file", "", "The values file for etcd cluster.")
 	cmd.Flags().StringVar(&options.GreptimeDBOperatorValuesFile, "greptimedb-operator-values-file", "", "The values file for greptimedb operator.")
 	cmd.Flags().BoolVar(&options.UseMemoryMeta, "use-memory-meta", false, "Bootstrap the whole cluster without installing etcd for testing purposes through using the memory storage of metasrv in bare-metal mode.")
+	cmd.Flags().BoolVar(&options.Detach, "detach", false, "Run the cluster in a background supervisor in bare-metal mode, so it keeps running after gtctl exits.")
+	cmd.Flags().BoolVar(&options.Resume, "resume", false, "Resume the existing cluster with its persisted config, data and binaries in bare-metal mode, instead of creating it again with the new config.")
+	cmd.Flags().BoolVar(&options.AutoPorts, "auto-ports", false, "Pick free ports for the addresses that conflict or are already in use, and record them in the persisted config in bare-metal mode.")
 
 	return cmd
 }
@@ -120,6 +127,17 @@ func NewCluster(args []string, options *clusterCreateCliOptions, l logger.Logger
 	if len(args) == 0 {
 		return fmt.Errorf("cluster name should be set")
 	}
+	if !options.BareMetal {
+		if options.Detach {
+			return fmt.Errorf("'--detach' only works in bare-metal mode")
+		}
+		if options.AutoPorts {
+			return fmt.Errorf("'--auto-ports' only works in bare-metal mode")
+		}
+		if options.Resume {
+			return fmt.Errorf("'--resume' only works in bare-metal mode")
+		}
+	}
 
 	var (
 		clusterName = args[0]
@@ -180,11 +198,29 @@ func NewCluster(args []string, options *clusterCreateCliOptions, l logger.Logger
 	}
 
 	var cluster opt.Operations
-	if options.BareMetal {
+	if options.Resume {
+		if len(options.Config) > 0 || len(options.GreptimeBinVersion) > 0 || options.UseMemoryMeta {
+			return fmt.Errorf("'--resume' can not be used with '--config', '--greptime-bin-version' or '--use-memory-meta', " +
+				"the cluster is resumed with its persisted config")
+		}
+
+		l.V(0).Infof("Resuming GreptimeDB cluster '%s' on bare-metal", logger.Bold(clusterName))
+		cluster, err = baremetal.NewCluster(l, clusterName,
+			baremetal.WithPersistedConfig(),
+			baremetal.WithEnableCache(options.EnableCache),
+			baremetal.WithAutoPorts(options.AutoPorts))
+		if err != nil {
+			return err
+		}
+	} else if options.BareMetal {
 		l.V(0).Infof("Creating GreptimeDB cluster '%s' on bare-metal", logger.Bold(clusterName))
 
 		var opts []baremetal.Option
-		opts = append(opts, baremetal.WithEnableCache(options.EnableCache), baremetal.WithMetastore(options.UseMemoryMeta))
+		opts = append(opts, baremetal.WithEnableCache(options.EnableCache), baremetal.WithMetastore(options.UseMemoryMeta),
+			baremetal.WithAutoPorts(options.AutoPorts))
+		if options.Standalone {
+			opts = append(opts, baremetal.WithStandalone())
+		}
 		if len(options.GreptimeBinVersion) > 0 {
 			opts = append(opts, baremetal.WithGreptimeVersion(options.GreptimeBinVersion))
 		}
@@ -216,12 +252,29 @@ func NewCluster(args []string, options *clusterCreateCliOptions, l logger.Logger
 		}
 	}
 
+	if options.BareMetal && options.Detach {
+		bm, _ := cluster.(*baremetal.Cluster)
+		if err = bm.Start(ctx, &opt.StartOptions{
+			Name:                   clusterName,
+			UseGreptimeCNArtifacts: options.UseGreptimeCNArtifacts,
+			Spinner:                spinner,
+		}); err != nil {
+			return err
+		}
+		printTips(l, clusterName, options, bm.TLSEnabled())
+		return nil
+	}
+
 	if err = cluster.Create(ctx, createOptions); err != nil {
 		return err
 	}
 
 	if !options.DryRun {
-		printTips(l, clusterName, options)
+		var tlsEnabled bool
+		if bm, ok := cluster.(*baremetal.Cluster); ok {
+			tlsEnabled = bm.TLSEnabled()
+		}
+		printTips(l, clusterName, options, tlsEnabled)
 	}
 
 	if options.BareMetal {
@@ -234,18 +287,29 @@ func NewCluster(args []string, options *clusterCreateCliOptions, l logger.Logger
 	return nil
 }
 
-func printTips(l logger.Logger, clusterName string, options *clusterCreateCliOptions) {
+func printTips(l logger.Logger, clusterName string, options *clusterCreateCliOptions, tlsEnabled bool) {
+	// The ports may be changed, or the servers require TLS with the self-signed CA, connecting by gtctl.
+	connectByGtctl := options.BareMetal && (options.AutoPorts || tlsEnabled)
+
 	l.V(0).Infof("\nNow you can use the following commands to access the GreptimeDB cluster:")
 	l.V(0).Infof("\n%s", logger.Bold("MySQL >"))
 	if !options.BareMetal {
 		l.V(0).Infof("%s", fmt.Sprintf("%s kubectl port-forward svc/%s-frontend -n %s 4002:4002 > connections-mysql.out &", logger.Bold("$"), clusterName, options.Namespace))
 	}
-	l.V(0).Infof("%s", fmt.Sprintf("%s mysql -h 127.0.0.1 -P 4002", logger.Bold("$")))
+	if connectByGtctl {
+		l.V(0).Infof("%s", fmt.Sprintf("%s gtctl cluster connect %s --bare-metal", logger.Bold("$"), clusterName))
+	} else {
+		l.V(0).Infof("%s", fmt.Sprintf("%s mysql -h 127.0.0.1 -P 4002", logger.Bold("$")))
+	}
 	l.V(0).Infof("\n%s", logger.Bold("PostgreSQL >"))
 	if !options.BareMetal {
 		l.V(0).Infof("%s", fmt.Sprintf("%s kubectl port-forward svc/%s-frontend -n %s 4003:4003 > connections-pg.out &", logger.Bold("$"), clusterName, options.Namespace))
 	}
-	l.V(0).Infof("%s", fmt.Sprintf("%s psql -h 127.0.0.1 -p 4003 -d public", logger.Bold("$")))
+	if connectByGtctl {
+		l.V(0).Infof("%s", fmt.Sprintf("%s gtctl cluster connect %s --bare-metal -p pg", logger.Bold("$"), clusterName))
+	} else {
+		l.V(0).Infof("%s", fmt.Sprintf("%s psql -h 127.0.0.1 -p 4003 -d public", logger.Bold("$")))
+	}
 	l.V(0).Infof("\nThank you for using %s! Check for more information on %s. 😊", logger.Bold("GreptimeDB"), logger.Bold("https://greptime.com"))
 	l.V(0).Infof("\n%s 🔑", logger.Bold("Invest in Data, Harvest over Time."))
 }
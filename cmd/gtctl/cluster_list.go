@@ -24,6 +24,7 @@ import (
 	"github.com/spf13/cobra"
 
 	opt "github.com/GreptimeTeam/gtctl/pkg/cluster"
+	"github.com/GreptimeTeam/gtctl/pkg/cluster/baremetal"
 	"github.com/GreptimeTeam/gtctl/pkg/cluster/kubernetes"
 	"github.com/GreptimeTeam/gtctl/pkg/logger"
 )
@@ -34,20 +35,38 @@ func NewListClustersCommand(l logger.Logger) *cobra.Command {
 	cmd := &cobra.Command{
 		Use:   "list",
 		Short: "List all GreptimeDB clusters",
-		Long:  `List all GreptimeDB clusters`,
+		Long:  `List all GreptimeDB clusters, including the clusters in Kubernetes and bare-metal environment`,
 		RunE: func(cmd *cobra.Command, args []string) error {
-			var ctx = context.Background()
+			var (
+				ctx     = context.Background()
+				options = &opt.ListOptions{
+					GetOptions: opt.GetOptions{
+						Table: table,
+					},
+				}
+			)
+			opt.ConfigListView(table)
 
-			cluster, err := kubernetes.NewCluster(l)
+			bm, err := baremetal.NewCluster(l, "", baremetal.WithCreateNoDirs())
 			if err != nil {
 				return err
 			}
+			if err = bm.List(ctx, options); err != nil {
+				return err
+			}
+
+			// The Kubernetes may be unavailable for users who only use bare-metal clusters.
+			k8s, err := kubernetes.NewCluster(l)
+			if err == nil {
+				err = k8s.List(ctx, options)
+			}
+			if err != nil {
+				l.V(3).Infof("failed to list clusters in Kubernetes: %v", err)
+			}
+
+			table.Render()
 
-			return cluster.List(ctx, &opt.ListOptions{
-				GetOptions: opt.GetOptions{
-					Table: table,
-				},
-			})
+			return nil
 		},
 	}
 
@@ -0,0 +1,103 @@
+/*
+ * Copyright 2023 Greptime Team
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	opt "github.com/GreptimeTeam/gtctl/pkg/cluster"
+	"github.com/GreptimeTeam/gtctl/pkg/cluster/baremetal"
+	"github.com/GreptimeTeam/gtctl/pkg/cluster/kubernetes"
+	"github.com/GreptimeTeam/gtctl/pkg/logger"
+)
+
+type clusterLogsCliOptions struct {
+	Namespace string
+	Component string
+	Replica   int
+	Follow    bool
+	Since     time.Duration
+	Tail      int64
+
+	// The options for printing logs of GreptimeDB cluster in bare-metal.
+	BareMetal bool
+}
+
+func NewLogsClusterCommand(l logger.Logger) *cobra.Command {
+	var options clusterLogsCliOptions
+
+	cmd := &cobra.Command{
+		Use:   "logs",
+		Short: "Print the logs of GreptimeDB cluster",
+		Long:  `Print the logs of GreptimeDB cluster components, the lines are prefixed by the replica they come from`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if len(args) == 0 {
+				return fmt.Errorf("cluster name should be set")
+			}
+			if options.Since < 0 {
+				return fmt.Errorf("'--since' should not be negative")
+			}
+
+			// Keep following the logs until it's interrupted.
+			ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+			defer stop()
+
+			var (
+				cluster     opt.Operations
+				err         error
+				clusterName = args[0]
+			)
+			if options.BareMetal {
+				cluster, err = baremetal.NewCluster(l, clusterName, baremetal.WithCreateNoDirs())
+			} else {
+				cluster, err = kubernetes.NewCluster(l)
+			}
+			if err != nil {
+				return err
+			}
+
+			logsOptions := &opt.LogsOptions{
+				Namespace: options.Namespace,
+				Name:      clusterName,
+				Component: options.Component,
+				Replica:   options.Replica,
+				Follow:    options.Follow,
+				Since:     options.Since,
+				Tail:      options.Tail,
+				Writer:    os.Stdout,
+			}
+			return cluster.Logs(ctx, logsOptions)
+		},
+	}
+
+	cmd.Flags().StringVarP(&options.Namespace, "namespace", "n", "default", "Namespace of GreptimeDB cluster.")
+	cmd.Flags().StringVarP(&options.Component, "component", "c", "", "The component to print logs, like frontend, datanode, meta or etcd(bare-metal only), all the components if not specified.")
+	cmd.Flags().IntVar(&options.Replica, "replica", -1, "The index of replica to print logs, all the replicas if not specified.")
+	cmd.Flags().BoolVarP(&options.Follow, "follow", "f", false, "Keep printing the new logs.")
+	cmd.Flags().DurationVar(&options.Since, "since", 0, "Only print the logs newer than a relative duration like 10m or 1h, all the logs if not specified.")
+	cmd.Flags().Int64Var(&options.Tail, "tail", -1, "The number of lines from the end of logs of each replica to print, all the lines if not specified.")
+	cmd.Flags().BoolVar(&options.BareMetal, "bare-metal", false, "Print the logs of greptimedb cluster on bare-metal environment.")
+
+	return cmd
+}
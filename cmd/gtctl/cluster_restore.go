@@ -0,0 +1,62 @@
+/*
+ * Copyright 2023 Greptime Team
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package main
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/spf13/cobra"
+
+	opt "github.com/GreptimeTeam/gtctl/pkg/cluster"
+	"github.com/GreptimeTeam/gtctl/pkg/cluster/baremetal"
+	"github.com/GreptimeTeam/gtctl/pkg/logger"
+)
+
+type clusterRestoreCliOptions struct {
+	Name string
+}
+
+func NewRestoreClusterCommand(l logger.Logger) *cobra.Command {
+	var options clusterRestoreCliOptions
+
+	cmd := &cobra.Command{
+		Use:   "restore",
+		Short: "Restore a GreptimeDB cluster from the backup",
+		Long:  `Restore a GreptimeDB cluster in bare-metal mode from the archive created by 'gtctl cluster backup', it can be started by 'gtctl cluster start' then`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if len(args) == 0 {
+				return fmt.Errorf("backup file should be set")
+			}
+
+			cluster, err := baremetal.NewCluster(l, "", baremetal.WithCreateNoDirs())
+			if err != nil {
+				return err
+			}
+
+			bm, _ := cluster.(*baremetal.Cluster)
+			return bm.Restore(context.Background(), &opt.RestoreOptions{
+				File: args[0],
+				Name: options.Name,
+			})
+		},
+	}
+
+	cmd.Flags().StringVar(&options.Name, "name", "", "The name of restored cluster, default is the name of the backed up cluster.")
+
+	return cmd
+}
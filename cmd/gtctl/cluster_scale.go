@@ -25,6 +25,7 @@ import (
 	"github.com/spf13/cobra"
 
 	opt "github.com/GreptimeTeam/gtctl/pkg/cluster"
+	"github.com/GreptimeTeam/gtctl/pkg/cluster/baremetal"
 	"github.com/GreptimeTeam/gtctl/pkg/cluster/kubernetes"
 	"github.com/GreptimeTeam/gtctl/pkg/logger"
 )
@@ -34,6 +35,9 @@ type clusterScaleCliOptions struct {
 	ComponentType string
 	Replicas      int32
 	Timeout       int
+
+	// The options for scaling GreptimeDB cluster in bare-metal.
+	BareMetal bool
 }
 
 func (s clusterScaleCliOptions) validate() error {
@@ -80,7 +84,15 @@ func NewScaleClusterCommand(l logger.Logger) *cobra.Command {
 				defer cancel()
 			}
 
-			cluster, err := kubernetes.NewCluster(l)
+			var (
+				cluster opt.Operations
+				err     error
+			)
+			if options.BareMetal {
+				cluster, err = baremetal.NewCluster(l, args[0], baremetal.WithCreateNoDirs())
+			} else {
+				cluster, err = kubernetes.NewCluster(l)
+			}
 			if err != nil {
 				return err
 			}
@@ -99,6 +111,7 @@ func NewScaleClusterCommand(l logger.Logger) *cobra.Command {
 	cmd.Flags().StringVarP(&options.Namespace, "namespace", "n", "default", "Namespace of GreptimeDB cluster.")
 	cmd.Flags().Int32Var(&options.Replicas, "replicas", 0, "The replicas of component of GreptimeDB cluster.")
 	cmd.Flags().IntVar(&options.Timeout, "timeout", 300, "Timeout in seconds for the command to complete, default is no timeout.")
+	cmd.Flags().BoolVar(&options.BareMetal, "bare-metal", false, "Scale the greptimedb cluster on bare-metal environment.")
 
 	return cmd
 }
@@ -0,0 +1,88 @@
+/*
+ * Copyright 2023 Greptime Team
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package main
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	opt "github.com/GreptimeTeam/gtctl/pkg/cluster"
+	"github.com/GreptimeTeam/gtctl/pkg/cluster/baremetal"
+	"github.com/GreptimeTeam/gtctl/pkg/logger"
+	"github.com/GreptimeTeam/gtctl/pkg/status"
+)
+
+type clusterStartCliOptions struct {
+	Timeout                int
+	EnableCache            bool
+	UseGreptimeCNArtifacts bool
+}
+
+func NewStartClusterCommand(l logger.Logger) *cobra.Command {
+	var options clusterStartCliOptions
+
+	cmd := &cobra.Command{
+		Use:   "start",
+		Short: "Start a stopped GreptimeDB cluster",
+		Long:  `Start a stopped GreptimeDB cluster in bare-metal mode with its persisted config and data`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if len(args) == 0 {
+				return fmt.Errorf("cluster name should be set")
+			}
+
+			var (
+				ctx         = context.Background()
+				cancel      context.CancelFunc
+				clusterName = args[0]
+			)
+
+			if options.Timeout > 0 {
+				ctx, cancel = context.WithTimeout(ctx, time.Duration(options.Timeout)*time.Second)
+				defer cancel()
+			}
+
+			spinner, err := status.NewSpinner()
+			if err != nil {
+				return err
+			}
+
+			cluster, err := baremetal.NewCluster(l, clusterName,
+				baremetal.WithCreateNoDirs(),
+				baremetal.WithPersistedConfig(),
+				baremetal.WithEnableCache(options.EnableCache))
+			if err != nil {
+				return err
+			}
+
+			bm, _ := cluster.(*baremetal.Cluster)
+			return bm.Start(ctx, &opt.StartOptions{
+				Name:                   clusterName,
+				UseGreptimeCNArtifacts: options.UseGreptimeCNArtifacts,
+				Spinner:                spinner,
+			})
+		},
+	}
+
+	cmd.Flags().IntVar(&options.Timeout, "timeout", 600, "Timeout in seconds for the command to complete, -1 means no timeout, default is 10 min.")
+	cmd.Flags().BoolVar(&options.EnableCache, "enable-cache", true, "If true, enable cache for downloading artifacts(charts and binaries).")
+	cmd.Flags().BoolVar(&options.UseGreptimeCNArtifacts, "use-greptime-cn-artifacts", false, "If true, use greptime-cn artifacts(charts and binaries).")
+
+	return cmd
+}
@@ -0,0 +1,76 @@
+/*
+ * Copyright 2023 Greptime Team
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package main
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/spf13/cobra"
+
+	opt "github.com/GreptimeTeam/gtctl/pkg/cluster"
+	"github.com/GreptimeTeam/gtctl/pkg/cluster/baremetal"
+	"github.com/GreptimeTeam/gtctl/pkg/logger"
+)
+
+type clusterSuperviseCliOptions struct {
+	EnableCache            bool
+	UseGreptimeCNArtifacts bool
+}
+
+// NewSuperviseClusterCommand creates the hidden command that runs as the background supervisor of a detached cluster.
+// It's spawned by 'gtctl cluster start' and 'gtctl cluster create --detach', and should not be used directly.
+func NewSuperviseClusterCommand(l logger.Logger) *cobra.Command {
+	var options clusterSuperviseCliOptions
+
+	cmd := &cobra.Command{
+		Use:    "supervise",
+		Short:  "Supervise a GreptimeDB cluster in background",
+		Long:   `Supervise a GreptimeDB cluster in background`,
+		Hidden: true,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if len(args) == 0 {
+				return fmt.Errorf("cluster name should be set")
+			}
+
+			clusterName := args[0]
+			cluster, err := baremetal.NewCluster(l, clusterName,
+				baremetal.WithCreateNoDirs(),
+				baremetal.WithPersistedConfig(),
+				baremetal.WithEnableCache(options.EnableCache))
+			if err != nil {
+				return err
+			}
+
+			bm, _ := cluster.(*baremetal.Cluster)
+			return bm.Supervise(context.Background(), &opt.CreateOptions{
+				Name: clusterName,
+				Cluster: &opt.CreateClusterOptions{
+					UseGreptimeCNArtifacts: options.UseGreptimeCNArtifacts,
+				},
+				Etcd: &opt.CreateEtcdOptions{
+					UseGreptimeCNArtifacts: options.UseGreptimeCNArtifacts,
+				},
+			})
+		},
+	}
+
+	cmd.Flags().BoolVar(&options.EnableCache, "enable-cache", true, "If true, enable cache for downloading artifacts(charts and binaries).")
+	cmd.Flags().BoolVar(&options.UseGreptimeCNArtifacts, "use-greptime-cn-artifacts", false, "If true, use greptime-cn artifacts(charts and binaries).")
+
+	return cmd
+}
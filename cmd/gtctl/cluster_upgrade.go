@@ -0,0 +1,94 @@
+/*
+ * Copyright 2023 Greptime Team
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package main
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	opt "github.com/GreptimeTeam/gtctl/pkg/cluster"
+	"github.com/GreptimeTeam/gtctl/pkg/cluster/baremetal"
+	"github.com/GreptimeTeam/gtctl/pkg/logger"
+	"github.com/GreptimeTeam/gtctl/pkg/status"
+)
+
+type clusterUpgradeCliOptions struct {
+	BareMetal              bool
+	GreptimeBinVersion     string
+	Timeout                int
+	UseGreptimeCNArtifacts bool
+}
+
+func NewUpgradeClusterCommand(l logger.Logger) *cobra.Command {
+	var options clusterUpgradeCliOptions
+
+	cmd := &cobra.Command{
+		Use:   "upgrade",
+		Short: "Upgrade a GreptimeDB cluster",
+		Long:  `Upgrade a running GreptimeDB cluster in bare-metal mode by restarting its replicas one by one`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if len(args) == 0 {
+				return fmt.Errorf("cluster name should be set")
+			}
+			if !options.BareMetal {
+				return fmt.Errorf("only the cluster in bare-metal can be upgraded now, please set '--bare-metal'")
+			}
+			if len(options.GreptimeBinVersion) == 0 {
+				return fmt.Errorf("'--greptime-bin-version' should be set")
+			}
+
+			var (
+				ctx         = context.Background()
+				cancel      context.CancelFunc
+				clusterName = args[0]
+			)
+
+			if options.Timeout > 0 {
+				ctx, cancel = context.WithTimeout(ctx, time.Duration(options.Timeout)*time.Second)
+				defer cancel()
+			}
+
+			spinner, err := status.NewSpinner()
+			if err != nil {
+				return err
+			}
+
+			cluster, err := baremetal.NewCluster(l, clusterName, baremetal.WithCreateNoDirs())
+			if err != nil {
+				return err
+			}
+
+			bm, _ := cluster.(*baremetal.Cluster)
+			return bm.Upgrade(ctx, &opt.UpgradeOptions{
+				Name:                   clusterName,
+				GreptimeBinVersion:     options.GreptimeBinVersion,
+				UseGreptimeCNArtifacts: options.UseGreptimeCNArtifacts,
+				Spinner:                spinner,
+			})
+		},
+	}
+
+	cmd.Flags().BoolVar(&options.BareMetal, "bare-metal", false, "Upgrade the greptimedb cluster on bare-metal environment.")
+	cmd.Flags().StringVar(&options.GreptimeBinVersion, "greptime-bin-version", "", "The version of greptime binary to upgrade to.")
+	cmd.Flags().IntVar(&options.Timeout, "timeout", 600, "Timeout in seconds for the command to complete, -1 means no timeout, default is 10 min.")
+	cmd.Flags().BoolVar(&options.UseGreptimeCNArtifacts, "use-greptime-cn-artifacts", false, "If true, use greptime-cn artifacts(charts and binaries).")
+
+	return cmd
+}
@@ -0,0 +1,175 @@
+/*
+ * Copyright 2023 Greptime Team
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/spf13/cobra"
+
+	opt "github.com/GreptimeTeam/gtctl/pkg/cluster"
+	"github.com/GreptimeTeam/gtctl/pkg/cluster/baremetal"
+	"github.com/GreptimeTeam/gtctl/pkg/cluster/kubernetes"
+	"github.com/GreptimeTeam/gtctl/pkg/logger"
+	"github.com/GreptimeTeam/gtctl/pkg/utils/users"
+)
+
+type clusterUsersCliOptions struct {
+	Namespace string
+	Password  string
+
+	// The options for managing the users of GreptimeDB cluster in bare-metal.
+	BareMetal bool
+}
+
+func NewUsersClusterCommand(l logger.Logger) *cobra.Command {
+	var options clusterUsersCliOptions
+
+	cmd := &cobra.Command{
+		Use:   "users",
+		Short: "Manage the users of GreptimeDB cluster",
+		Long: `Manage the static users that the frontend of GreptimeDB cluster authenticates the clients with, they are stored ` +
+			`in the cluster directory in bare-metal mode, or the '<cluster>-users' Secret in Kubernetes`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if err := cmd.Help(); err != nil {
+				return err
+			}
+
+			return errors.New("subcommand is required")
+		},
+	}
+
+	cmd.AddCommand(newAddUserCommand(l, &options))
+	cmd.AddCommand(newRemoveUserCommand(l, &options))
+	cmd.AddCommand(newListUsersCommand(l, &options))
+
+	cmd.PersistentFlags().StringVarP(&options.Namespace, "namespace", "n", "default", "Namespace of GreptimeDB cluster.")
+	cmd.PersistentFlags().BoolVar(&options.BareMetal, "bare-metal", false, "Manage the users of the greptimedb cluster on bare-metal environment.")
+
+	return cmd
+}
+
+func newAddUserCommand(l logger.Logger, options *clusterUsersCliOptions) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "add",
+		Short: "Add a user to GreptimeDB cluster",
+		Long:  `Add a user to GreptimeDB cluster, or change the password of the existing user`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if len(args) < 2 {
+				return fmt.Errorf("cluster name and user name should be set")
+			}
+
+			cluster, err := newUsersCluster(l, args[0], options)
+			if err != nil {
+				return err
+			}
+
+			password, generated := options.Password, false
+			if len(password) == 0 {
+				if password, err = users.GeneratePassword(); err != nil {
+					return err
+				}
+				generated = true
+			}
+			if err = cluster.AddUser(context.Background(), &opt.UserOptions{
+				Namespace: options.Namespace,
+				Name:      args[0],
+				User:      args[1],
+				Password:  password,
+			}); err != nil {
+				return err
+			}
+
+			if generated {
+				l.V(0).Infof("The generated password is %s", logger.Bold(password))
+			}
+			return nil
+		},
+	}
+
+	cmd.Flags().StringVar(&options.Password, "password", "", "The password of the user, a random one is generated if it's not set.")
+
+	return cmd
+}
+
+func newRemoveUserCommand(l logger.Logger, options *clusterUsersCliOptions) *cobra.Command {
+	return &cobra.Command{
+		Use:   "remove",
+		Short: "Remove a user from GreptimeDB cluster",
+		Long:  `Remove a user from GreptimeDB cluster, the clients are not authenticated once the last user is removed`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if len(args) < 2 {
+				return fmt.Errorf("cluster name and user name should be set")
+			}
+
+			cluster, err := newUsersCluster(l, args[0], options)
+			if err != nil {
+				return err
+			}
+
+			return cluster.RemoveUser(context.Background(), &opt.UserOptions{
+				Namespace: options.Namespace,
+				Name:      args[0],
+				User:      args[1],
+			})
+		},
+	}
+}
+
+func newListUsersCommand(l logger.Logger, options *clusterUsersCliOptions) *cobra.Command {
+	return &cobra.Command{
+		Use:   "list",
+		Short: "List the users of GreptimeDB cluster",
+		Long:  `List the names of the users of GreptimeDB cluster`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if len(args) == 0 {
+				return fmt.Errorf("cluster name should be set")
+			}
+
+			cluster, err := newUsersCluster(l, args[0], options)
+			if err != nil {
+				return err
+			}
+
+			names, err := cluster.ListUsers(context.Background(), &opt.UserOptions{
+				Namespace: options.Namespace,
+				Name:      args[0],
+			})
+			if err != nil {
+				return err
+			}
+
+			if len(names) == 0 {
+				l.V(0).Infof("Cluster '%s' has no users, the clients are not authenticated", args[0])
+				return nil
+			}
+			for _, name := range names {
+				l.V(0).Infof("%s", name)
+			}
+			return nil
+		},
+	}
+}
+
+func newUsersCluster(l logger.Logger, clusterName string, options *clusterUsersCliOptions) (opt.Operations, error) {
+	if options.BareMetal {
+		return baremetal.NewCluster(l, clusterName, baremetal.WithCreateNoDirs())
+	}
+	return kubernetes.NewCluster(l)
+}
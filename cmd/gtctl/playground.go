@@ -24,7 +24,9 @@ import (
 )
 
 func NewPlaygroundCommand(l logger.Logger) *cobra.Command {
-	return &cobra.Command{
+	var standalone bool
+
+	cmd := &cobra.Command{
 		Use:   "playground",
 		Short: "Starts a GreptimeDB cluster playground",
 		Long:  "Starts a GreptimeDB cluster playground in bare-metal mode",
@@ -39,9 +41,14 @@ func NewPlaygroundCommand(l logger.Logger) *cobra.Command {
 				BareMetal:   true,
 				Timeout:     900, // 15min
 				EnableCache: false,
+				Standalone:  standalone,
 			}
 
 			return NewCluster([]string{playgroundName}, playgroundOptions, l)
 		},
 	}
+
+	cmd.Flags().BoolVar(&standalone, "standalone", false, "Run a single greptime standalone process instead of a distributed cluster.")
+
+	return cmd
 }
@@ -0,0 +1,580 @@
+/*
+ * Copyright 2023 Greptime Team
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package baremetal
+
+import (
+	"archive/tar"
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/klauspost/compress/zstd"
+	"gopkg.in/yaml.v3"
+
+	opt "github.com/GreptimeTeam/gtctl/pkg/cluster"
+	"github.com/GreptimeTeam/gtctl/pkg/components"
+	"github.com/GreptimeTeam/gtctl/pkg/config"
+	"github.com/GreptimeTeam/gtctl/pkg/logger"
+	"github.com/GreptimeTeam/gtctl/pkg/metadata"
+	fileutils "github.com/GreptimeTeam/gtctl/pkg/utils/file"
+)
+
+const (
+	// backupVersion is the version of the layout of backup archive.
+	backupVersion = 1
+
+	// The layout of backup archive:
+	//   - data/: the data dir of cluster, each datanode replica stores its data in 'data/<replica>/home'
+	//     and 'data/<replica>/wal', and its procedures in 'data/datanode-procedure/<replica>'.
+	//   - configs/<component>/: the config file of each component.
+	//   - users: the static users of cluster, if there are any.
+	//   - metadata.yaml: the metadata of cluster without the pids and state.
+	//   - manifest.yaml: the size and checksum of all the files above, it's the last entry.
+	backupDataDir          = "data"
+	backupConfigsDir       = metadata.ClusterConfigsDir
+	backupUsersFile        = metadata.ClusterUsersFile
+	backupMetadataFile     = "metadata.yaml"
+	backupManifestFile     = "manifest.yaml"
+	backupDatanodeProcDir  = "datanode-procedure"
+	backupDatanodeHomeDir  = "home"
+	backupDatanodeWalDir   = "wal"
+	backupRestoreDirPrefix = ".restore-"
+)
+
+// backupManifest describes the files in the backup archive.
+type backupManifest struct {
+	Version   int          `yaml:"version"`
+	Cluster   string       `yaml:"cluster"`
+	CreatedAt time.Time    `yaml:"createdAt"`
+	Files     []backupFile `yaml:"files"`
+}
+
+type backupFile struct {
+	Path   string `yaml:"path"`
+	Size   int64  `yaml:"size"`
+	SHA256 string `yaml:"sha256"`
+}
+
+// Backup archives the data, configs and metadata of the cluster into a zstd compressed tarball.
+// The running cluster is stopped before backing up and started again afterwards if Stop is set.
+func (c *Cluster) Backup(ctx context.Context, options *opt.BackupOptions) (err error) {
+	md, err := c.get(ctx, &opt.GetOptions{Name: options.Name})
+	if err != nil {
+		return err
+	}
+	if exists, _ := fileutils.IsFileExists(options.Output); exists {
+		return fmt.Errorf("'%s' already exists", options.Output)
+	}
+
+	if state := c.observedState(md); state.IsActive() {
+		if !options.Stop {
+			return fmt.Errorf("cluster '%s' is %s, stop it first or back it up with '--stop'",
+				options.Name, strings.ToLower(string(state.Phase)))
+		}
+		if err = c.Stop(ctx, &opt.StopOptions{Name: options.Name}); err != nil {
+			return err
+		}
+
+		// The cluster is started again even if the backup fails.
+		defer func() {
+			c.logger.V(0).Infof("Starting cluster '%s' again...", options.Name)
+			startErr := c.Start(ctx, &opt.StartOptions{
+				Name:                   options.Name,
+				UseGreptimeCNArtifacts: options.UseGreptimeCNArtifacts,
+			})
+			if startErr != nil {
+				startErr = fmt.Errorf("failed to start cluster '%s' again: %v", options.Name, startErr)
+				if err == nil {
+					err = startErr
+				} else {
+					c.logger.Error(startErr.Error())
+				}
+			}
+		}()
+
+		if md, err = c.mm.GetClusterMetadata(); err != nil {
+			return err
+		}
+	}
+
+	c.logger.V(0).Infof("Backing up cluster '%s' to %s...", options.Name, logger.Bold(options.Output))
+	manifest, err := c.backup(md, options.Name, options.Output)
+	if err != nil {
+		return err
+	}
+
+	var size int64
+	for _, f := range manifest.Files {
+		size += f.Size
+	}
+	c.logger.V(0).Infof("Backed up %d file(s) of %d bytes, you can restore it by '%s'", len(manifest.Files), size,
+		logger.Bold(fmt.Sprintf("gtctl cluster restore %s", options.Output)))
+	return nil
+}
+
+func (c *Cluster) backup(md *config.BareMetalClusterMetadata, name, output string) (*backupManifest, error) {
+	for _, d := range md.DatanodeDirs {
+		if len(d.Host) > 0 {
+			return nil, fmt.Errorf("the data of %s is on host '%s', the data on remote hosts can not be backed up", d.Name, d.Host)
+		}
+	}
+	if standalone := md.Config.Cluster.Standalone; md.Config.IsStandalone() && standalone != nil {
+		if host := components.ReplicaProcessOptions(standalone.Process, 0).Host; len(host) > 0 {
+			return nil, fmt.Errorf("the data of standalone is on host '%s', the data on remote hosts can not be backed up", host)
+		}
+	}
+
+	tmpPath := output + ".tmp"
+	f, err := os.Create(tmpPath)
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		_ = f.Close()
+		_ = os.Remove(tmpPath)
+	}()
+
+	zw, err := zstd.NewWriter(f)
+	if err != nil {
+		return nil, err
+	}
+	w := &archiveWriter{tw: tar.NewWriter(zw)}
+
+	// The datanode replicas are archived by their directories, which may be outside the data dir.
+	csd := c.mm.GetClusterScopeDirs()
+	replicas := make(map[string]bool, len(md.DatanodeDirs))
+	for _, d := range md.DatanodeDirs {
+		replicas[d.Name] = true
+	}
+	if err = w.addDir(csd.DataDir, backupDataDir, func(rel string) bool {
+		return replicas[rel]
+	}); err != nil {
+		return nil, err
+	}
+	for _, d := range md.DatanodeDirs {
+		dirs := map[string]string{
+			d.DataHome:     path.Join(backupDataDir, d.Name, backupDatanodeHomeDir),
+			d.WalDir:       path.Join(backupDataDir, d.Name, backupDatanodeWalDir),
+			d.ProcedureDir: path.Join(backupDataDir, backupDatanodeProcDir, d.Name),
+		}
+		for src, dst := range dirs {
+			if len(src) == 0 {
+				continue
+			}
+			if err = w.addDir(src, dst, nil); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	for component, configPath := range componentConfigs(md.Config) {
+		if len(*configPath) == 0 {
+			continue
+		}
+		if err = w.addFile(*configPath, backupConfigPath(component, *configPath)); err != nil {
+			return nil, err
+		}
+	}
+
+	if exists, _ := fileutils.IsFileExists(csd.UsersFile); exists {
+		if err = w.addFile(csd.UsersFile, backupUsersFile); err != nil {
+			return nil, err
+		}
+	}
+
+	// The pids and state belong to the processes that run the cluster, they are meaningless once restored.
+	backupMd := *md
+	backupMd.ClusterDir = ""
+	backupMd.ForegroundPid, backupMd.SupervisorPid = 0, 0
+	backupMd.State = nil
+	out, err := yaml.Marshal(&backupMd)
+	if err != nil {
+		return nil, err
+	}
+	if err = w.addBytes(backupMetadataFile, out); err != nil {
+		return nil, err
+	}
+
+	manifest := &backupManifest{
+		Version:   backupVersion,
+		Cluster:   name,
+		CreatedAt: time.Now(),
+		Files:     w.files,
+	}
+	if out, err = yaml.Marshal(manifest); err != nil {
+		return nil, err
+	}
+	if err = w.writeEntry(backupManifestFile, int64(len(out)), 0644, bytes.NewReader(out)); err != nil {
+		return nil, err
+	}
+
+	if err = w.tw.Close(); err != nil {
+		return nil, err
+	}
+	if err = zw.Close(); err != nil {
+		return nil, err
+	}
+	if err = f.Close(); err != nil {
+		return nil, err
+	}
+	if err = os.Rename(tmpPath, output); err != nil {
+		return nil, err
+	}
+
+	return manifest, nil
+}
+
+// Restore recreates the cluster from the backup archive, the cluster can be started by 'gtctl cluster start' then.
+// The data and configs are restored into the cluster directory, even if they were outside it when backed up.
+func (c *Cluster) Restore(_ context.Context, options *opt.RestoreOptions) error {
+	if err := fileutils.EnsureDir(c.mm.GetWorkingDir()); err != nil {
+		return err
+	}
+	// The archive is extracted into the working dir, so the files can be renamed into the cluster dir.
+	tmpDir, err := os.MkdirTemp(c.mm.GetWorkingDir(), backupRestoreDirPrefix)
+	if err != nil {
+		return err
+	}
+	defer os.RemoveAll(tmpDir)
+
+	c.logger.V(0).Infof("Extracting %s...", logger.Bold(options.File))
+	manifest, err := extractBackup(options.File, tmpDir)
+	if err != nil {
+		return fmt.Errorf("invalid backup '%s': %v", options.File, err)
+	}
+
+	in, err := os.ReadFile(filepath.Join(tmpDir, backupMetadataFile))
+	if err != nil {
+		return err
+	}
+	var md config.BareMetalClusterMetadata
+	if err = yaml.Unmarshal(in, &md); err != nil {
+		return fmt.Errorf("invalid metadata in backup: %v", err)
+	}
+	if md.Config == nil || md.Config.Cluster == nil {
+		return fmt.Errorf("invalid metadata in backup: no cluster config")
+	}
+
+	name := options.Name
+	if len(name) == 0 {
+		name = manifest.Cluster
+	}
+	c.mm.AllocateClusterScopeDirs(name)
+	csd := c.mm.GetClusterScopeDirs()
+	if exists, _ := fileutils.IsFileExists(csd.ConfigPath); exists {
+		return fmt.Errorf("cluster '%s' already exists, delete it first or restore with another name by '--name'", name)
+	}
+
+	cfg := md.Config
+	for component, configPath := range componentConfigs(cfg) {
+		if len(*configPath) > 0 {
+			*configPath = path.Join(csd.ConfigsDir, component, path.Base(*configPath))
+		}
+	}
+	if datanode := cfg.Cluster.Datanode; datanode != nil {
+		datanode.DataDir, datanode.WalDir = "", ""
+		if len(datanode.ProcedureDir) > 0 {
+			datanode.ProcedureDir = path.Join(csd.DataDir, backupDatanodeProcDir)
+		}
+	}
+	datanodeDirs := make([]config.DatanodeDirs, 0, len(md.DatanodeDirs))
+	for _, d := range md.DatanodeDirs {
+		restored := config.DatanodeDirs{
+			Name:     d.Name,
+			DataHome: path.Join(csd.DataDir, d.Name, backupDatanodeHomeDir),
+			WalDir:   path.Join(csd.DataDir, d.Name, backupDatanodeWalDir),
+		}
+		if len(d.ProcedureDir) > 0 {
+			restored.ProcedureDir = path.Join(csd.DataDir, backupDatanodeProcDir, d.Name)
+		}
+		datanodeDirs = append(datanodeDirs, restored)
+	}
+
+	if err = c.mm.CreateClusterScopeDirs(cfg); err != nil {
+		return err
+	}
+	for src, dst := range map[string]string{
+		filepath.Join(tmpDir, backupDataDir):    csd.DataDir,
+		filepath.Join(tmpDir, backupConfigsDir): csd.ConfigsDir,
+	} {
+		if err = moveDirEntries(src, dst); err != nil {
+			return err
+		}
+	}
+	if exists, _ := fileutils.IsFileExists(filepath.Join(tmpDir, backupUsersFile)); exists {
+		if err = os.Rename(filepath.Join(tmpDir, backupUsersFile), csd.UsersFile); err != nil {
+			return err
+		}
+	}
+
+	if err = c.mm.UpdateClusterMetadata(func(restored *config.BareMetalClusterMetadata) {
+		restored.ForegroundPid = 0
+		restored.UseMemoryMeta = md.UseMemoryMeta
+		restored.GreptimeBinPath = md.GreptimeBinPath
+		restored.EtcdBinPath = md.EtcdBinPath
+		restored.DatanodeDirs = datanodeDirs
+	}); err != nil {
+		return err
+	}
+
+	c.logger.V(0).Infof("Cluster '%s' is restored in %s, you can start it by '%s'", name, logger.Bold(csd.BaseDir),
+		logger.Bold(fmt.Sprintf("gtctl cluster start %s", name)))
+	return nil
+}
+
+// componentConfigs returns the config file paths of components by their names.
+func componentConfigs(cfg *config.BareMetalClusterConfig) map[string]*string {
+	configs := make(map[string]*string)
+	if cfg.Cluster.Frontend != nil {
+		configs["frontend"] = &cfg.Cluster.Frontend.Config
+	}
+	if cfg.Cluster.MetaSrv != nil {
+		configs["metasrv"] = &cfg.Cluster.MetaSrv.Config
+	}
+	if cfg.Cluster.Datanode != nil {
+		configs["datanode"] = &cfg.Cluster.Datanode.Config
+	}
+	if cfg.Cluster.Standalone != nil {
+		configs["standalone"] = &cfg.Cluster.Standalone.Config
+	}
+	return configs
+}
+
+// backupConfigPath returns the path of config file of component in the backup archive.
+func backupConfigPath(component, configPath string) string {
+	return path.Join(backupConfigsDir, component, path.Base(configPath))
+}
+
+// archiveWriter writes the files into tarball and records their size and checksum.
+type archiveWriter struct {
+	tw    *tar.Writer
+	files []backupFile
+}
+
+// addDir adds the directory recursively as dst, the top level entries that skip returns true are skipped.
+// Only the directories and regular files are added, and the directory that doesn't exist is ignored.
+func (w *archiveWriter) addDir(src, dst string, skip func(rel string) bool) error {
+	if _, err := os.Stat(src); os.IsNotExist(err) {
+		return nil
+	}
+
+	return filepath.WalkDir(src, func(p string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		rel, err := filepath.Rel(src, p)
+		if err != nil {
+			return err
+		}
+		if skip != nil && rel != "." && !strings.Contains(rel, string(filepath.Separator)) && skip(rel) {
+			if d.IsDir() {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+
+		name := path.Join(dst, filepath.ToSlash(rel))
+		switch {
+		case d.IsDir():
+			return w.tw.WriteHeader(&tar.Header{Typeflag: tar.TypeDir, Name: name + "/", Mode: 0755})
+		case d.Type().IsRegular():
+			return w.addFile(p, name)
+		default:
+			return nil
+		}
+	})
+}
+
+func (w *archiveWriter) addFile(src, name string) error {
+	f, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	info, err := f.Stat()
+	if err != nil {
+		return err
+	}
+
+	h := sha256.New()
+	if err = w.writeEntry(name, info.Size(), int64(info.Mode().Perm()), io.TeeReader(f, h)); err != nil {
+		return err
+	}
+	w.files = append(w.files, backupFile{Path: name, Size: info.Size(), SHA256: hex.EncodeToString(h.Sum(nil))})
+	return nil
+}
+
+func (w *archiveWriter) addBytes(name string, data []byte) error {
+	sum := sha256.Sum256(data)
+	if err := w.writeEntry(name, int64(len(data)), 0644, bytes.NewReader(data)); err != nil {
+		return err
+	}
+	w.files = append(w.files, backupFile{Path: name, Size: int64(len(data)), SHA256: hex.EncodeToString(sum[:])})
+	return nil
+}
+
+func (w *archiveWriter) writeEntry(name string, size, mode int64, r io.Reader) error {
+	if err := w.tw.WriteHeader(&tar.Header{Typeflag: tar.TypeReg, Name: name, Size: size, Mode: mode}); err != nil {
+		return err
+	}
+	// The file may grow while it's being archived, only the size in header is copied.
+	_, err := io.CopyN(w.tw, r, size)
+	return err
+}
+
+// extractBackup extracts the backup archive into dst, and verifies the extracted files against the manifest.
+func extractBackup(file, dst string) (*backupManifest, error) {
+	f, err := os.Open(file)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	zr, err := zstd.NewReader(f)
+	if err != nil {
+		return nil, err
+	}
+	defer zr.Close()
+
+	var (
+		tr        = tar.NewReader(zr)
+		extracted = make(map[string]backupFile)
+		manifest  *backupManifest
+	)
+	for {
+		header, err := tr.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		name := path.Clean(header.Name)
+		if path.IsAbs(name) || name == ".." || strings.HasPrefix(name, "../") {
+			return nil, fmt.Errorf("illegal path '%s'", header.Name)
+		}
+
+		switch header.Typeflag {
+		case tar.TypeDir:
+			if err = os.MkdirAll(filepath.Join(dst, filepath.FromSlash(name)), 0755); err != nil {
+				return nil, err
+			}
+		case tar.TypeReg:
+			if name == backupManifestFile {
+				manifest = &backupManifest{}
+				if err = yaml.NewDecoder(tr).Decode(manifest); err != nil {
+					return nil, fmt.Errorf("invalid manifest: %v", err)
+				}
+				continue
+			}
+			extractedFile, err := extractFile(tr, filepath.Join(dst, filepath.FromSlash(name)), header.FileInfo().Mode().Perm())
+			if err != nil {
+				return nil, err
+			}
+			extractedFile.Path = name
+			extracted[name] = extractedFile
+		default:
+			return nil, fmt.Errorf("unsupported entry '%s'", header.Name)
+		}
+	}
+
+	if manifest == nil {
+		return nil, fmt.Errorf("no manifest")
+	}
+	if manifest.Version != backupVersion {
+		return nil, fmt.Errorf("unsupported version %d", manifest.Version)
+	}
+	if err := verifyBackup(manifest, extracted); err != nil {
+		return nil, err
+	}
+	return manifest, nil
+}
+
+func extractFile(r io.Reader, dst string, mode os.FileMode) (backupFile, error) {
+	if err := os.MkdirAll(filepath.Dir(dst), 0755); err != nil {
+		return backupFile{}, err
+	}
+	f, err := os.OpenFile(dst, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, mode)
+	if err != nil {
+		return backupFile{}, err
+	}
+	defer f.Close()
+
+	h := sha256.New()
+	size, err := io.Copy(io.MultiWriter(f, h), r)
+	if err != nil {
+		return backupFile{}, err
+	}
+	return backupFile{Size: size, SHA256: hex.EncodeToString(h.Sum(nil))}, f.Close()
+}
+
+// verifyBackup checks that the extracted files are exactly the ones in manifest.
+func verifyBackup(manifest *backupManifest, extracted map[string]backupFile) error {
+	for _, expected := range manifest.Files {
+		actual, ok := extracted[expected.Path]
+		if !ok {
+			return fmt.Errorf("file '%s' is missing", expected.Path)
+		}
+		if actual.Size != expected.Size || actual.SHA256 != expected.SHA256 {
+			return fmt.Errorf("checksum of file '%s' mismatched", expected.Path)
+		}
+		delete(extracted, expected.Path)
+	}
+
+	if len(extracted) > 0 {
+		unexpected := make([]string, 0, len(extracted))
+		for name := range extracted {
+			unexpected = append(unexpected, name)
+		}
+		sort.Strings(unexpected)
+		return fmt.Errorf("file(s) not in manifest: %s", strings.Join(unexpected, ", "))
+	}
+	return nil
+}
+
+// moveDirEntries moves the entries in src into dst, src that doesn't exist is ignored.
+func moveDirEntries(src, dst string) error {
+	entries, err := os.ReadDir(src)
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	if err = fileutils.EnsureDir(dst); err != nil {
+		return err
+	}
+	for _, entry := range entries {
+		if err = os.Rename(filepath.Join(src, entry.Name()), filepath.Join(dst, entry.Name())); err != nil {
+			return err
+		}
+	}
+	return nil
+}
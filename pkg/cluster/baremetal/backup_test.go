@@ -0,0 +1,171 @@
+/*
+ * Copyright 2023 Greptime Team
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package baremetal
+
+import (
+	"context"
+	"io"
+	"os"
+	"path"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	opt "github.com/GreptimeTeam/gtctl/pkg/cluster"
+	"github.com/GreptimeTeam/gtctl/pkg/components"
+	"github.com/GreptimeTeam/gtctl/pkg/config"
+	"github.com/GreptimeTeam/gtctl/pkg/logger"
+	"github.com/GreptimeTeam/gtctl/pkg/metadata"
+	"github.com/GreptimeTeam/gtctl/pkg/utils/users"
+)
+
+func writeTestFile(t *testing.T, name, content string) {
+	assert.NoError(t, os.MkdirAll(filepath.Dir(name), 0755))
+	assert.NoError(t, os.WriteFile(name, []byte(content), 0644))
+}
+
+// newBackupTestCluster creates a stopped cluster whose datanode WAL is outside the cluster directory.
+func newBackupTestCluster(t *testing.T, home string) *Cluster {
+	mm, err := metadata.New(home)
+	assert.NoError(t, err)
+	mm.AllocateClusterScopeDirs("mycluster")
+	csd := mm.GetClusterScopeDirs()
+
+	cfg := config.DefaultBareMetalConfig()
+	cfg.Cluster.Datanode.Replicas = 2
+	cfg.Cluster.Datanode.WalDir = filepath.Join(t.TempDir(), "wal")
+	cfg.Cluster.Frontend.Config = filepath.Join(t.TempDir(), "frontend.toml")
+	writeTestFile(t, cfg.Cluster.Frontend.Config, "[http]\ntimeout = '30s'\n")
+	assert.NoError(t, mm.CreateClusterScopeDirs(cfg))
+
+	var datanodeDirs []config.DatanodeDirs
+	for i := 0; i < cfg.Cluster.Datanode.Replicas; i++ {
+		dirs := components.DatanodeReplicaDirs(cfg.Cluster.Datanode, csd.DataDir, i)
+		writeTestFile(t, path.Join(dirs.DataHome, "region", "data.parquet"), dirs.Name+" data")
+		writeTestFile(t, path.Join(dirs.WalDir, "00000001.log"), dirs.Name+" wal")
+		datanodeDirs = append(datanodeDirs, dirs)
+	}
+	writeTestFile(t, path.Join(csd.DataDir, "etcd.0", "member", "snap", "db"), "etcd")
+	writeTestFile(t, path.Join(csd.PidsDir, "frontend.0", "pid"), "12345")
+	assert.NoError(t, users.WriteFile(csd.UsersFile, map[string]string{"alice": "secret"}))
+
+	assert.NoError(t, mm.UpdateClusterMetadata(func(md *config.BareMetalClusterMetadata) {
+		md.GreptimeBinPath = "/usr/local/bin/greptime"
+		md.DatanodeDirs = datanodeDirs
+		md.State = &config.ClusterState{Phase: config.ClusterPhaseStopped, Pid: 12345}
+	}))
+
+	return &Cluster{config: cfg, mm: mm, logger: logger.New(io.Discard, 0)}
+}
+
+func TestBackupAndRestore(t *testing.T) {
+	home := t.TempDir()
+	c := newBackupTestCluster(t, home)
+	output := filepath.Join(t.TempDir(), "mycluster.tar.zst")
+
+	ctx := context.Background()
+	assert.NoError(t, c.Backup(ctx, &opt.BackupOptions{Name: "mycluster", Output: output}))
+	assert.ErrorContains(t, c.Backup(ctx, &opt.BackupOptions{Name: "mycluster", Output: output}), "already exists")
+
+	// The cluster can not be restored with the name of existing one.
+	assert.ErrorContains(t, c.Restore(ctx, &opt.RestoreOptions{File: output}), "cluster 'mycluster' already exists")
+
+	assert.NoError(t, c.Restore(ctx, &opt.RestoreOptions{File: output, Name: "restored"}))
+	csd := c.mm.GetClusterScopeDirs()
+	assert.Equal(t, "restored", path.Base(csd.BaseDir))
+
+	md, err := c.mm.GetClusterMetadata()
+	assert.NoError(t, err)
+	assert.Equal(t, csd.BaseDir, md.ClusterDir)
+	assert.Zero(t, md.ForegroundPid)
+	assert.Nil(t, md.State)
+	assert.Equal(t, "/usr/local/bin/greptime", md.GreptimeBinPath)
+	assert.Empty(t, md.Config.Cluster.Datanode.WalDir)
+	assert.Equal(t, path.Join(csd.BaseDir, "configs", "frontend", "frontend.toml"), md.Config.Cluster.Frontend.Config)
+
+	// The data is restored into the cluster directory in the default layout, and the pids are not restored.
+	for i, dirs := range md.DatanodeDirs {
+		assert.Equal(t, components.DatanodeReplicaDirs(md.Config.Cluster.Datanode, csd.DataDir, i), dirs)
+		data, err := os.ReadFile(path.Join(dirs.DataHome, "region", "data.parquet"))
+		assert.NoError(t, err)
+		assert.Equal(t, dirs.Name+" data", string(data))
+		data, err = os.ReadFile(path.Join(dirs.WalDir, "00000001.log"))
+		assert.NoError(t, err)
+		assert.Equal(t, dirs.Name+" wal", string(data))
+	}
+	assert.Len(t, md.DatanodeDirs, 2)
+	assert.FileExists(t, path.Join(csd.DataDir, "etcd.0", "member", "snap", "db"))
+	assert.FileExists(t, md.Config.Cluster.Frontend.Config)
+	assert.NoDirExists(t, path.Join(csd.PidsDir, "frontend.0"))
+
+	// The users are restored and only readable by the user.
+	restoredUsers, err := users.ReadFile(csd.UsersFile)
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]string{"alice": "secret"}, restoredUsers)
+	info, err := os.Stat(csd.UsersFile)
+	assert.NoError(t, err)
+	assert.Equal(t, os.FileMode(0600), info.Mode().Perm())
+
+	// The temporary directory of restoring is cleaned up.
+	entries, err := filepath.Glob(filepath.Join(home, metadata.BaseDir, backupRestoreDirPrefix+"*"))
+	assert.NoError(t, err)
+	assert.Empty(t, entries)
+}
+
+func TestBackupRunningCluster(t *testing.T) {
+	c := newBackupTestCluster(t, t.TempDir())
+	assert.NoError(t, c.mm.UpdateClusterMetadata(func(md *config.BareMetalClusterMetadata) {
+		md.State = &config.ClusterState{Phase: config.ClusterPhaseRunning, Pid: os.Getpid(), UpdatedAt: time.Now()}
+	}))
+
+	output := filepath.Join(t.TempDir(), "mycluster.tar.zst")
+	err := c.Backup(context.Background(), &opt.BackupOptions{Name: "mycluster", Output: output})
+	assert.ErrorContains(t, err, "cluster 'mycluster' is running, stop it first or back it up with '--stop'")
+	assert.NoFileExists(t, output)
+}
+
+func TestVerifyBackup(t *testing.T) {
+	manifest := &backupManifest{
+		Version: backupVersion,
+		Files: []backupFile{
+			{Path: "data/etcd.0/db", Size: 4, SHA256: "a"},
+			{Path: "metadata.yaml", Size: 10, SHA256: "b"},
+		},
+	}
+
+	extracted := func() map[string]backupFile {
+		return map[string]backupFile{
+			"data/etcd.0/db": {Path: "data/etcd.0/db", Size: 4, SHA256: "a"},
+			"metadata.yaml":  {Path: "metadata.yaml", Size: 10, SHA256: "b"},
+		}
+	}
+	assert.NoError(t, verifyBackup(manifest, extracted()))
+
+	files := extracted()
+	files["data/etcd.0/db"] = backupFile{Path: "data/etcd.0/db", Size: 4, SHA256: "c"}
+	assert.ErrorContains(t, verifyBackup(manifest, files), "checksum of file 'data/etcd.0/db' mismatched")
+
+	files = extracted()
+	delete(files, "metadata.yaml")
+	assert.ErrorContains(t, verifyBackup(manifest, files), "file 'metadata.yaml' is missing")
+
+	files = extracted()
+	files["data/evil"] = backupFile{Path: "data/evil"}
+	assert.ErrorContains(t, verifyBackup(manifest, files), "file(s) not in manifest: data/evil")
+}
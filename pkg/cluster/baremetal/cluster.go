@@ -18,7 +18,9 @@ package baremetal
 
 import (
 	"context"
+	"fmt"
 	"os/signal"
+	"strings"
 	"sync"
 	"syscall"
 
@@ -28,18 +30,32 @@ import (
 	"github.com/GreptimeTeam/gtctl/pkg/config"
 	"github.com/GreptimeTeam/gtctl/pkg/logger"
 	"github.com/GreptimeTeam/gtctl/pkg/metadata"
+	fileutils "github.com/GreptimeTeam/gtctl/pkg/utils/file"
 )
 
 type Cluster struct {
-	config        *config.BareMetalClusterConfig
-	createNoDirs  bool
-	enableCache   bool
-	useMemoryMeta bool
+	config             *config.BareMetalClusterConfig
+	createNoDirs       bool
+	enableCache        bool
+	useMemoryMeta      bool
+	usePersistedConfig bool
+	autoPorts          bool
 
 	am artifacts.Manager
 	mm metadata.Manager
 	cc *ClusterComponents
 
+	// hosts are the remote hosts that the replicas run on.
+	hosts components.RemoteHosts
+
+	// greptimeBinPath is the greptime binary that the cluster is running with.
+	greptimeBinPath string
+	supervisor      *supervisor
+
+	// failedComponent is the component whose failure stopped the cluster.
+	failedMu        sync.Mutex
+	failedComponent string
+
 	logger logger.Logger
 	stop   context.CancelFunc
 	ctx    context.Context
@@ -47,23 +63,53 @@ type Cluster struct {
 }
 
 // ClusterComponents describes all the components need to be deployed under bare-metal mode.
+// Only Standalone is set in standalone topology.
 type ClusterComponents struct {
-	MetaSrv  components.ClusterComponent
-	Datanode components.ClusterComponent
-	Frontend components.ClusterComponent
-	Etcd     components.ClusterComponent
+	MetaSrv    components.ClusterComponent
+	Datanode   components.ClusterComponent
+	Frontend   components.ClusterComponent
+	Etcd       components.ClusterComponent
+	Standalone components.ClusterComponent
 }
 
-func NewClusterComponents(config *config.BareMetalClusterComponentsConfig, workingDirs components.WorkingDirs,
-	wg *sync.WaitGroup, logger logger.Logger, useMemoryMeta bool) *ClusterComponents {
+func NewClusterComponents(config *config.BareMetalClusterConfig, workingDirs components.WorkingDirs,
+	hosts components.RemoteHosts, wg *sync.WaitGroup, logger logger.Logger, useMemoryMeta bool) *ClusterComponents {
+	if config.IsStandalone() {
+		return &ClusterComponents{
+			Standalone: components.NewStandalone(config.Cluster.Standalone, config.TLS, workingDirs, config.Log, hosts, wg, logger),
+		}
+	}
+
+	// The metasrv connects to all the etcd members unless the store address is specified.
+	cluster, logConfig := config.Cluster, config.Log
+	storeAddr := cluster.MetaSrv.StoreAddr
+	if len(storeAddr) == 0 {
+		storeAddr = strings.Join(components.EtcdClientEndpoints(config.Etcd), ",")
+	}
+
+	metaSrvAddr := cluster.MetaSrv.ServerAddr
+
 	return &ClusterComponents{
-		MetaSrv:  components.NewMetaSrv(config.MetaSrv, workingDirs, wg, logger, useMemoryMeta),
-		Datanode: components.NewDataNode(config.Datanode, config.MetaSrv.ServerAddr, workingDirs, wg, logger),
-		Frontend: components.NewFrontend(config.Frontend, config.MetaSrv.ServerAddr, workingDirs, wg, logger),
-		Etcd:     components.NewEtcd(workingDirs, wg, logger),
+		MetaSrv:  components.NewMetaSrv(cluster.MetaSrv, storeAddr, workingDirs, logConfig, hosts, wg, logger, useMemoryMeta),
+		Datanode: components.NewDataNode(cluster.Datanode, metaSrvAddr, workingDirs, logConfig, hosts, wg, logger),
+		Frontend: components.NewFrontend(cluster.Frontend, metaSrvAddr, config.TLS, workingDirs, logConfig, hosts, wg, logger),
+		Etcd:     components.NewEtcd(config.Etcd, workingDirs, logConfig, wg, logger),
 	}
 }
 
+// ordered returns the running components in the order of starting.
+func (cc *ClusterComponents) ordered(withEtcd bool) []components.ClusterComponent {
+	if cc.Standalone != nil {
+		return []components.ClusterComponent{cc.Standalone}
+	}
+
+	ordered := []components.ClusterComponent{cc.MetaSrv, cc.Datanode, cc.Frontend}
+	if withEtcd {
+		ordered = append([]components.ClusterComponent{cc.Etcd}, ordered...)
+	}
+	return ordered
+}
+
 type Option func(cluster *Cluster)
 
 // WithReplaceConfig replaces current cluster config with given config.
@@ -97,6 +143,31 @@ func WithCreateNoDirs() Option {
 	}
 }
 
+// WithPersistedConfig replaces current cluster config with the one persisted in cluster metadata,
+// and the cluster is resumed with its data and pinned binaries.
+func WithPersistedConfig() Option {
+	return func(c *Cluster) {
+		c.usePersistedConfig = true
+	}
+}
+
+// WithAutoPorts picks free ports for the addresses that conflict with others or are already in use
+// before the cluster is created, and the picked ports are recorded in the persisted config.
+func WithAutoPorts(autoPorts bool) Option {
+	return func(c *Cluster) {
+		c.autoPorts = autoPorts
+	}
+}
+
+// WithStandalone runs the cluster in standalone topology with the default standalone config.
+func WithStandalone() Option {
+	return func(c *Cluster) {
+		c.config.Topology = config.TopologyStandalone
+		c.config.Cluster.Frontend, c.config.Cluster.MetaSrv, c.config.Cluster.Datanode = nil, nil, nil
+		c.config.Etcd = nil
+	}
+}
+
 func NewCluster(l logger.Logger, clusterName string, opts ...Option) (cluster.Operations, error) {
 	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
 
@@ -113,16 +184,37 @@ func NewCluster(l logger.Logger, clusterName string, opts ...Option) (cluster.Op
 		}
 	}
 
-	if err := config.ValidateConfig(c.config); err != nil {
-		return nil, err
-	}
-
 	// Configure Metadata Manager
 	mm, err := metadata.New("")
 	if err != nil {
 		return nil, err
 	}
 	c.mm = mm
+	mm.AllocateClusterScopeDirs(clusterName)
+
+	if c.usePersistedConfig {
+		md, err := mm.GetClusterMetadata()
+		if err != nil {
+			return nil, fmt.Errorf("failed to load the config of cluster '%s': %v", clusterName, err)
+		}
+		c.config = md.Config
+		c.useMemoryMeta = md.UseMemoryMeta
+	}
+
+	// The standalone listens on the default addresses if it's not configured.
+	if c.config.IsStandalone() && c.config.Cluster != nil && c.config.Cluster.Standalone == nil {
+		c.config.Cluster.Standalone = config.DefaultStandaloneConfig()
+	}
+
+	if err = config.ValidateConfig(c.config); err != nil {
+		return nil, err
+	}
+
+	// The supported GreptimeDB has no TLS options of the HTTP and gRPC servers.
+	if !c.usePersistedConfig && c.config.TLS.IsEnabled() {
+		l.Warnf("TLS is only enabled for the MySQL and PostgreSQL servers, the HTTP and gRPC servers of cluster '%s' still serve plaintext",
+			clusterName)
+	}
 
 	// Configure Artifact Manager.
 	am, err := artifacts.NewManager(l)
@@ -132,18 +224,56 @@ func NewCluster(l logger.Logger, clusterName string, opts ...Option) (cluster.Op
 	c.am = am
 
 	// Configure Cluster Components.
-	mm.AllocateClusterScopeDirs(clusterName)
 	if !c.createNoDirs {
+		// The existing cluster that is not active is created again with the new config, and its data is kept.
+		// The active one can't be created again until it's stopped.
+		if !c.usePersistedConfig {
+			if exists, _ := fileutils.IsFileExists(mm.GetClusterScopeDirs().ConfigPath); exists {
+				md, err := mm.GetClusterMetadata()
+				if err != nil {
+					return nil, err
+				}
+				if state := c.observedState(md); state.IsActive() {
+					return nil, fmt.Errorf("cluster '%s' is %s, stop it before creating it again",
+						clusterName, strings.ToLower(string(state.Phase)))
+				}
+				c.logger.V(0).Infof("Cluster '%s' already exists, creating it again with the new config and its data, "+
+					"use '--resume' to run it with its persisted config", clusterName)
+			}
+		}
+
+		if c.autoPorts {
+			if err = allocatePorts(c.config, c.useMemoryMeta, c.logger); err != nil {
+				return nil, err
+			}
+		}
+
 		if err = mm.CreateClusterScopeDirs(c.config); err != nil {
 			return nil, err
 		}
+		// The cluster that is created again may switch its metastore.
+		if err = mm.UpdateClusterMetadata(func(md *config.BareMetalClusterMetadata) {
+			md.UseMemoryMeta = c.useMemoryMeta
+		}); err != nil {
+			return nil, err
+		}
 	}
 	csd := mm.GetClusterScopeDirs()
-	c.cc = NewClusterComponents(c.config.Cluster, components.WorkingDirs{
-		DataDir: csd.DataDir,
-		LogsDir: csd.LogsDir,
-		PidsDir: csd.PidsDir,
-	}, &c.wg, c.logger, c.useMemoryMeta)
+	c.hosts = components.NewRemoteHosts(c.config.Hosts, clusterName, c.logger)
+	c.cc = NewClusterComponents(c.config, components.WorkingDirs{
+		DataDir:    csd.DataDir,
+		LogsDir:    csd.LogsDir,
+		PidsDir:    csd.PidsDir,
+		ConfigsDir: csd.ConfigsDir,
+		CertsDir:   csd.CertsDir,
+		UsersFile:  csd.UsersFile,
+		Cgroup:     components.ClusterCgroup(clusterName),
+	}, c.hosts, &c.wg, c.logger, c.useMemoryMeta)
 
 	return c, nil
 }
+
+// withEtcd returns whether the cluster runs etcd as the storage of metasrv.
+func (c *Cluster) withEtcd() bool {
+	return !c.useMemoryMeta && !c.config.IsStandalone()
+}
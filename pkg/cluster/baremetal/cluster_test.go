@@ -0,0 +1,74 @@
+/*
+ * Copyright 2023 Greptime Team
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package baremetal
+
+import (
+	"io"
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/GreptimeTeam/gtctl/pkg/config"
+	"github.com/GreptimeTeam/gtctl/pkg/logger"
+)
+
+func TestNewClusterExisting(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	l := logger.New(io.Discard, 0)
+
+	newCluster := func(opts ...Option) (*Cluster, error) {
+		c, err := NewCluster(l, "mycluster", opts...)
+		if err != nil {
+			return nil, err
+		}
+		bm := c.(*Cluster)
+		t.Cleanup(bm.stop)
+		return bm, nil
+	}
+
+	c, err := newCluster(WithMetastore(true))
+	assert.NoError(t, err)
+	setState := func(phase config.ClusterPhase) {
+		assert.NoError(t, c.mm.UpdateClusterMetadata(func(md *config.BareMetalClusterMetadata) {
+			md.State = &config.ClusterState{Phase: phase, Pid: os.Getpid()}
+		}))
+	}
+
+	// The active cluster can't be created again.
+	setState(config.ClusterPhaseRunning)
+	_, err = newCluster()
+	assert.ErrorContains(t, err, "cluster 'mycluster' is running, stop it before creating it again")
+
+	// The stopped cluster is created again with the new config.
+	setState(config.ClusterPhaseStopped)
+	_, err = newCluster()
+	assert.NoError(t, err)
+	md, err := c.mm.GetClusterMetadata()
+	assert.NoError(t, err)
+	assert.False(t, md.UseMemoryMeta)
+
+	// The cluster whose process was gone is not active anymore.
+	assert.NoError(t, c.mm.UpdateClusterMetadata(func(md *config.BareMetalClusterMetadata) {
+		md.State = &config.ClusterState{Phase: config.ClusterPhaseRunning, Pid: -1}
+	}))
+	_, err = newCluster(WithMetastore(true))
+	assert.NoError(t, err)
+	md, err = c.mm.GetClusterMetadata()
+	assert.NoError(t, err)
+	assert.True(t, md.UseMemoryMeta)
+}
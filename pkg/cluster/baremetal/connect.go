@@ -0,0 +1,118 @@
+/*
+ * Copyright 2023 Greptime Team
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package baremetal
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"strings"
+
+	opt "github.com/GreptimeTeam/gtctl/pkg/cluster"
+	"github.com/GreptimeTeam/gtctl/pkg/components"
+	"github.com/GreptimeTeam/gtctl/pkg/config"
+	"github.com/GreptimeTeam/gtctl/pkg/connector"
+	"github.com/GreptimeTeam/gtctl/pkg/utils/certs"
+)
+
+// Connect connects to the frontend replica, or the standalone, of the running cluster by the address in cluster metadata.
+func (c *Cluster) Connect(ctx context.Context, options *opt.ConnectOptions) error {
+	md, err := c.get(ctx, &opt.GetOptions{Name: options.Name})
+	if err != nil {
+		return err
+	}
+	if state := c.observedState(md); !state.IsActive() {
+		return fmt.Errorf("cluster '%s' is %s, it can't be connected", options.Name, strings.ToLower(string(state.Phase)))
+	}
+
+	// The standalone is connected like the only one frontend replica.
+	var (
+		name                    = "frontend"
+		replicas                int
+		mysqlAddr, postgresAddr string
+		process                 config.Process
+	)
+	if md.Config.IsStandalone() {
+		standalone := md.Config.Cluster.Standalone
+		name, replicas = components.StandaloneName, 1
+		mysqlAddr, postgresAddr = standalone.MysqlAddr, standalone.PostgresAddr
+		process = standalone.Process
+	} else {
+		frontend := md.Config.Cluster.Frontend
+		replicas = frontend.Replicas
+		mysqlAddr, postgresAddr = frontend.MysqlAddr, frontend.PostgresAddr
+		process = frontend.Process
+	}
+	if options.Replica < 0 || options.Replica >= replicas {
+		return fmt.Errorf("invalid %s replica %d, cluster '%s' has %d %s replicas",
+			name, options.Replica, options.Name, replicas, name)
+	}
+
+	// The replica on remote host is connected by the hostname of the host.
+	var (
+		hosts          = components.NewRemoteHosts(md.Config.Hosts, options.Name, c.logger)
+		processOptions = components.ReplicaProcessOptions(process, options.Replica)
+	)
+
+	// The client trusts the CA of cluster with TLS, and authenticates with the stored password of user.
+	clientOptions := &connector.ClientOptions{User: options.User}
+	if md.Config.TLS.IsEnabled() {
+		clientOptions.CAFile, _ = certs.Files(c.mm.GetClusterScopeDirs().CertsDir, certs.CAName)
+	}
+	if len(options.User) > 0 {
+		if clientOptions.Password, err = c.userPassword(options.Name, options.User); err != nil {
+			return err
+		}
+	}
+
+	switch options.Protocol {
+	case opt.MySQL:
+		addr, err := connectAddr(mysqlAddr, options.Replica)
+		if err != nil {
+			return fmt.Errorf("invalid mysql address of %s: %v", name, err)
+		}
+		if err = connector.MysqlWithAddr(hosts.AdvertiseAddr(addr, processOptions), clientOptions, c.logger); err != nil {
+			return fmt.Errorf("error connecting to mysql: %v", err)
+		}
+	case opt.Postgres:
+		addr, err := connectAddr(postgresAddr, options.Replica)
+		if err != nil {
+			return fmt.Errorf("invalid postgres address of %s: %v", name, err)
+		}
+		if err = connector.PostgresSQLWithAddr(hosts.AdvertiseAddr(addr, processOptions), clientOptions, c.logger); err != nil {
+			return fmt.Errorf("error connecting to postgres: %v", err)
+		}
+	default:
+		return fmt.Errorf("unsupported connect protocol type")
+	}
+
+	return nil
+}
+
+// connectAddr returns the address that the client connects to for the frontend replica.
+func connectAddr(addr string, replica int) (string, error) {
+	if len(addr) == 0 {
+		return "", fmt.Errorf("address is not configured")
+	}
+
+	replicaAddr := components.FormatAddrArg(addr, replica)
+	if _, _, err := net.SplitHostPort(replicaAddr); err != nil {
+		return "", err
+	}
+
+	return components.AdvertiseAddr(replicaAddr), nil
+}
@@ -0,0 +1,67 @@
+/*
+ * Copyright 2023 Greptime Team
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package baremetal
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	opt "github.com/GreptimeTeam/gtctl/pkg/cluster"
+	"github.com/GreptimeTeam/gtctl/pkg/config"
+)
+
+func TestConnectAddr(t *testing.T) {
+	tests := []struct {
+		addr    string
+		replica int
+		want    string
+		wantErr bool
+	}{
+		{addr: "0.0.0.0:4002", replica: 0, want: "127.0.0.1:4002"},
+		{addr: "0.0.0.0:4002", replica: 2, want: "127.0.0.1:4004"},
+		{addr: "[::]:4003", replica: 1, want: "127.0.0.1:4004"},
+		{addr: ":4003", replica: 0, want: "127.0.0.1:4003"},
+		{addr: "192.168.1.10:4002", replica: 1, want: "192.168.1.10:4003"},
+		{addr: "", replica: 0, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		addr, err := connectAddr(tt.addr, tt.replica)
+		if tt.wantErr {
+			assert.Error(t, err)
+			continue
+		}
+		assert.NoError(t, err)
+		assert.Equal(t, tt.want, addr)
+	}
+}
+
+func TestConnectInactive(t *testing.T) {
+	c := newTestCluster(t, config.DefaultBareMetalConfig(), "")
+	options := &opt.ConnectOptions{Name: "mycluster", Protocol: opt.MySQL}
+
+	// The foreground pid of metadata is the one of test, but the cluster is not active.
+	assert.ErrorContains(t, c.Connect(context.Background(), options), "cluster 'mycluster' is stopped")
+
+	// The cluster whose process was gone can't be connected either.
+	assert.NoError(t, c.mm.UpdateClusterMetadata(func(md *config.BareMetalClusterMetadata) {
+		md.State = &config.ClusterState{Phase: config.ClusterPhaseRunning, Pid: -1}
+	}))
+	assert.ErrorContains(t, c.Connect(context.Background(), options), "cluster 'mycluster' is failed")
+}
@@ -20,13 +20,11 @@ import (
 	"context"
 	"fmt"
 	"os"
-	"os/exec"
-	"path"
-	"strings"
-	"time"
 
 	"github.com/GreptimeTeam/gtctl/pkg/artifacts"
 	opt "github.com/GreptimeTeam/gtctl/pkg/cluster"
+	"github.com/GreptimeTeam/gtctl/pkg/components"
+	"github.com/GreptimeTeam/gtctl/pkg/config"
 	"github.com/GreptimeTeam/gtctl/pkg/logger"
 	fileutils "github.com/GreptimeTeam/gtctl/pkg/utils/file"
 )
@@ -34,6 +32,14 @@ import (
 func (c *Cluster) Create(ctx context.Context, options *opt.CreateOptions) error {
 	spinner := options.Spinner
 
+	// Find out all the port conflicts before anything starts.
+	if err := checkPorts(c.config, c.useMemoryMeta); err != nil {
+		return err
+	}
+	if err := c.transit(config.ClusterPhaseCreating, ""); err != nil {
+		return err
+	}
+
 	withSpinner := func(target string, f func(context.Context, *opt.CreateOptions) error) error {
 		if spinner != nil {
 			spinner.Start(fmt.Sprintf("Installing %s...", target))
@@ -52,18 +58,34 @@ func (c *Cluster) Create(ctx context.Context, options *opt.CreateOptions) error
 		return nil
 	}
 
-	if !c.useMemoryMeta {
+	if c.withEtcd() {
 		if err := withSpinner("Etcd Cluster", c.createEtcdCluster); err != nil {
+			c.fail(errorReason(err))
+			if err := c.Wait(ctx, true); err != nil {
+				return err
+			}
 			return err
 		}
 	}
 	if err := withSpinner("GreptimeDB Cluster", c.createCluster); err != nil {
+		c.fail(errorReason(err))
 		if err := c.Wait(ctx, true); err != nil {
 			return err
 		}
 		return err
 	}
 
+	return c.transit(config.ClusterPhaseRunning, "")
+}
+
+// startComponent starts the component and records its state.
+func (c *Cluster) startComponent(component components.ClusterComponent, binPath string) error {
+	c.setComponentState(component.Name(), config.ComponentPhaseStarting, "")
+	if err := component.Start(c.ctx, c.failFunc(component), binPath); err != nil {
+		c.setComponentState(component.Name(), config.ComponentPhaseFailed, errorReason(err))
+		return err
+	}
+	c.setComponentState(component.Name(), config.ComponentPhaseRunning, "")
 	return nil
 }
 
@@ -73,8 +95,10 @@ func (c *Cluster) createCluster(ctx context.Context, options *opt.CreateOptions)
 	}
 	clusterOpt := options.Cluster
 
-	var binPath string
-	if c.config.Cluster.Artifact != nil {
+	binPath := c.pinnedBinPath(c.config.Cluster.Artifact, func(md *config.BareMetalClusterMetadata) string {
+		return md.GreptimeBinPath
+	})
+	if len(binPath) == 0 && c.config.Cluster.Artifact != nil {
 		if c.config.Cluster.Artifact.Local != "" {
 			binPath = c.config.Cluster.Artifact.Local
 
@@ -83,43 +107,44 @@ func (c *Cluster) createCluster(ctx context.Context, options *opt.CreateOptions)
 				return fmt.Errorf("greptimedb cluster artifact '%s' is not exist", binPath)
 			}
 		} else {
-			src, err := c.am.NewSource(artifacts.GreptimeBinName, c.config.Cluster.Artifact.Version,
-				artifacts.ArtifactTypeBinary, clusterOpt.UseGreptimeCNArtifacts)
-			if err != nil {
-				return err
-			}
-
-			destDir, err := c.mm.AllocateArtifactFilePath(src, false)
-			if err != nil {
-				return err
-			}
-
-			installDir, err := c.mm.AllocateArtifactFilePath(src, true)
-			if err != nil {
-				return err
-			}
-
-			artifactFile, err := c.am.DownloadTo(ctx, src, destDir, &artifacts.DownloadOptions{
-				EnableCache:      c.enableCache,
-				BinaryInstallDir: installDir,
-			})
+			artifactFile, err := c.installBinary(ctx, artifacts.GreptimeBinName, c.config.Cluster.Artifact.Version,
+				clusterOpt.UseGreptimeCNArtifacts)
 			if err != nil {
 				return err
 			}
 			binPath = artifactFile
 		}
 	}
+	c.greptimeBinPath = binPath
+
+	// Find out the unreachable hosts before anything starts on them.
+	for _, host := range c.config.Hosts {
+		if err := c.hosts[host.Name].Connect(); err != nil {
+			return err
+		}
+	}
 
-	if err := c.cc.MetaSrv.Start(c.ctx, c.stop, binPath); err != nil {
+	datanodeDirs, err := c.datanodeDirs()
+	if err != nil {
 		return err
 	}
-	if err := c.cc.Datanode.Start(c.ctx, c.stop, binPath); err != nil {
+	if err := c.mm.UpdateClusterMetadata(func(md *config.BareMetalClusterMetadata) {
+		md.GreptimeBinPath = binPath
+		md.DatanodeDirs = datanodeDirs
+	}); err != nil {
 		return err
 	}
-	if err := c.cc.Frontend.Start(c.ctx, c.stop, binPath); err != nil {
+
+	if err := c.ensureCerts(); err != nil {
 		return err
 	}
 
+	for _, component := range c.cc.ordered(false) {
+		if err := c.startComponent(component, binPath); err != nil {
+			return err
+		}
+	}
+
 	return nil
 }
 
@@ -129,8 +154,10 @@ func (c *Cluster) createEtcdCluster(ctx context.Context, options *opt.CreateOpti
 	}
 	etcdOpt := options.Etcd
 
-	var binPath string
-	if c.config.Etcd.Artifact != nil {
+	binPath := c.pinnedBinPath(c.config.Etcd.Artifact, func(md *config.BareMetalClusterMetadata) string {
+		return md.EtcdBinPath
+	})
+	if len(binPath) == 0 && c.config.Etcd.Artifact != nil {
 		if c.config.Etcd.Artifact.Local != "" {
 			binPath = c.config.Etcd.Artifact.Local
 
@@ -139,26 +166,8 @@ func (c *Cluster) createEtcdCluster(ctx context.Context, options *opt.CreateOpti
 				return fmt.Errorf("etcd artifact '%s' is not exist", binPath)
 			}
 		} else {
-			src, err := c.am.NewSource(artifacts.EtcdBinName, c.config.Etcd.Artifact.Version,
-				artifacts.ArtifactTypeBinary, etcdOpt.UseGreptimeCNArtifacts)
-			if err != nil {
-				return err
-			}
-
-			destDir, err := c.mm.AllocateArtifactFilePath(src, false)
-			if err != nil {
-				return err
-			}
-
-			installDir, err := c.mm.AllocateArtifactFilePath(src, true)
-			if err != nil {
-				return err
-			}
-
-			artifactFile, err := c.am.DownloadTo(ctx, src, destDir, &artifacts.DownloadOptions{
-				EnableCache:      c.enableCache,
-				BinaryInstallDir: installDir,
-			})
+			artifactFile, err := c.installBinary(ctx, artifacts.EtcdBinName, c.config.Etcd.Artifact.Version,
+				etcdOpt.UseGreptimeCNArtifacts)
 			if err != nil {
 				return err
 			}
@@ -166,65 +175,90 @@ func (c *Cluster) createEtcdCluster(ctx context.Context, options *opt.CreateOpti
 		}
 	}
 
-	if err := c.cc.Etcd.Start(c.ctx, c.stop, binPath); err != nil {
+	if err := c.mm.UpdateClusterMetadata(func(md *config.BareMetalClusterMetadata) {
+		md.EtcdBinPath = binPath
+	}); err != nil {
 		return err
 	}
-	if err := c.checkEtcdHealth(binPath); err != nil {
+
+	if err := c.startComponent(c.cc.Etcd, binPath); err != nil {
 		return err
 	}
 
 	return nil
 }
 
-func (c *Cluster) checkEtcdHealth(etcdBin string) error {
-	// It's very likely that "etcdctl" is under the same directory of "etcd".
-	etcdctlBin := path.Join(etcdBin, "../etcdctl")
-	exists, err := fileutils.IsFileExists(etcdctlBin)
-	if err != nil {
-		return err
-	}
-	if !exists {
-		c.logger.V(3).Infof("'etcdctl' is not found under the same directory of 'etcd', skip checking the healthy of Etcd.")
-		return nil
+// datanodeDirs returns the directories of all the datanode replicas.
+func (c *Cluster) datanodeDirs() ([]config.DatanodeDirs, error) {
+	if c.config.IsStandalone() {
+		return nil, nil
 	}
 
-	for retry := 0; retry < 10; retry++ {
-		outputRaw, err := exec.Command(etcdctlBin, "endpoint", "status").Output()
+	var (
+		csd      = c.mm.GetClusterScopeDirs()
+		datanode = c.config.Cluster.Datanode
+		dirs     []config.DatanodeDirs
+	)
+	for i := 0; i < datanode.Replicas; i++ {
+		host := components.ReplicaProcessOptions(datanode.Process, i).Host
+		dataDir, err := c.hosts.DataDir(host, csd.DataDir)
 		if err != nil {
-			return err
-		}
-		output := string(outputRaw)
-		statuses := strings.Split(output, "\n")
-
-		hasLeader := false
-		for i := 0; i < len(statuses); i++ {
-			fields := strings.Split(statuses[i], ",")
-
-			// We are checking Etcd status with default output format("--write-out=simple"), example output:
-			// 127.0.0.1:2379, 8e9e05c52164694d, 3.5.0, 131 kB, true, false, 3, 75, 75,
-			//
-			// The output fields are corresponding to the following table's columns (with format "--write-out=table"):
-			// +----------------+------------------+---------+---------+-----------+------------+-----------+------------+--------------------+--------+
-			// |    ENDPOINT    |        ID        | VERSION | DB SIZE | IS LEADER | IS LEARNER | RAFT TERM | RAFT INDEX | RAFT APPLIED INDEX | ERRORS |
-			// +----------------+------------------+---------+---------+-----------+------------+-----------+------------+--------------------+--------+
-			// | 127.0.0.1:2379 | 8e9e05c52164694d |   3.5.0 |  131 kB |      true |      false |         3 |         72 |                 72 |        |
-			// +----------------+------------------+---------+---------+-----------+------------+-----------+------------+--------------------+--------+
-			//
-			// So we can just check the "IS LEADER" field.
-			if strings.TrimSpace(fields[4]) == "true" {
-				hasLeader = true
-				break
-			}
-		}
-		if hasLeader {
-			return nil
+			return nil, err
 		}
 
-		time.Sleep(1 * time.Second)
+		replicaDirs := components.DatanodeReplicaDirs(datanode, dataDir, i)
+		replicaDirs.Host = host
+		dirs = append(dirs, replicaDirs)
+	}
+	return dirs, nil
+}
+
+// installBinary downloads the binary artifact of the version and returns the path of installed binary.
+func (c *Cluster) installBinary(ctx context.Context, name, version string, useGreptimeCNArtifacts bool) (string, error) {
+	src, err := c.am.NewSource(name, version, artifacts.ArtifactTypeBinary, useGreptimeCNArtifacts)
+	if err != nil {
+		return "", err
 	}
 
-	csd := c.mm.GetClusterScopeDirs()
-	return fmt.Errorf("etcd is not ready in 10 second! You can find its logs in %s", path.Join(csd.LogsDir, "etcd"))
+	destDir, err := c.mm.AllocateArtifactFilePath(src, false)
+	if err != nil {
+		return "", err
+	}
+
+	installDir, err := c.mm.AllocateArtifactFilePath(src, true)
+	if err != nil {
+		return "", err
+	}
+
+	return c.am.DownloadTo(ctx, src, destDir, &artifacts.DownloadOptions{
+		EnableCache:      c.enableCache,
+		BinaryInstallDir: installDir,
+	})
+}
+
+// pinnedBinPath returns the binary that the persisted cluster ran with last time if it still exists,
+// so the resumed cluster won't download a different one, e.g. the artifact version is 'latest'.
+// The local artifact in config always takes precedence over the pinned binary.
+func (c *Cluster) pinnedBinPath(artifact *config.Artifact, pinned func(md *config.BareMetalClusterMetadata) string) string {
+	if !c.usePersistedConfig || (artifact != nil && artifact.Local != "") {
+		return ""
+	}
+
+	md, err := c.mm.GetClusterMetadata()
+	if err != nil {
+		return ""
+	}
+	binPath := pinned(md)
+	if len(binPath) == 0 {
+		return ""
+	}
+	if exist, _ := fileutils.IsFileExists(binPath); !exist {
+		c.logger.Warnf("The pinned binary '%s' is not exist, the artifact will be installed again", binPath)
+		return ""
+	}
+
+	c.logger.V(3).Infof("use the pinned binary '%s'", binPath)
+	return binPath
 }
 
 func (c *Cluster) Wait(ctx context.Context, close bool) error {
@@ -235,9 +269,18 @@ func (c *Cluster) Wait(ctx context.Context, close bool) error {
 
 	csd := c.mm.GetClusterScopeDirs()
 	if !close {
+		if err := c.serveControl(); err != nil {
+			return err
+		}
+		c.supervisor.setStatus(supervisorStatusRunning)
+		go c.watchState()
+
 		c.logger.V(0).Infof("The cluster(pid=%d, version=%s) is running in bare-metal mode now...", os.Getpid(), v)
 		c.logger.V(0).Infof("To view dashboard by accessing: %s", logger.Bold("http://localhost:4000/dashboard/"))
 	} else {
+		// Stop the components that have been started.
+		c.stop()
+		c.shutdown()
 		c.logger.Warnf("The cluster(pid=%d, version=%s) run in bare-metal has been shutting down...", os.Getpid(), v)
 		c.logger.Warnf("To view the failure by browsing logs in: %s", logger.Bold(csd.LogsDir))
 		return nil
@@ -251,12 +294,30 @@ func (c *Cluster) Wait(ctx context.Context, close bool) error {
 }
 
 func (c *Cluster) wait(_ context.Context) error {
-	c.wg.Wait()
-
 	// We ignore the context from input params, since
 	// it is not the context of current cluster.
 	<-c.ctx.Done()
 
+	if c.supervisor != nil {
+		c.supervisor.close()
+	}
+
+	failed := c.getFailedComponent()
+	if len(failed) > 0 {
+		c.fail(fmt.Sprintf("%s failed", failed))
+	} else if err := c.transit(config.ClusterPhaseStopping, ""); err != nil {
+		c.logger.Warnf("failed to record the stopping of cluster: %v", err)
+	}
+
+	c.shutdown()
+	c.wg.Wait()
+
+	if len(failed) == 0 {
+		if err := c.transit(config.ClusterPhaseStopped, ""); err != nil {
+			c.logger.Warnf("failed to record the stopping of cluster: %v", err)
+		}
+	}
+
 	csd := c.mm.GetClusterScopeDirs()
 	c.logger.V(0).Infof("Cluster is shutting down, don't worry, it still remain in %s", logger.Bold(csd.BaseDir))
 	return nil
@@ -19,10 +19,12 @@ package baremetal
 import (
 	"context"
 	"fmt"
-	"os"
-	"syscall"
+	"path/filepath"
+	"strings"
 
 	opt "github.com/GreptimeTeam/gtctl/pkg/cluster"
+	"github.com/GreptimeTeam/gtctl/pkg/components"
+	"github.com/GreptimeTeam/gtctl/pkg/config"
 	fileutils "github.com/GreptimeTeam/gtctl/pkg/utils/file"
 )
 
@@ -32,12 +34,9 @@ func (c *Cluster) Delete(ctx context.Context, options *opt.DeleteOptions) error
 		return err
 	}
 
-	running, ferr, serr := c.isClusterRunning(cluster.ForegroundPid)
-	if ferr != nil {
-		return fmt.Errorf("error checking whether cluster '%s' is running: %v", options.Name, ferr)
-	}
-	if running || serr == nil {
-		return fmt.Errorf("cluster '%s' is running, please stop it before deleting", options.Name)
+	if state := c.observedState(cluster); state.IsActive() {
+		return fmt.Errorf("cluster '%s' is %s (pid %d), please stop it before deleting",
+			options.Name, strings.ToLower(string(state.Phase)), state.Pid)
 	}
 
 	csd := c.mm.GetClusterScopeDirs()
@@ -45,6 +44,17 @@ func (c *Cluster) Delete(ctx context.Context, options *opt.DeleteOptions) error
 	if err = c.delete(ctx, csd.BaseDir); err != nil {
 		return err
 	}
+	// The cgroups of replicas have been removed after they exited.
+	if err = components.RemoveCgroup(components.ClusterCgroup(options.Name)); err != nil {
+		c.logger.V(3).Infof("failed to remove the cgroup of cluster '%s': %v", options.Name, err)
+	}
+	for _, dir := range externalDatanodeDirs(cluster.DatanodeDirs, csd.BaseDir) {
+		c.logger.V(0).Infof("Deleting datanode data in %s", dir)
+		if err = c.delete(ctx, dir); err != nil {
+			return err
+		}
+	}
+	c.deleteRemoteDirs(cluster, options.Name)
 	c.logger.V(0).Info("Deleted!")
 
 	return nil
@@ -54,17 +64,59 @@ func (c *Cluster) delete(_ context.Context, baseDir string) error {
 	return fileutils.DeleteDirIfExists(baseDir)
 }
 
-// isClusterRunning checks the current status of cluster by sending signal to process.
-func (c *Cluster) isClusterRunning(pid int) (runs bool, f error, s error) {
-	p, f := os.FindProcess(pid)
-	if f != nil {
-		return false, f, nil
-	}
+// deleteRemoteDirs deletes the directory of cluster and the datanode data outside it on each remote host,
+// the replicas left running on the host are killed first.
+// The unreachable host is skipped with a warning, so the cluster can still be deleted if the host is gone.
+func (c *Cluster) deleteRemoteDirs(cluster *config.BareMetalClusterMetadata, name string) {
+	hosts := components.NewRemoteHosts(cluster.Config.Hosts, name, c.logger)
+	defer hosts.Close()
 
-	s = p.Signal(syscall.Signal(0))
-	if s != nil {
-		return false, nil, s
+	for _, host := range cluster.Config.Hosts {
+		client := hosts[host.Name]
+		clusterDir, err := client.ClusterDir()
+		if err != nil {
+			c.logger.Warnf("Skip deleting the data on host '%s': %v", host.Name, err)
+			continue
+		}
+
+		if err = components.KillRemoteReplicas(client); err != nil {
+			c.logger.Warnf("Failed to kill the replicas left on host '%s': %v", host.Name, err)
+		}
+
+		dirs := []string{clusterDir}
+		for _, d := range cluster.DatanodeDirs {
+			if d.Host == host.Name {
+				// The dirs are resolved on the host, like the ones of local replica.
+				d.Host = ""
+				dirs = append(dirs, externalDatanodeDirs([]config.DatanodeDirs{d}, clusterDir)...)
+			}
+		}
+		for _, dir := range dirs {
+			c.logger.V(0).Infof("Deleting %s on host '%s'", dir, host.Name)
+			if err = client.RemoveAll(dir); err != nil {
+				c.logger.Warnf("Failed to delete %s on host '%s': %v", dir, host.Name, err)
+			}
+		}
 	}
+}
 
-	return true, nil, nil
+// externalDatanodeDirs returns the directories of local datanode replicas that are outside baseDir.
+func externalDatanodeDirs(dirs []config.DatanodeDirs, baseDir string) []string {
+	var external []string
+	for _, d := range dirs {
+		if len(d.Host) > 0 {
+			continue
+		}
+		// The data home is in the directory of replica.
+		for _, dir := range []string{filepath.Dir(d.DataHome), d.WalDir, d.ProcedureDir} {
+			if len(dir) == 0 {
+				continue
+			}
+			if rel, err := filepath.Rel(baseDir, dir); err == nil && !strings.HasPrefix(rel, "..") {
+				continue
+			}
+			external = append(external, dir)
+		}
+	}
+	return external
 }
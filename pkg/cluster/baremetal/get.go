@@ -23,12 +23,16 @@ import (
 	"os"
 	"path"
 	"path/filepath"
+	"sort"
+	"strings"
+	"time"
 
 	greptimedbclusterv1alpha1 "github.com/GreptimeTeam/greptimedb-operator/apis/v1alpha1"
 	"github.com/olekukonko/tablewriter"
 	"gopkg.in/yaml.v3"
 
 	opt "github.com/GreptimeTeam/gtctl/pkg/cluster"
+	"github.com/GreptimeTeam/gtctl/pkg/components"
 	cfg "github.com/GreptimeTeam/gtctl/pkg/config"
 	"github.com/GreptimeTeam/gtctl/pkg/metadata"
 	fileutils "github.com/GreptimeTeam/gtctl/pkg/utils/file"
@@ -63,16 +67,7 @@ func (c *Cluster) get(_ context.Context, options *opt.GetOptions) (*cfg.BareMeta
 		return nil, fmt.Errorf("cluster %s is not exist", options.Name)
 	}
 
-	var cluster cfg.BareMetalClusterMetadata
-	in, err := os.ReadFile(csd.ConfigPath)
-	if err != nil {
-		return nil, err
-	}
-	if err = yaml.Unmarshal(in, &cluster); err != nil {
-		return nil, err
-	}
-
-	return &cluster, nil
+	return c.mm.GetClusterMetadata()
 }
 
 func (c *Cluster) configGetView(table *tablewriter.Table) {
@@ -83,7 +78,7 @@ func (c *Cluster) configGetView(table *tablewriter.Table) {
 func (c *Cluster) renderGetView(table *tablewriter.Table, data *cfg.BareMetalClusterMetadata) {
 	c.configGetView(table)
 
-	headers, footers, bulk := collectClusterInfoFromBareMetal(data)
+	headers, footers, bulk := collectClusterInfoFromBareMetal(data, c.observedState(data))
 	table.SetHeader(headers)
 	table.AppendBulk(bulk)
 	table.Render()
@@ -93,39 +88,94 @@ func (c *Cluster) renderGetView(table *tablewriter.Table, data *cfg.BareMetalClu
 	}
 }
 
-func collectClusterInfoFromBareMetal(data *cfg.BareMetalClusterMetadata) (
+func collectClusterInfoFromBareMetal(data *cfg.BareMetalClusterMetadata, state *cfg.ClusterState) (
 	headers, footers []string, bulk [][]string) {
-	headers = []string{"COMPONENT", "PID"}
+	headers = []string{"COMPONENT", "STATE", "PID"}
 
 	pidsDir := path.Join(data.ClusterDir, metadata.ClusterPidsDir)
 	pidsMap := collectPidsForBareMetal(pidsDir)
 
+	// The process options and resources are only shown if any component has them.
+	withProcess, withResources := hasProcessOptions(data.Config), hasResources(data.Config)
+	if withProcess {
+		headers = append(headers, "ENV", "EXTRA-ARGS", "WORKING-DIR")
+	}
+	if withResources {
+		headers = append(headers, "MEMORY(USAGE/LIMIT)", "CPU(TIME/LIMIT)")
+	}
+
 	var (
-		date = data.CreationDate.String()
-		rows = func(name string, replicas int) {
+		date   = data.CreationDate.String()
+		cgroup = components.ClusterCgroup(path.Base(data.ClusterDir))
+		rows   = func(name, replicaName string, replicas int, process *cfg.Process) {
 			for i := 0; i < replicas; i++ {
-				key := fmt.Sprintf("%s.%d", name, i)
+				key := fmt.Sprintf("%s.%d", replicaName, i)
 				pid := "N/A"
 				if val, ok := pidsMap[key]; ok {
 					pid = fmt.Sprintf(".%d: %s", i, val)
 				}
-				bulk = append(bulk, []string{name, pid})
+				row := []string{name, componentStateView(state, replicaName), pid}
+				if withProcess {
+					var options cfg.ProcessOptions
+					if process != nil {
+						options = components.ReplicaProcessOptions(*process, i)
+					}
+					row = append(row, envView(options.Env), strings.Join(options.ExtraArgs, " "), options.WorkingDir)
+				}
+				if withResources {
+					var resources *cfg.Resources
+					if process != nil {
+						resources = process.Resources
+					}
+					row = append(row, resourcesView(path.Join(cgroup, key), resources)...)
+				}
+				bulk = append(bulk, row)
 			}
 		}
 	)
 
-	rows(string(greptimedbclusterv1alpha1.FrontendComponentKind), data.Config.Cluster.Frontend.Replicas)
-	rows(string(greptimedbclusterv1alpha1.DatanodeComponentKind), data.Config.Cluster.Datanode.Replicas)
-	rows(string(greptimedbclusterv1alpha1.MetaComponentKind), data.Config.Cluster.MetaSrv.Replicas)
+	if data.Config.IsStandalone() {
+		rows(components.StandaloneName, components.StandaloneName, 1, &data.Config.Cluster.Standalone.Process)
+	} else {
+		frontend := string(greptimedbclusterv1alpha1.FrontendComponentKind)
+		rows(frontend, frontend, data.Config.Cluster.Frontend.Replicas, &data.Config.Cluster.Frontend.Process)
+		datanode := string(greptimedbclusterv1alpha1.DatanodeComponentKind)
+		rows(datanode, datanode, data.Config.Cluster.Datanode.Replicas, &data.Config.Cluster.Datanode.Process)
+		// The replicas of meta are named after metasrv.
+		rows(string(greptimedbclusterv1alpha1.MetaComponentKind), "metasrv", data.Config.Cluster.MetaSrv.Replicas,
+			&data.Config.Cluster.MetaSrv.Process)
 
-	bulk = append(bulk, []string{"etcd", pidsMap["etcd"]})
+		rows("etcd", "etcd", components.EtcdReplicas(data.Config.Etcd), nil)
+	}
 
 	config, err := yaml.Marshal(data.Config)
 	footers = []string{
 		fmt.Sprintf("CREATION-DATE: %s", date),
 		fmt.Sprintf("GREPTIMEDB-VERSION: %s", data.Config.Cluster.Artifact.Version),
-		fmt.Sprintf("ETCD-VERSION: %s", data.Config.Etcd.Artifact.Version),
-		fmt.Sprintf("CLUSTER-DIR: %s", data.ClusterDir),
+	}
+	if data.Config.IsStandalone() {
+		footers = append(footers, fmt.Sprintf("TOPOLOGY: %s", cfg.TopologyStandalone))
+	} else {
+		footers = append(footers, fmt.Sprintf("ETCD-VERSION: %s", data.Config.Etcd.Artifact.Version))
+	}
+	footers = append(footers, fmt.Sprintf("CLUSTER-DIR: %s", data.ClusterDir))
+	footers = append(footers, fmt.Sprintf("STATE: %s", clusterStateView(state)))
+	if data.SupervisorPid > 0 {
+		footers = append(footers, fmt.Sprintf("SUPERVISOR-PID: %d", data.SupervisorPid))
+	}
+	if len(data.DatanodeDirs) > 0 {
+		dirs := "DATANODE-DIRS:"
+		for _, d := range data.DatanodeDirs {
+			name := d.Name
+			if len(d.Host) > 0 {
+				name = fmt.Sprintf("%s(%s)", d.Name, d.Host)
+			}
+			dirs += fmt.Sprintf("\n  %s: data-home=%s, wal-dir=%s", name, d.DataHome, d.WalDir)
+			if len(d.ProcedureDir) > 0 {
+				dirs += fmt.Sprintf(", procedure-dir=%s", d.ProcedureDir)
+			}
+		}
+		footers = append(footers, dirs)
 	}
 	if err != nil {
 		footers = append(footers, fmt.Sprintf("CLUSTER-CONFIG: error retrieving cluster config: %v", err))
@@ -136,7 +186,97 @@ func collectClusterInfoFromBareMetal(data *cfg.BareMetalClusterMetadata) (
 	return headers, footers, bulk
 }
 
-// collectPidsForBareMetal returns the pid of each component.
+// clusterStateView shows the phase of cluster with the time it entered the phase and the reason,
+// like 'Failed since 2023-08-01 10:00:00, reason: frontend failed'.
+func clusterStateView(state *cfg.ClusterState) string {
+	view := string(state.Phase)
+	if !state.Since.IsZero() {
+		view += fmt.Sprintf(" since %s", state.Since.Format("2006-01-02 15:04:05"))
+	}
+	if len(state.Reason) > 0 {
+		view += fmt.Sprintf(", reason: %s", state.Reason)
+	}
+	return view
+}
+
+// componentStateView shows the phase of component with the reason, it's 'N/A' if the component has no state.
+func componentStateView(state *cfg.ClusterState, name string) string {
+	component := state.Component(name)
+	if component == nil {
+		return opt.NotAvailable
+	}
+	if len(component.Reason) > 0 {
+		return fmt.Sprintf("%s(%s)", component.Phase, component.Reason)
+	}
+	return string(component.Phase)
+}
+
+// componentProcesses returns the process configs of all the greptime components.
+func componentProcesses(config *cfg.BareMetalClusterConfig) []cfg.Process {
+	if config.IsStandalone() {
+		return []cfg.Process{config.Cluster.Standalone.Process}
+	}
+	return []cfg.Process{config.Cluster.Frontend.Process, config.Cluster.Datanode.Process, config.Cluster.MetaSrv.Process}
+}
+
+func hasProcessOptions(config *cfg.BareMetalClusterConfig) bool {
+	for _, process := range componentProcesses(config) {
+		if len(process.Env) > 0 || len(process.ExtraArgs) > 0 || len(process.WorkingDir) > 0 ||
+			len(process.ReplicaOverrides) > 0 {
+			return true
+		}
+	}
+	return false
+}
+
+func hasResources(config *cfg.BareMetalClusterConfig) bool {
+	for _, process := range componentProcesses(config) {
+		if process.Resources != nil {
+			return true
+		}
+	}
+	return false
+}
+
+// resourcesView returns the current memory and CPU usage of the replica in its cgroup along with their limits,
+// it's empty if the replica has no limits. The usage is 'N/A' if the replica is not running or not in the cgroup.
+func resourcesView(cgroup string, resources *cfg.Resources) []string {
+	if resources == nil {
+		return []string{"", ""}
+	}
+
+	memory, cpu := opt.NotAvailable, opt.NotAvailable
+	if usage, err := components.GetCgroupUsage(cgroup); err == nil {
+		if usage.Memory >= 0 {
+			memory = fmt.Sprintf("%.1fMi", float64(usage.Memory)/(1<<20))
+		}
+		cpu = usage.CPU.Round(time.Millisecond).String()
+	}
+
+	limit := func(limit string) string {
+		if len(limit) == 0 {
+			return "unlimited"
+		}
+		return limit
+	}
+	return []string{
+		fmt.Sprintf("%s/%s", memory, limit(resources.Memory)),
+		fmt.Sprintf("%s/%s", cpu, limit(resources.CPU)),
+	}
+}
+
+// envView returns the env in the form of sorted 'KEY=VALUE' lines.
+func envView(env map[string]string) string {
+	lines := make([]string, 0, len(env))
+	for k, v := range env {
+		lines = append(lines, fmt.Sprintf("%s=%s", k, v))
+	}
+	sort.Strings(lines)
+	return strings.Join(lines, "\n")
+}
+
+// collectPidsForBareMetal returns the pid of each component,
+// the pid of replica on remote host is prefixed with the host name, like 'node-1:1234'.
 func collectPidsForBareMetal(pidsDir string) map[string]string {
 	ret := make(map[string]string)
 
@@ -152,7 +292,11 @@ func collectPidsForBareMetal(pidsDir string) map[string]string {
 				return err
 			}
 
-			ret[d.Name()] = string(pid)
+			if host, err := os.ReadFile(filepath.Join(path, "host")); err == nil {
+				ret[d.Name()] = fmt.Sprintf("%s:%s", host, pid)
+			} else {
+				ret[d.Name()] = string(pid)
+			}
 		}
 		return nil
 	}); err != nil {
@@ -0,0 +1,110 @@
+/*
+ * Copyright 2023 Greptime Team
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package baremetal
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"path"
+	"strings"
+
+	"github.com/olekukonko/tablewriter"
+
+	opt "github.com/GreptimeTeam/gtctl/pkg/cluster"
+	cfg "github.com/GreptimeTeam/gtctl/pkg/config"
+	"github.com/GreptimeTeam/gtctl/pkg/metadata"
+)
+
+// List lists all the clusters whose metadata is stored in ${HomeDir}/.gtctl.
+func (c *Cluster) List(_ context.Context, options *opt.ListOptions) error {
+	// The clusters whose metadata is corrupted are skipped, so the others are still listed.
+	clusters, err := c.mm.ListClustersMetadata()
+	var corrupted *metadata.CorruptedMetadataError
+	if errors.As(err, &corrupted) {
+		for _, err := range corrupted.Errors {
+			c.logger.Warnf("skip the cluster: %v", err)
+		}
+	} else if err != nil {
+		return err
+	}
+
+	c.renderListView(options.Table, clusters)
+
+	return nil
+}
+
+func (c *Cluster) renderListView(table *tablewriter.Table, data []*cfg.BareMetalClusterMetadata) {
+	for _, cluster := range data {
+		var (
+			name        = path.Base(cluster.ClusterDir)
+			config      = cluster.Config.Cluster
+			processes   []string
+			greptimeVer = opt.NotAvailable
+			etcdVer     = opt.NotAvailable
+			replicas    = string(cfg.TopologyStandalone)
+		)
+		if !cluster.Config.IsStandalone() {
+			replicas = opt.ReplicasView(config.Frontend.Replicas, config.Datanode.Replicas, config.MetaSrv.Replicas)
+		}
+		if len(config.Artifact.Version) > 0 {
+			greptimeVer = config.Artifact.Version
+		}
+		if cluster.Config.Etcd != nil && len(cluster.Config.Etcd.Artifact.Version) > 0 {
+			etcdVer = cluster.Config.Etcd.Artifact.Version
+		}
+
+		state := c.observedState(cluster)
+		if state.IsActive() {
+			runner := "foreground"
+			if state.Pid == cluster.SupervisorPid {
+				runner = "supervisor"
+			}
+			processes = append(processes, c.pidView(runner, state.Pid))
+		}
+		if state.IsActive() && len(state.Components) > 0 {
+			running := 0
+			for _, component := range state.Components {
+				if component.Phase == cfg.ComponentPhaseRunning {
+					running++
+				}
+			}
+			processes = append(processes, fmt.Sprintf("components: %d/%d running", running, len(state.Components)))
+		}
+
+		table.Append([]string{
+			name,
+			opt.BareMetalClusterType,
+			opt.NotAvailable,
+			cluster.CreationDate.String(),
+			greptimeVer,
+			etcdVer,
+			replicas,
+			string(state.Phase),
+			strings.Join(processes, ", "),
+		})
+	}
+}
+
+// pidView shows the pid of the process and whether it is alive, like 'supervisor: 1234(alive)'.
+func (c *Cluster) pidView(name string, pid int) string {
+	state := "dead"
+	if c.isPidRunning(pid) {
+		state = "alive"
+	}
+	return fmt.Sprintf("%s: %d(%s)", name, pid, state)
+}
@@ -0,0 +1,284 @@
+/*
+ * Copyright 2023 Greptime Team
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package baremetal
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	greptimedbclusterv1alpha1 "github.com/GreptimeTeam/greptimedb-operator/apis/v1alpha1"
+
+	opt "github.com/GreptimeTeam/gtctl/pkg/cluster"
+	"github.com/GreptimeTeam/gtctl/pkg/components"
+)
+
+// followLogInterval is the interval of checking the new logs when following the log file.
+const followLogInterval = 500 * time.Millisecond
+
+func (c *Cluster) Logs(ctx context.Context, options *opt.LogsOptions) error {
+	if _, err := c.get(ctx, &opt.GetOptions{Name: options.Name}); err != nil {
+		return err
+	}
+
+	replicas, err := c.logReplicas(options.Component, options.Replica)
+	if err != nil {
+		return err
+	}
+
+	var (
+		csd     = c.mm.GetClusterScopeDirs()
+		sources []*opt.LogSource
+	)
+	for _, replica := range replicas {
+		source, err := openLogSource(path.Join(csd.LogsDir, replica, "log"), options)
+		if err != nil {
+			for _, source := range sources {
+				_ = source.Reader.Close()
+			}
+			return fmt.Errorf("failed to open logs of '%s': %v", replica, err)
+		}
+		source.Name = replica
+		sources = append(sources, source)
+	}
+
+	return opt.PrintLogs(ctx, options.Writer, sources, options.Follow)
+}
+
+// logReplicas returns the names of replicas whose logs are going to be printed, e.g. 'datanode.0'.
+// The logs of supervisor are only printed when it's specified as component.
+func (c *Cluster) logReplicas(component string, replica int) ([]string, error) {
+	switch component {
+	case string(greptimedbclusterv1alpha1.MetaComponentKind):
+		component = c.cc.MetaSrv.Name()
+	case "", c.cc.Frontend.Name(), c.cc.Datanode.Name(), c.cc.MetaSrv.Name(), c.cc.Etcd.Name(), components.StandaloneName,
+		SupervisorName:
+	default:
+		return nil, fmt.Errorf("unknown component '%s', it should be one of frontend, datanode, meta, etcd, "+
+			"standalone and supervisor", component)
+	}
+
+	if component == SupervisorName {
+		return []string{SupervisorName}, nil
+	}
+
+	entries, err := os.ReadDir(c.mm.GetClusterScopeDirs().LogsDir)
+	if err != nil {
+		return nil, err
+	}
+
+	type logReplica struct {
+		name      string
+		component string
+		index     int
+	}
+	var replicas []logReplica
+	for _, entry := range entries {
+		name, index, ok := splitReplicaName(entry.Name())
+		if !entry.IsDir() || !ok {
+			continue
+		}
+		if (len(component) > 0 && name != component) || (replica >= 0 && index != replica) {
+			continue
+		}
+		replicas = append(replicas, logReplica{name: entry.Name(), component: name, index: index})
+	}
+	if len(replicas) == 0 {
+		return nil, fmt.Errorf("no logs found for component '%s' replica %d", component, replica)
+	}
+
+	sort.Slice(replicas, func(i, j int) bool {
+		if replicas[i].component != replicas[j].component {
+			return replicas[i].component < replicas[j].component
+		}
+		return replicas[i].index < replicas[j].index
+	})
+
+	names := make([]string, 0, len(replicas))
+	for _, r := range replicas {
+		names = append(names, r.name)
+	}
+	return names, nil
+}
+
+// splitReplicaName splits the name of replica like 'datanode.1' into its component and index.
+func splitReplicaName(name string) (string, int, bool) {
+	i := strings.LastIndex(name, ".")
+	if i < 0 {
+		return "", 0, false
+	}
+	index, err := strconv.Atoi(name[i+1:])
+	if err != nil {
+		return "", 0, false
+	}
+	return name[:i], index, true
+}
+
+// openLogSource reads the lines of log file that match the since and tail options,
+// and keeps reading the new lines from the end of file if it's following.
+func openLogSource(filename string, options *opt.LogsOptions) (*opt.LogSource, error) {
+	file, err := os.Open(filename)
+	if err != nil {
+		return nil, err
+	}
+
+	lines, err := filterLogLines(file, options.Since, options.Tail)
+	if err != nil {
+		file.Close()
+		return nil, err
+	}
+
+	source := &opt.LogSource{}
+	if options.Follow {
+		source.Reader = &followReader{
+			head:     bytes.NewReader(lines),
+			filename: filename,
+			file:     file,
+			closed:   make(chan struct{}),
+		}
+	} else {
+		file.Close()
+		source.Reader = io.NopCloser(bytes.NewReader(lines))
+	}
+	return source, nil
+}
+
+// filterLogLines reads all the lines from r, and returns the last tail lines that are newer than since.
+// The line without timestamp is kept or dropped together with its previous line.
+func filterLogLines(r io.Reader, since time.Duration, tail int64) ([]byte, error) {
+	var (
+		lines  []string
+		keep   = since <= 0
+		cutoff = time.Now().Add(-since)
+	)
+
+	reader := bufio.NewReader(r)
+	for {
+		line, err := reader.ReadString('\n')
+		if len(line) > 0 {
+			if ts, ok := opt.ParseLogTimestamp(line); ok && since > 0 {
+				keep = !ts.Before(cutoff)
+			}
+			if keep {
+				lines = append(lines, line)
+			}
+			if tail >= 0 && int64(len(lines)) > tail {
+				lines = lines[1:]
+			}
+		}
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return []byte(strings.Join(lines, "")), nil
+}
+
+// followReader reads the selected lines first, and then the new lines that appended to the log file.
+// The log file is reopened if it's rotated.
+type followReader struct {
+	head     io.Reader
+	filename string
+
+	mu     sync.Mutex
+	file   *os.File
+	closed chan struct{}
+}
+
+func (r *followReader) Read(p []byte) (int, error) {
+	if n, err := r.head.Read(p); n > 0 || err != io.EOF {
+		return n, err
+	}
+
+	for {
+		n, err := r.readFile(p)
+		if n > 0 || err != nil {
+			return n, err
+		}
+
+		select {
+		case <-r.closed:
+			return 0, io.EOF
+		case <-time.After(followLogInterval):
+		}
+	}
+}
+
+// readFile reads the log file, and switches to the new log file if current one has been rotated and drained.
+func (r *followReader) readFile(p []byte) (int, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	select {
+	case <-r.closed:
+		return 0, io.EOF
+	default:
+	}
+
+	n, err := r.file.Read(p)
+	if n > 0 || (err != nil && err != io.EOF) {
+		return n, err
+	}
+
+	current, err := r.file.Stat()
+	if err != nil {
+		return 0, err
+	}
+	latest, err := os.Stat(r.filename)
+	if err != nil || os.SameFile(current, latest) {
+		// The log file may be renamed and the new one is not created yet.
+		return 0, nil
+	}
+
+	// The log file has been rotated, drain it before switching to the new one.
+	// All the logs have been written into it before renaming.
+	if n, err = r.file.Read(p); n > 0 || (err != nil && err != io.EOF) {
+		return n, err
+	}
+	file, err := os.Open(r.filename)
+	if err != nil {
+		return 0, nil
+	}
+	r.file.Close()
+	r.file = file
+	return 0, nil
+}
+
+func (r *followReader) Close() error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	select {
+	case <-r.closed:
+		return nil
+	default:
+	}
+	close(r.closed)
+	return r.file.Close()
+}
@@ -0,0 +1,71 @@
+/*
+ * Copyright 2023 Greptime Team
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package baremetal
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFilterLogLines(t *testing.T) {
+	var (
+		format = "2006-01-02T15:04:05Z"
+		now    = time.Now().UTC()
+		lines  = []string{
+			fmt.Sprintf("%s old line\n", now.Add(-time.Hour).Format(format)),
+			"  old stack\n",
+			fmt.Sprintf("%s new line 1\n", now.Add(-time.Minute).Format(format)),
+			"  new stack\n",
+			fmt.Sprintf("%s new line 2\n", now.Format(format)),
+		}
+	)
+
+	tests := []struct {
+		name  string
+		since time.Duration
+		tail  int64
+		want  []string
+	}{
+		{name: "all", since: 0, tail: -1, want: lines},
+		{name: "since", since: 10 * time.Minute, tail: -1, want: lines[2:]},
+		{name: "tail", since: 0, tail: 2, want: lines[3:]},
+		{name: "since and tail", since: 10 * time.Minute, tail: 1, want: lines[4:]},
+		{name: "nothing", since: 0, tail: 0, want: nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := filterLogLines(strings.NewReader(strings.Join(lines, "")), tt.since, tt.tail)
+			assert.NoError(t, err)
+			assert.Equal(t, strings.Join(tt.want, ""), string(got))
+		})
+	}
+}
+
+func TestSplitReplicaName(t *testing.T) {
+	name, index, ok := splitReplicaName("datanode.12")
+	assert.True(t, ok)
+	assert.Equal(t, "datanode", name)
+	assert.Equal(t, 12, index)
+
+	_, _, ok = splitReplicaName(SupervisorName)
+	assert.False(t, ok)
+}
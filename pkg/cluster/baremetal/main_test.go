@@ -0,0 +1,63 @@
+/*
+ * Copyright 2023 Greptime Team
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package baremetal
+
+import (
+	"context"
+	"io"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/GreptimeTeam/gtctl/pkg/components"
+	"github.com/GreptimeTeam/gtctl/pkg/components/greptimetest"
+	"github.com/GreptimeTeam/gtctl/pkg/config"
+	"github.com/GreptimeTeam/gtctl/pkg/logger"
+	"github.com/GreptimeTeam/gtctl/pkg/metadata"
+)
+
+func TestMain(m *testing.M) {
+	greptimetest.Main(m)
+}
+
+// newTestCluster returns the cluster whose components run with the binary, the components are not started.
+// The metasrv uses the memory store, so the cluster runs without etcd.
+func newTestCluster(t *testing.T, cfg *config.BareMetalClusterConfig, binary string) *Cluster {
+	mm, err := metadata.New(t.TempDir())
+	assert.NoError(t, err)
+	mm.AllocateClusterScopeDirs("mycluster")
+	assert.NoError(t, mm.CreateClusterScopeDirs(cfg))
+	csd := mm.GetClusterScopeDirs()
+
+	c := &Cluster{
+		config:          cfg,
+		mm:              mm,
+		useMemoryMeta:   true,
+		greptimeBinPath: binary,
+		logger:          logger.New(io.Discard, 0),
+	}
+	c.ctx, c.stop = context.WithCancel(context.Background())
+	t.Cleanup(c.stop)
+	c.cc = NewClusterComponents(cfg, components.WorkingDirs{
+		DataDir:    csd.DataDir,
+		LogsDir:    csd.LogsDir,
+		PidsDir:    csd.PidsDir,
+		ConfigsDir: csd.ConfigsDir,
+	}, nil, &c.wg, c.logger, c.useMemoryMeta)
+
+	return c
+}
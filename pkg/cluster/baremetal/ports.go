@@ -0,0 +1,364 @@
+/*
+ * Copyright 2023 Greptime Team
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package baremetal
+
+import (
+	"fmt"
+	"net"
+	"strconv"
+	"strings"
+
+	"github.com/GreptimeTeam/gtctl/pkg/components"
+	"github.com/GreptimeTeam/gtctl/pkg/config"
+	"github.com/GreptimeTeam/gtctl/pkg/logger"
+)
+
+// maxPort is the max port that can be allocated.
+const maxPort = 65535
+
+// portRange is the ports that listened by the replicas of component on one address,
+// the port of each replica is increased one by one from the base address, see components.FormatAddrArg.
+type portRange struct {
+	component string
+	field     string
+	addr      *string
+	replicas  int
+
+	// process decides the remote host that each replica runs on, it's nil if all the replicas run locally.
+	process *config.Process
+}
+
+// endpoint is the address that listened by one replica.
+type endpoint struct {
+	name string
+	host string
+	port int
+
+	// machine is the remote host that the replica runs on, it's empty if the replica runs locally.
+	machine string
+}
+
+func (e endpoint) String() string {
+	return fmt.Sprintf("%s(%s)", e.name, net.JoinHostPort(e.host, strconv.Itoa(e.port)))
+}
+
+// overlaps returns true if the two endpoints can not listen at the same time.
+func (e endpoint) overlaps(other endpoint) bool {
+	if e.port != other.port || e.machine != other.machine {
+		return false
+	}
+	return e.host == other.host || isUnspecifiedHost(e.host) || isUnspecifiedHost(other.host)
+}
+
+func isUnspecifiedHost(host string) bool {
+	ip := net.ParseIP(host)
+	return len(host) == 0 || (ip != nil && ip.IsUnspecified())
+}
+
+// isPortFree checks whether the endpoint can be listened on.
+// The endpoint on remote host is always treated as free, since it can't be checked locally.
+func isPortFree(e endpoint) bool {
+	if len(e.machine) > 0 {
+		return true
+	}
+
+	listener, err := net.Listen("tcp", net.JoinHostPort(e.host, strconv.Itoa(e.port)))
+	if err != nil {
+		return false
+	}
+	_ = listener.Close()
+	return true
+}
+
+// endpoints returns the endpoints of all the replicas whose base port is basePort.
+func (r *portRange) endpoints(host string, basePort int) []endpoint {
+	var ret []endpoint
+	for i := 0; i < r.replicas; i++ {
+		e := endpoint{
+			name: fmt.Sprintf("%s.%d %s", r.component, i, r.field),
+			host: host,
+			port: basePort + i,
+		}
+		if r.process != nil {
+			e.machine = components.ReplicaProcessOptions(*r.process, i).Host
+		}
+		ret = append(ret, e)
+	}
+	return ret
+}
+
+func (r *portRange) split() (string, int, error) {
+	host, port, err := net.SplitHostPort(*r.addr)
+	if err != nil {
+		return "", 0, fmt.Errorf("invalid %s of %s '%s': %v", r.field, r.component, *r.addr, err)
+	}
+	portInt, err := strconv.Atoi(port)
+	if err != nil {
+		return "", 0, fmt.Errorf("invalid %s of %s '%s': %v", r.field, r.component, *r.addr, err)
+	}
+	return host, portInt, nil
+}
+
+// collectPortRanges returns the port ranges of all the addresses that listened by the cluster.
+// The addresses that are not specified and have no known defaults are skipped, since they are decided by the binary.
+func collectPortRanges(cfg *config.BareMetalClusterConfig, useMemoryMeta bool) []*portRange {
+	var (
+		ranges []*portRange
+		add    = func(component, field string, addr *string, replicas int, process *config.Process) {
+			if len(*addr) > 0 {
+				ranges = append(ranges, &portRange{
+					component: component,
+					field:     field,
+					addr:      addr,
+					replicas:  replicas,
+					process:   process,
+				})
+			}
+		}
+	)
+
+	if cfg.IsStandalone() {
+		standalone := cfg.Cluster.Standalone
+		add(components.StandaloneName, "httpAddr", &standalone.HTTPAddr, 1, &standalone.Process)
+		add(components.StandaloneName, "grpcAddr", &standalone.GRPCAddr, 1, &standalone.Process)
+		add(components.StandaloneName, "mysqlAddr", &standalone.MysqlAddr, 1, &standalone.Process)
+		add(components.StandaloneName, "postgresAddr", &standalone.PostgresAddr, 1, &standalone.Process)
+		return ranges
+	}
+
+	// The frontend listens on its default addresses if they are not specified.
+	frontend, defaultFrontend := cfg.Cluster.Frontend, config.DefaultBareMetalConfig().Cluster.Frontend
+	replicas, process := frontend.Replicas, &frontend.Process
+	add("frontend", "httpAddr", orDefault(&frontend.HTTPAddr, defaultFrontend.HTTPAddr), replicas, process)
+	add("frontend", "grpcAddr", orDefault(&frontend.GRPCAddr, defaultFrontend.GRPCAddr), replicas, process)
+	add("frontend", "mysqlAddr", orDefault(&frontend.MysqlAddr, defaultFrontend.MysqlAddr), replicas, process)
+	add("frontend", "postgresAddr", orDefault(&frontend.PostgresAddr, defaultFrontend.PostgresAddr), replicas, process)
+
+	datanode := cfg.Cluster.Datanode
+	add("datanode", "rpcAddr", &datanode.RPCAddr, datanode.Replicas, &datanode.Process)
+	add("datanode", "httpAddr", &datanode.HTTPAddr, datanode.Replicas, &datanode.Process)
+
+	metaSrv := cfg.Cluster.MetaSrv
+	replicas, process = metaSrv.Replicas, &metaSrv.Process
+	add("metasrv", "bindAddr", orDefault(&metaSrv.BindAddr, components.DefaultMetaSrvBindAddr), replicas, process)
+	add("metasrv", "httpAddr", &metaSrv.HTTPAddr, replicas, process)
+
+	if !useMemoryMeta {
+		etcd := cfg.Etcd
+		replicas = components.EtcdReplicas(etcd)
+		add("etcd", "clientAddr", orDefault(&etcd.ClientAddr, components.EtcdClientAddr(etcd, 0)), replicas, nil)
+		add("etcd", "peerAddr", orDefault(&etcd.PeerAddr, components.EtcdPeerAddr(etcd, 0)), replicas, nil)
+	}
+
+	return ranges
+}
+
+// orDefault returns addr if it's specified, otherwise returns the default address that used by the component.
+func orDefault(addr *string, defaultAddr string) *string {
+	if len(*addr) > 0 {
+		return addr
+	}
+	return &defaultAddr
+}
+
+// checkPorts checks the full port map of the cluster before it starts,
+// and reports all the conflicts between the replicas and the ports that are already in use.
+func checkPorts(cfg *config.BareMetalClusterConfig, useMemoryMeta bool) error {
+	var (
+		problems []string
+		checked  []endpoint
+	)
+	for _, r := range collectPortRanges(cfg, useMemoryMeta) {
+		host, port, err := r.split()
+		if err != nil {
+			return err
+		}
+
+		for _, e := range r.endpoints(host, port) {
+			problems = append(problems, conflicts(e, checked)...)
+			checked = append(checked, e)
+		}
+	}
+
+	if len(problems) > 0 {
+		return fmt.Errorf("found %d port conflict(s), fix them in config or pick free ports by '--auto-ports':\n  - %s",
+			len(problems), strings.Join(problems, "\n  - "))
+	}
+	return nil
+}
+
+// checkScalePorts checks the ports of the new replicas before the component is scaled from oldReplicas to replicas.
+// The ports of running replicas are in use by themselves, so only the new ones are checked against others.
+func checkScalePorts(cfg *config.BareMetalClusterConfig, useMemoryMeta bool, component string, oldReplicas, replicas int) error {
+	var (
+		problems      []string
+		running, news []endpoint
+	)
+	for _, r := range collectPortRanges(scaledConfig(cfg, component, replicas), useMemoryMeta) {
+		host, port, err := r.split()
+		if err != nil {
+			return err
+		}
+
+		for i, e := range r.endpoints(host, port) {
+			if r.component == component && i >= oldReplicas {
+				news = append(news, e)
+			} else {
+				running = append(running, e)
+			}
+		}
+	}
+	for _, e := range news {
+		problems = append(problems, conflicts(e, running)...)
+		running = append(running, e)
+	}
+
+	if len(problems) > 0 {
+		return fmt.Errorf("found %d port conflict(s) of the new %s replicas, fix them in config before scaling:\n  - %s",
+			len(problems), component, strings.Join(problems, "\n  - "))
+	}
+	return nil
+}
+
+// scaledConfig returns a copy of cfg whose component has the replicas, cfg is not changed.
+func scaledConfig(cfg *config.BareMetalClusterConfig, component string, replicas int) *config.BareMetalClusterConfig {
+	scaled, cluster := *cfg, *cfg.Cluster
+	scaled.Cluster = &cluster
+	switch component {
+	case "frontend":
+		frontend := *cluster.Frontend
+		frontend.Replicas, cluster.Frontend = replicas, &frontend
+	case "datanode":
+		datanode := *cluster.Datanode
+		datanode.Replicas, cluster.Datanode = replicas, &datanode
+	case "metasrv":
+		metaSrv := *cluster.MetaSrv
+		metaSrv.Replicas, cluster.MetaSrv = replicas, &metaSrv
+	}
+	return &scaled
+}
+
+// conflicts returns the problems of the endpoint, it conflicts with the checked endpoints or it's already in use.
+func conflicts(e endpoint, checked []endpoint) []string {
+	var problems []string
+	for _, other := range checked {
+		if e.overlaps(other) {
+			problems = append(problems, fmt.Sprintf("%s conflicts with %s", e, other))
+		}
+	}
+	if len(problems) == 0 && !isPortFree(e) {
+		problems = append(problems, fmt.Sprintf("%s is already in use", e))
+	}
+	return problems
+}
+
+// allocatePorts picks free ports for the addresses that conflict with others or are already in use,
+// the addresses in cfg are updated in place so the allocated ports can be persisted.
+// The port of address is kept if it's free.
+func allocatePorts(cfg *config.BareMetalClusterConfig, useMemoryMeta bool, l logger.Logger) error {
+	// The default addresses may be changed, so they are persisted explicitly.
+	var (
+		metaSrv         *config.MetaSrv
+		etcdClientAddrs []string
+	)
+	if !cfg.IsStandalone() {
+		frontend, defaultFrontend := cfg.Cluster.Frontend, config.DefaultBareMetalConfig().Cluster.Frontend
+		frontend.HTTPAddr = *orDefault(&frontend.HTTPAddr, defaultFrontend.HTTPAddr)
+		frontend.GRPCAddr = *orDefault(&frontend.GRPCAddr, defaultFrontend.GRPCAddr)
+		frontend.MysqlAddr = *orDefault(&frontend.MysqlAddr, defaultFrontend.MysqlAddr)
+		frontend.PostgresAddr = *orDefault(&frontend.PostgresAddr, defaultFrontend.PostgresAddr)
+
+		metaSrv = cfg.Cluster.MetaSrv
+		metaSrv.BindAddr = *orDefault(&metaSrv.BindAddr, components.DefaultMetaSrvBindAddr)
+		if !useMemoryMeta {
+			cfg.Etcd.ClientAddr = components.EtcdClientAddr(cfg.Etcd, 0)
+			cfg.Etcd.PeerAddr = components.EtcdPeerAddr(cfg.Etcd, 0)
+			for i := 0; i < components.EtcdReplicas(cfg.Etcd); i++ {
+				etcdClientAddrs = append(etcdClientAddrs, components.EtcdClientAddr(cfg.Etcd, i))
+			}
+		}
+	}
+
+	var reserved []endpoint
+	for _, r := range collectPortRanges(cfg, useMemoryMeta) {
+		host, basePort, err := r.split()
+		if err != nil {
+			return err
+		}
+
+		allocated := -1
+		for port := basePort; port+r.replicas-1 <= maxPort; port++ {
+			if isRangeFree(r.endpoints(host, port), reserved) {
+				allocated = port
+				break
+			}
+		}
+		if allocated < 0 {
+			return fmt.Errorf("no free ports for %s of %s from %d", r.field, r.component, basePort)
+		}
+		reserved = append(reserved, r.endpoints(host, allocated)...)
+
+		if allocated != basePort {
+			oldAddr := *r.addr
+			*r.addr = net.JoinHostPort(host, strconv.Itoa(allocated))
+			l.V(0).Infof("The %s of %s is changed from '%s' to '%s'", r.field, r.component, oldAddr, *r.addr)
+
+			// Others access metasrv by its server address, which shares the port with its bind address.
+			if metaSrv != nil && r.addr == &metaSrv.BindAddr {
+				serverHost, serverPort, err := net.SplitHostPort(metaSrv.ServerAddr)
+				if err == nil && serverPort == strconv.Itoa(basePort) {
+					metaSrv.ServerAddr = net.JoinHostPort(serverHost, strconv.Itoa(allocated))
+				}
+			}
+		}
+	}
+
+	// Metasrv connects to etcd by its store address if it's set, which is the client address of one etcd member.
+	if metaSrv != nil && len(metaSrv.StoreAddr) > 0 {
+		storeHost, _, err := net.SplitHostPort(metaSrv.StoreAddr)
+		if err != nil {
+			return err
+		}
+		storeAddr := components.AdvertiseAddr(strings.Replace(metaSrv.StoreAddr, "localhost", "127.0.0.1", 1))
+		for i, oldAddr := range etcdClientAddrs {
+			_, oldPort, _ := net.SplitHostPort(oldAddr)
+			_, newPort, _ := net.SplitHostPort(components.EtcdClientAddr(cfg.Etcd, i))
+			if storeAddr == components.AdvertiseAddr(oldAddr) && newPort != oldPort {
+				oldStoreAddr := metaSrv.StoreAddr
+				metaSrv.StoreAddr = net.JoinHostPort(storeHost, newPort)
+				l.V(0).Infof("The storeAddr of metasrv is changed from '%s' to '%s'", oldStoreAddr, metaSrv.StoreAddr)
+				break
+			}
+		}
+	}
+
+	return nil
+}
+
+func isRangeFree(endpoints, reserved []endpoint) bool {
+	for _, e := range endpoints {
+		for _, other := range reserved {
+			if e.overlaps(other) {
+				return false
+			}
+		}
+		if !isPortFree(e) {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,201 @@
+/*
+ * Copyright 2023 Greptime Team
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package baremetal
+
+import (
+	"fmt"
+	"io"
+	"net"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/GreptimeTeam/gtctl/pkg/config"
+	"github.com/GreptimeTeam/gtctl/pkg/logger"
+)
+
+// testPortsConfig returns the config whose addresses are all based on the given port.
+func testPortsConfig(base int) *config.BareMetalClusterConfig {
+	addr := func(offset int) string {
+		return fmt.Sprintf("127.0.0.1:%d", base+offset)
+	}
+
+	cfg := config.DefaultBareMetalConfig()
+	cfg.Cluster.Frontend.HTTPAddr = addr(0)
+	cfg.Cluster.Frontend.GRPCAddr = addr(1)
+	cfg.Cluster.Frontend.MysqlAddr = addr(2)
+	cfg.Cluster.Frontend.PostgresAddr = addr(3)
+	cfg.Cluster.Datanode.Replicas = 3
+	cfg.Cluster.Datanode.RPCAddr = addr(10)
+	cfg.Cluster.Datanode.HTTPAddr = addr(20)
+	cfg.Cluster.MetaSrv.ServerAddr = addr(30)
+	cfg.Cluster.MetaSrv.BindAddr = addr(30)
+	cfg.Cluster.MetaSrv.HTTPAddr = addr(31)
+	cfg.Etcd.ClientAddr = addr(40)
+	return cfg
+}
+
+func TestCheckPorts(t *testing.T) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer listener.Close()
+	// Leave some room for the ports below the listened one.
+	base := listener.Addr().(*net.TCPAddr).Port - 50
+	if base <= 0 {
+		t.Skip("no room for the testing ports")
+	}
+
+	cfg := testPortsConfig(base)
+	// The port is already in use.
+	cfg.Cluster.Frontend.HTTPAddr = listener.Addr().String()
+	// The ranges of datanode rpcAddr and httpAddr are overlapped.
+	cfg.Cluster.Datanode.HTTPAddr = fmt.Sprintf("0.0.0.0:%d", base+12)
+
+	err = checkPorts(cfg, false)
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), fmt.Sprintf("frontend.0 httpAddr(%s) is already in use", listener.Addr()))
+	assert.Contains(t, err.Error(), fmt.Sprintf("datanode.0 httpAddr(0.0.0.0:%d) conflicts with datanode.2 rpcAddr(127.0.0.1:%d)", base+12, base+12))
+
+	assert.NoError(t, allocatePorts(cfg, false, logger.New(io.Discard, 0)))
+	assert.NotEqual(t, listener.Addr().String(), cfg.Cluster.Frontend.HTTPAddr)
+	assert.Equal(t, fmt.Sprintf("0.0.0.0:%d", base+13), cfg.Cluster.Datanode.HTTPAddr)
+	// The free ports are kept.
+	assert.Equal(t, fmt.Sprintf("127.0.0.1:%d", base+10), cfg.Cluster.Datanode.RPCAddr)
+	assert.Equal(t, fmt.Sprintf("127.0.0.1:%d", base+41), cfg.Etcd.PeerAddr)
+	assert.NoError(t, checkPorts(cfg, false))
+}
+
+func TestCheckScalePorts(t *testing.T) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer listener.Close()
+	base := listener.Addr().(*net.TCPAddr).Port - 50
+	if base <= 0 {
+		t.Skip("no room for the testing ports")
+	}
+	cfg := testPortsConfig(base)
+
+	// The ports of new replicas are checked against the others, but the running ones are not checked.
+	err = checkScalePorts(cfg, false, "frontend", 1, 2)
+	assert.ErrorContains(t, err, fmt.Sprintf("frontend.1 httpAddr(127.0.0.1:%d) conflicts with frontend.0 grpcAddr(127.0.0.1:%d)",
+		base+1, base+1))
+	assert.NoError(t, checkScalePorts(cfg, false, "datanode", 3, 4))
+	assert.Equal(t, 1, cfg.Cluster.Frontend.Replicas)
+	assert.Equal(t, 3, cfg.Cluster.Datanode.Replicas)
+
+	// The new replica whose port is in use.
+	cfg.Cluster.MetaSrv.HTTPAddr = fmt.Sprintf("127.0.0.1:%d", base+49)
+	err = checkScalePorts(cfg, false, "metasrv", 1, 2)
+	assert.ErrorContains(t, err, fmt.Sprintf("metasrv.1 httpAddr(%s) is already in use", listener.Addr()))
+}
+
+func TestAllocateStoreAddr(t *testing.T) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer listener.Close()
+	// The etcd client port of the config is the one in use, e.g. taken by the etcd of another cluster.
+	base := listener.Addr().(*net.TCPAddr).Port - 40
+	if base <= 0 {
+		t.Skip("no room for the testing ports")
+	}
+
+	// The store address follows the etcd member whose client port is changed.
+	cfg := testPortsConfig(base)
+	cfg.Cluster.MetaSrv.StoreAddr = listener.Addr().String()
+	assert.NoError(t, allocatePorts(cfg, false, logger.New(io.Discard, 0)))
+	assert.NotEqual(t, listener.Addr().String(), cfg.Etcd.ClientAddr)
+	assert.Equal(t, cfg.Etcd.ClientAddr, cfg.Cluster.MetaSrv.StoreAddr)
+
+	cfg = testPortsConfig(base)
+	cfg.Cluster.MetaSrv.StoreAddr = fmt.Sprintf("localhost:%d", base+40)
+	assert.NoError(t, allocatePorts(cfg, false, logger.New(io.Discard, 0)))
+	_, port, _ := net.SplitHostPort(cfg.Etcd.ClientAddr)
+	assert.Equal(t, "localhost:"+port, cfg.Cluster.MetaSrv.StoreAddr)
+
+	// The store address of the etcd outside the cluster is kept.
+	cfg = testPortsConfig(base)
+	storeAddr := fmt.Sprintf("192.168.1.10:%d", base+40)
+	cfg.Cluster.MetaSrv.StoreAddr = storeAddr
+	assert.NoError(t, allocatePorts(cfg, false, logger.New(io.Discard, 0)))
+	assert.NotEqual(t, listener.Addr().String(), cfg.Etcd.ClientAddr)
+	assert.Equal(t, storeAddr, cfg.Cluster.MetaSrv.StoreAddr)
+}
+
+func TestCheckStandalonePorts(t *testing.T) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer listener.Close()
+
+	cfg := &config.BareMetalClusterConfig{
+		Topology: config.TopologyStandalone,
+		Cluster: &config.BareMetalClusterComponentsConfig{
+			Standalone: &config.Standalone{
+				HTTPAddr:  listener.Addr().String(),
+				MysqlAddr: listener.Addr().String(),
+			},
+		},
+	}
+
+	err = checkPorts(cfg, false)
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), fmt.Sprintf("standalone.0 httpAddr(%s) is already in use", listener.Addr()))
+	assert.Contains(t, err.Error(), fmt.Sprintf("standalone.0 mysqlAddr(%s) conflicts with standalone.0 httpAddr(%s)",
+		listener.Addr(), listener.Addr()))
+
+	assert.NoError(t, allocatePorts(cfg, false, logger.New(io.Discard, 0)))
+	assert.NotEqual(t, cfg.Cluster.Standalone.HTTPAddr, cfg.Cluster.Standalone.MysqlAddr)
+	assert.NoError(t, checkPorts(cfg, false))
+}
+
+func TestCheckRemotePorts(t *testing.T) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer listener.Close()
+	base := listener.Addr().(*net.TCPAddr).Port - 50
+	if base <= 0 {
+		t.Skip("no room for the testing ports")
+	}
+
+	cfg := testPortsConfig(base)
+	cfg.Hosts = []*config.Host{{Name: "node-1", Address: "192.168.1.10:22"}, {Name: "node-2", Address: "192.168.1.11:22"}}
+	cfg.Cluster.Frontend.Host = "node-1"
+	cfg.Cluster.Datanode.Host = "node-2"
+	cfg.Cluster.Datanode.ReplicaOverrides = map[int]*config.ProcessOptions{2: {Host: "node-1"}}
+
+	// The port in use locally is free on the remote host.
+	cfg.Cluster.Frontend.HTTPAddr = listener.Addr().String()
+	// The replicas on different hosts can listen on the same port.
+	cfg.Cluster.Frontend.GRPCAddr = fmt.Sprintf("0.0.0.0:%d", base+10)
+	// The replicas on the same host conflict.
+	cfg.Cluster.Frontend.MysqlAddr = fmt.Sprintf("0.0.0.0:%d", base+12)
+
+	err = checkPorts(cfg, false)
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), fmt.Sprintf("datanode.2 rpcAddr(127.0.0.1:%d) conflicts with frontend.0 mysqlAddr(0.0.0.0:%d)", base+12, base+12))
+	assert.NotContains(t, err.Error(), "already in use")
+	assert.NotContains(t, err.Error(), "datanode.0 rpcAddr")
+}
@@ -0,0 +1,121 @@
+/*
+ * Copyright 2023 Greptime Team
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package baremetal
+
+import (
+	"context"
+	"fmt"
+
+	greptimedbclusterv1alpha1 "github.com/GreptimeTeam/greptimedb-operator/apis/v1alpha1"
+
+	opt "github.com/GreptimeTeam/gtctl/pkg/cluster"
+	"github.com/GreptimeTeam/gtctl/pkg/components"
+	"github.com/GreptimeTeam/gtctl/pkg/config"
+)
+
+// Scale scales the component of a running cluster by sending scale command to the process that runs the cluster,
+// no matter it runs in foreground or in a background supervisor.
+func (c *Cluster) Scale(ctx context.Context, options *opt.ScaleOptions) error {
+	if options.NewReplicas <= 0 {
+		return fmt.Errorf("replicas should be greater than 0 in bare-metal mode")
+	}
+
+	if _, err := c.get(ctx, &opt.GetOptions{Name: options.Name}); err != nil {
+		return err
+	}
+
+	c.logger.V(0).Infof("Scaling %s of cluster '%s' to %d...", options.ComponentType, options.Name, options.NewReplicas)
+
+	csd := c.mm.GetClusterScopeDirs()
+	rsp, err := sendSupervisorCommand(ctx, csd.SocketPath, &supervisorRequest{
+		Command:   supervisorCommandScale,
+		Component: string(options.ComponentType),
+		Replicas:  int(options.NewReplicas),
+	})
+	if rsp == nil && err != nil {
+		return fmt.Errorf("failed to connect to cluster '%s', is it running? error: %v", options.Name, err)
+	}
+	if err != nil {
+		return err
+	}
+	options.OldReplicas = int32(rsp.OldReplicas)
+
+	c.logger.V(0).Infof("Scaled %s of cluster '%s' from %d to %d", options.ComponentType, options.Name,
+		options.OldReplicas, options.NewReplicas)
+
+	return nil
+}
+
+// scale scales the component of the cluster that runs in current process and persists the new replicas.
+// It returns the replicas of the component before scaling.
+func (c *Cluster) scale(componentType string, replicas int) (int, error) {
+	if c.config.IsStandalone() {
+		return 0, fmt.Errorf("scaling is not supported in standalone topology")
+	}
+
+	var (
+		component   components.ClusterComponent
+		oldReplicas int
+	)
+	switch greptimedbclusterv1alpha1.ComponentKind(componentType) {
+	case greptimedbclusterv1alpha1.FrontendComponentKind:
+		component, oldReplicas = c.cc.Frontend, c.config.Cluster.Frontend.Replicas
+	case greptimedbclusterv1alpha1.DatanodeComponentKind:
+		component, oldReplicas = c.cc.Datanode, c.config.Cluster.Datanode.Replicas
+	case greptimedbclusterv1alpha1.MetaComponentKind:
+		component, oldReplicas = c.cc.MetaSrv, c.config.Cluster.MetaSrv.Replicas
+	default:
+		return 0, fmt.Errorf("unsupported component type '%s'", componentType)
+	}
+
+	if replicas == oldReplicas {
+		return oldReplicas, nil
+	}
+
+	// The new replica whose port is taken would exit at once, so the ports are checked before it starts.
+	if replicas > oldReplicas {
+		if err := checkScalePorts(c.config, c.useMemoryMeta, component.Name(), oldReplicas, replicas); err != nil {
+			return oldReplicas, err
+		}
+	}
+
+	c.logger.V(0).Infof("Scaling %s from %d to %d", componentType, oldReplicas, replicas)
+	scaleErr := component.Scale(c.ctx, c.failFunc(component), c.greptimeBinPath, replicas)
+	if scaleErr != nil {
+		c.setComponentState(component.Name(), config.ComponentPhaseUnhealthy, errorReason(scaleErr))
+	} else {
+		c.setComponentState(component.Name(), config.ComponentPhaseRunning, "")
+	}
+
+	// The component keeps the replicas in config consistent with its running replicas even if scaling failed.
+	datanodeDirs, err := c.datanodeDirs()
+	if err != nil {
+		return oldReplicas, err
+	}
+	if err := c.mm.UpdateClusterMetadata(func(md *config.BareMetalClusterMetadata) {
+		md.Config = c.config
+
+		// The data of removed datanode replicas remains and is reused when scaling up again.
+		if len(datanodeDirs) > len(md.DatanodeDirs) {
+			md.DatanodeDirs = datanodeDirs
+		}
+	}); err != nil {
+		return oldReplicas, err
+	}
+
+	return oldReplicas, scaleErr
+}
@@ -0,0 +1,93 @@
+/*
+ * Copyright 2023 Greptime Team
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package baremetal
+
+import (
+	"net"
+	"os"
+	"path/filepath"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/GreptimeTeam/gtctl/pkg/components/greptimetest"
+	"github.com/GreptimeTeam/gtctl/pkg/config"
+)
+
+func TestScale(t *testing.T) {
+	cfg := config.DefaultBareMetalConfig()
+	cfg.Cluster.Datanode.Replicas = 1
+	cfg.Cluster.Datanode.HTTPAddr = greptimetest.FreeAddr(t, 3)
+	cfg.Cluster.Datanode.StartupTimeout = 10 * time.Second
+	c := newTestCluster(t, cfg, greptimetest.NewBinary(t, greptimetest.Options{}))
+
+	datanode := c.cc.Datanode
+	assert.NoError(t, datanode.Start(c.ctx, c.failFunc(datanode), c.greptimeBinPath))
+	defer func() {
+		assert.NoError(t, datanode.Stop(5*time.Second))
+		c.wg.Wait()
+	}()
+
+	assertPersisted := func(replicas, datanodeDirs int, phase config.ComponentPhase) {
+		t.Helper()
+		md, err := c.mm.GetClusterMetadata()
+		assert.NoError(t, err)
+		assert.Equal(t, replicas, md.Config.Cluster.Datanode.Replicas)
+		assert.Len(t, md.DatanodeDirs, datanodeDirs)
+		assert.Equal(t, phase, md.State.Components[0].Phase)
+	}
+
+	oldReplicas, err := c.scale("datanode", 3)
+	assert.NoError(t, err)
+	assert.Equal(t, 1, oldReplicas)
+	assertPersisted(3, 3, config.ComponentPhaseRunning)
+
+	// The dirs of removed replicas are kept, so their data is reused when scaling out again.
+	oldReplicas, err = c.scale("datanode", 1)
+	assert.NoError(t, err)
+	assert.Equal(t, 3, oldReplicas)
+	assertPersisted(1, 3, config.ComponentPhaseRunning)
+
+	// The new replica whose port is taken is not started, and the running cluster is not stopped.
+	host, port, err := net.SplitHostPort(cfg.Cluster.Datanode.HTTPAddr)
+	assert.NoError(t, err)
+	basePort, err := strconv.Atoi(port)
+	assert.NoError(t, err)
+	listener, err := net.Listen("tcp", net.JoinHostPort(host, strconv.Itoa(basePort+1)))
+	assert.NoError(t, err)
+	_, err = c.scale("datanode", 2)
+	assert.ErrorContains(t, err, "datanode.1 httpAddr")
+	assert.ErrorContains(t, err, "is already in use")
+	assertPersisted(1, 3, config.ComponentPhaseRunning)
+	assert.NoError(t, c.ctx.Err())
+	assert.NoError(t, listener.Close())
+
+	// The new replicas are stopped if the scaling fails midway, and the replicas before scaling are persisted.
+	notDir := filepath.Join(t.TempDir(), "file")
+	assert.NoError(t, os.WriteFile(notDir, nil, 0644))
+	cfg.Cluster.Datanode.ReplicaOverrides = map[int]*config.ProcessOptions{2: {WorkingDir: filepath.Join(notDir, "work")}}
+	_, err = c.scale("datanode", 3)
+	assert.Error(t, err)
+	assertPersisted(1, 3, config.ComponentPhaseUnhealthy)
+	assert.NoError(t, c.ctx.Err())
+	assert.True(t, datanode.IsRunning(c.ctx))
+
+	_, err = c.scale("etcd", 3)
+	assert.ErrorContains(t, err, "unsupported component type 'etcd'")
+}
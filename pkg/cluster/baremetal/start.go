@@ -0,0 +1,145 @@
+/*
+ * Copyright 2023 Greptime Team
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package baremetal
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/exec"
+	"path"
+	"strings"
+	"syscall"
+	"time"
+
+	opt "github.com/GreptimeTeam/gtctl/pkg/cluster"
+	"github.com/GreptimeTeam/gtctl/pkg/logger"
+	fileutils "github.com/GreptimeTeam/gtctl/pkg/utils/file"
+)
+
+// Start starts the persisted cluster in a background supervisor process,
+// which is detached from current process and keeps running after current process exits.
+func (c *Cluster) Start(ctx context.Context, options *opt.StartOptions) error {
+	spinner := options.Spinner
+	if spinner != nil {
+		spinner.Start("Starting GreptimeDB cluster in background...")
+	}
+
+	if err := c.start(ctx, options); err != nil {
+		if spinner != nil {
+			spinner.Stop(false, "Starting GreptimeDB cluster failed")
+		}
+		return err
+	}
+
+	if spinner != nil {
+		spinner.Stop(true, "Starting GreptimeDB cluster successfully 🎉")
+	}
+
+	csd := c.mm.GetClusterScopeDirs()
+	c.logger.V(0).Infof("The cluster '%s' is running in background, you can stop it by '%s'",
+		options.Name, logger.Bold(fmt.Sprintf("gtctl cluster stop %s", options.Name)))
+	c.logger.V(0).Infof("The logs of the supervisor are in %s", logger.Bold(path.Join(csd.LogsDir, SupervisorName)))
+
+	return nil
+}
+
+func (c *Cluster) start(ctx context.Context, options *opt.StartOptions) error {
+	md, err := c.get(ctx, &opt.GetOptions{Name: options.Name})
+	if err != nil {
+		return err
+	}
+	if state := c.observedState(md); state.IsActive() {
+		return fmt.Errorf("cluster '%s' is already %s (pid %d)", options.Name, strings.ToLower(string(state.Phase)), state.Pid)
+	}
+	if err = checkPorts(c.config, c.useMemoryMeta); err != nil {
+		return err
+	}
+
+	executable, err := os.Executable()
+	if err != nil {
+		return err
+	}
+
+	csd := c.mm.GetClusterScopeDirs()
+	supervisorLogDir := path.Join(csd.LogsDir, SupervisorName)
+	if err = fileutils.EnsureDir(supervisorLogDir); err != nil {
+		return err
+	}
+	logFile, err := os.OpenFile(path.Join(supervisorLogDir, "log"), os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0644)
+	if err != nil {
+		return err
+	}
+	defer logFile.Close()
+
+	args := []string{
+		"cluster", "supervise", options.Name,
+		fmt.Sprintf("--enable-cache=%t", c.enableCache),
+		fmt.Sprintf("--use-greptime-cn-artifacts=%t", options.UseGreptimeCNArtifacts),
+	}
+	cmd := exec.Command(executable, args...)
+	cmd.Stdout = logFile
+	cmd.Stderr = logFile
+	// Run the supervisor in a new session, so it won't be killed when the terminal is closed.
+	cmd.SysProcAttr = &syscall.SysProcAttr{Setsid: true}
+
+	if err = cmd.Start(); err != nil {
+		return err
+	}
+	c.logger.V(3).Infof("run supervisor '%s' with args: '%v', pid: '%d'", executable, args, cmd.Process.Pid)
+
+	exited := make(chan error, 1)
+	go func() {
+		exited <- cmd.Wait()
+	}()
+
+	// Checking the status of the supervisor with intervals.
+	ticker := time.NewTicker(500 * time.Millisecond)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			statusCtx, cancel := context.WithTimeout(ctx, supervisorDialTimeout)
+			rsp, err := sendSupervisorCommand(statusCtx, csd.SocketPath, &supervisorRequest{Command: supervisorCommandStatus})
+			cancel()
+			if err != nil {
+				c.logger.V(5).Infof("failed to get the status of supervisor: %v", err)
+				continue
+			}
+			if rsp.Status == supervisorStatusRunning {
+				return nil
+			}
+		case err := <-exited:
+			return fmt.Errorf("supervisor exited unexpectedly(%v), you can find its logs in %s", err, supervisorLogDir)
+		case <-ctx.Done():
+			return fmt.Errorf("status checking failed: %v", ctx.Err())
+		}
+	}
+}
+
+// isPidRunning checks whether the process of pid is still alive by sending signal 0 to it.
+func (c *Cluster) isPidRunning(pid int) bool {
+	if pid <= 0 {
+		return false
+	}
+	p, err := os.FindProcess(pid)
+	if err != nil {
+		return false
+	}
+	return p.Signal(syscall.Signal(0)) == nil
+}
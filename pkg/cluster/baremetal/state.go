@@ -0,0 +1,211 @@
+/*
+ * Copyright 2023 Greptime Team
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package baremetal
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/GreptimeTeam/gtctl/pkg/components"
+	"github.com/GreptimeTeam/gtctl/pkg/config"
+	"github.com/GreptimeTeam/gtctl/pkg/logger"
+)
+
+// stateCheckInterval is the interval of checking the health of components to update their state.
+const stateCheckInterval = 5 * time.Second
+
+// observedState returns the state of cluster as it's observed now. The cluster that has no state is stopped,
+// and the active cluster whose process is gone is failed, since the process crashed or was killed
+// before it could record the state.
+func (c *Cluster) observedState(md *config.BareMetalClusterMetadata) *config.ClusterState {
+	if md.State == nil {
+		return &config.ClusterState{Phase: config.ClusterPhaseStopped}
+	}
+
+	state := *md.State
+	if state.IsActive() && !c.isPidRunning(state.Pid) {
+		state.Phase = config.ClusterPhaseFailed
+		state.Reason = fmt.Sprintf("the process (pid %d) that ran the cluster was gone while it was %s",
+			state.Pid, strings.ToLower(string(md.State.Phase)))
+		state.Since = state.UpdatedAt
+
+		// The replicas may still be running, but they are not supervised anymore.
+		state.Components = make([]*config.ComponentState, 0, len(md.State.Components))
+		for _, component := range md.State.Components {
+			component := *component
+			if component.Phase != config.ComponentPhaseStopped && component.Phase != config.ComponentPhaseFailed {
+				component.Phase, component.Reason = config.ComponentPhaseFailed, "not supervised anymore"
+			}
+			state.Components = append(state.Components, &component)
+		}
+	}
+	return &state
+}
+
+// printRestartTips tells the user to restart the running cluster to apply the changes, e.g. the new 'users',
+// which are only loaded when the cluster starts.
+func (c *Cluster) printRestartTips(md *config.BareMetalClusterMetadata, name, what string) {
+	state := c.observedState(md)
+	if !state.IsActive() {
+		return
+	}
+	c.logger.V(0).Infof("The cluster is %s, restart it by '%s' and '%s' to apply the new %s",
+		strings.ToLower(string(state.Phase)),
+		logger.Bold(fmt.Sprintf("gtctl cluster stop %s", name)),
+		logger.Bold(fmt.Sprintf("gtctl cluster start %s", name)), what)
+}
+
+// transit moves the persisted state of cluster to the phase atomically.
+// The cluster is run by current process once it starts creating.
+func (c *Cluster) transit(phase config.ClusterPhase, reason string) error {
+	var transitErr error
+	if err := c.mm.UpdateClusterMetadata(func(md *config.BareMetalClusterMetadata) {
+		state := c.observedState(md)
+		if transitErr = state.Transit(phase, reason, time.Now()); transitErr != nil {
+			return
+		}
+		if phase == config.ClusterPhaseCreating {
+			state.Pid = os.Getpid()
+			state.Components = nil
+		}
+		md.State = state
+	}); err != nil {
+		return err
+	}
+	if transitErr != nil {
+		return fmt.Errorf("cluster is %s: %v", c.currentPhase(), transitErr)
+	}
+
+	c.logger.V(3).Infof("cluster is %s now", strings.ToLower(string(phase)))
+	return nil
+}
+
+// currentPhase returns the phase of cluster as it's observed now.
+func (c *Cluster) currentPhase() config.ClusterPhase {
+	md, err := c.mm.GetClusterMetadata()
+	if err != nil {
+		return ""
+	}
+	return c.observedState(md).Phase
+}
+
+// setComponentState records the phase of component atomically, the failure is only logged
+// since it should not break the running cluster.
+func (c *Cluster) setComponentState(name string, phase config.ComponentPhase, reason string) {
+	if err := c.mm.UpdateClusterMetadata(func(md *config.BareMetalClusterMetadata) {
+		if md.State == nil {
+			md.State = &config.ClusterState{}
+		}
+		md.State.SetComponent(name, phase, reason, time.Now())
+	}); err != nil {
+		c.logger.Warnf("failed to record the state of %s: %v", name, err)
+	}
+}
+
+// fail records the failure of cluster, it's only logged if the failure can not be recorded.
+func (c *Cluster) fail(reason string) {
+	if err := c.transit(config.ClusterPhaseFailed, reason); err != nil {
+		c.logger.Warnf("failed to record the failure of cluster: %v", err)
+	}
+}
+
+// failFunc returns the func that the component calls to stop the cluster once its replica fails,
+// the component is recorded as failed before the cluster is stopped.
+func (c *Cluster) failFunc(component components.ClusterComponent) context.CancelFunc {
+	return func() {
+		c.failedMu.Lock()
+		if len(c.failedComponent) == 0 {
+			c.failedComponent = component.Name()
+		}
+		c.failedMu.Unlock()
+
+		c.stop()
+	}
+}
+
+// getFailedComponent returns the component whose failure stopped the cluster, it's empty if there's no failure.
+func (c *Cluster) getFailedComponent() string {
+	c.failedMu.Lock()
+	defer c.failedMu.Unlock()
+	return c.failedComponent
+}
+
+// watchState checks the health of components with intervals until the cluster is stopped,
+// the cluster is degraded if any component is unhealthy, and it's running again once they all recover.
+func (c *Cluster) watchState() {
+	ticker := time.NewTicker(stateCheckInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			// The components are not checked while they are being scaled or upgraded.
+			if c.supervisor != nil && !c.supervisor.opMu.TryLock() {
+				continue
+			}
+			c.checkState()
+			if c.supervisor != nil {
+				c.supervisor.opMu.Unlock()
+			}
+		case <-c.ctx.Done():
+			return
+		}
+	}
+}
+
+// checkState updates the state of components and cluster by their health, it only writes the changed state.
+func (c *Cluster) checkState() {
+	md, err := c.mm.GetClusterMetadata()
+	if err != nil || md.State == nil {
+		return
+	}
+
+	unhealthy := 0
+	for _, component := range c.cc.ordered(c.withEtcd()) {
+		phase, reason := config.ComponentPhaseRunning, ""
+		if !component.IsRunning(c.ctx) {
+			phase, reason = config.ComponentPhaseUnhealthy, "some replicas are not healthy"
+			unhealthy++
+		}
+		if c.ctx.Err() != nil {
+			return
+		}
+		if current := md.State.Component(component.Name()); current == nil || current.Phase != phase {
+			c.logger.V(3).Infof("%s is %s now", component.Name(), strings.ToLower(string(phase)))
+			c.setComponentState(component.Name(), phase, reason)
+		}
+	}
+
+	phase, reason := config.ClusterPhaseRunning, ""
+	if unhealthy > 0 {
+		phase, reason = config.ClusterPhaseDegraded, fmt.Sprintf("%d component(s) are unhealthy", unhealthy)
+	}
+	if md.State.Phase != phase && (md.State.Phase == config.ClusterPhaseRunning || md.State.Phase == config.ClusterPhaseDegraded) {
+		if err = c.transit(phase, reason); err != nil {
+			c.logger.V(3).Infof("failed to update the state of cluster: %v", err)
+		}
+	}
+}
+
+// errorReason returns the first line of error as the reason of state, since the error may contain logs.
+func errorReason(err error) string {
+	reason, _, _ := strings.Cut(err.Error(), "\n")
+	return strings.TrimSuffix(reason, ":")
+}
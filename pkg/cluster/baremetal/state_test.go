@@ -0,0 +1,74 @@
+/*
+ * Copyright 2023 Greptime Team
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package baremetal
+
+import (
+	"errors"
+	"os"
+	"os/exec"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/GreptimeTeam/gtctl/pkg/config"
+)
+
+func TestObservedState(t *testing.T) {
+	c := &Cluster{}
+	updatedAt := time.Date(2023, 8, 1, 10, 0, 0, 0, time.UTC)
+
+	// The cluster that has no state is stopped.
+	state := c.observedState(&config.BareMetalClusterMetadata{})
+	assert.Equal(t, config.ClusterPhaseStopped, state.Phase)
+
+	// The running cluster whose process is alive is kept as it is.
+	running := &config.ClusterState{
+		Phase:     config.ClusterPhaseRunning,
+		Pid:       os.Getpid(),
+		UpdatedAt: updatedAt,
+		Components: []*config.ComponentState{
+			{Name: "metasrv", Phase: config.ComponentPhaseRunning},
+			{Name: "datanode", Phase: config.ComponentPhaseStopped},
+		},
+	}
+	state = c.observedState(&config.BareMetalClusterMetadata{State: running})
+	assert.Equal(t, running, state)
+
+	// The running cluster whose process is gone has failed, and the persisted state is not changed.
+	cmd := exec.Command("true")
+	assert.NoError(t, cmd.Run())
+	running.Pid = cmd.Process.Pid
+	state = c.observedState(&config.BareMetalClusterMetadata{State: running})
+	assert.Equal(t, config.ClusterPhaseFailed, state.Phase)
+	assert.Contains(t, state.Reason, "was gone while it was running")
+	assert.Equal(t, updatedAt, state.Since)
+	assert.Equal(t, config.ComponentPhaseFailed, state.Component("metasrv").Phase)
+	assert.Equal(t, config.ComponentPhaseStopped, state.Component("datanode").Phase)
+	assert.Equal(t, config.ClusterPhaseRunning, running.Phase)
+	assert.Equal(t, config.ComponentPhaseRunning, running.Components[0].Phase)
+
+	// The stopped cluster has no process to check.
+	stopped := &config.ClusterState{Phase: config.ClusterPhaseStopped, Pid: cmd.Process.Pid}
+	assert.Equal(t, config.ClusterPhaseStopped, c.observedState(&config.BareMetalClusterMetadata{State: stopped}).Phase)
+}
+
+func TestErrorReason(t *testing.T) {
+	err := errors.New("frontend is not ready in 1m0s, 1/1 replica(s) are unhealthy:\n  - frontend.0 (pid '1'): exited")
+	assert.Equal(t, "frontend is not ready in 1m0s, 1/1 replica(s) are unhealthy", errorReason(err))
+	assert.Equal(t, "failed", errorReason(errors.New("failed")))
+}
@@ -0,0 +1,139 @@
+/*
+ * Copyright 2023 Greptime Team
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package baremetal
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"syscall"
+	"time"
+
+	opt "github.com/GreptimeTeam/gtctl/pkg/cluster"
+	"github.com/GreptimeTeam/gtctl/pkg/components"
+	"github.com/GreptimeTeam/gtctl/pkg/config"
+	"github.com/GreptimeTeam/gtctl/pkg/logger"
+)
+
+// Stop stops the running cluster, no matter it runs in foreground or in a background supervisor.
+// The data, logs and pids directories of the cluster are kept, so it can be started again.
+func (c *Cluster) Stop(ctx context.Context, options *opt.StopOptions) error {
+	md, err := c.get(ctx, &opt.GetOptions{Name: options.Name})
+	if err != nil {
+		return err
+	}
+
+	state := c.observedState(md)
+	if !state.IsActive() {
+		return fmt.Errorf("cluster '%s' is not running, it's %s", options.Name, strings.ToLower(string(state.Phase)))
+	}
+
+	pid := state.Pid
+	if pid == md.SupervisorPid {
+		csd := c.mm.GetClusterScopeDirs()
+		stopCtx, cancel := context.WithTimeout(ctx, supervisorDialTimeout)
+		_, err = sendSupervisorCommand(stopCtx, csd.SocketPath, &supervisorRequest{Command: supervisorCommandStop})
+		cancel()
+		if err != nil {
+			c.logger.V(3).Infof("failed to send stop command to supervisor: %v, fall back to send signal", err)
+			if err = syscall.Kill(pid, syscall.SIGTERM); err != nil {
+				return err
+			}
+		}
+	} else if err = syscall.Kill(pid, syscall.SIGTERM); err != nil {
+		return err
+	}
+
+	c.logger.V(0).Infof("Stopping cluster '%s'(pid=%d)...", options.Name, pid)
+
+	// Waiting for the process to exit with intervals.
+	ticker := time.NewTicker(500 * time.Millisecond)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			if !c.isPidRunning(pid) {
+				c.logger.V(0).Infof("Cluster '%s' is stopped, its data still remain in %s",
+					options.Name, logger.Bold(md.ClusterDir))
+				return nil
+			}
+		case <-ctx.Done():
+			return fmt.Errorf("waiting for cluster '%s' to stop failed: %v", options.Name, ctx.Err())
+		}
+	}
+}
+
+// shutdown stops the components of current cluster gracefully in order: frontend, datanode, metasrv and etcd,
+// so the datanodes can flush their data before metasrv and etcd go down.
+func (c *Cluster) shutdown() {
+	// Wait for the running scaling or upgrading to finish.
+	if c.supervisor != nil {
+		c.supervisor.opMu.Lock()
+		defer c.supervisor.opMu.Unlock()
+	}
+
+	// Stop the components in the reverse order of starting.
+	started := c.cc.ordered(c.withEtcd())
+	ordered := make([]components.ClusterComponent, 0, len(started))
+	for i := len(started) - 1; i >= 0; i-- {
+		ordered = append(ordered, started[i])
+	}
+
+	var (
+		timeout = c.config.Cluster.ShutdownGracePeriod
+		start   = time.Now()
+	)
+	for _, component := range ordered {
+		begin := time.Now()
+		c.logger.V(0).Infof("Stopping %s...", component.Name())
+		if err := component.Stop(timeout); err != nil {
+			c.logger.Errorf("failed to stop %s: %v", component.Name(), err)
+			c.setComponentState(component.Name(), config.ComponentPhaseFailed, fmt.Sprintf("failed to stop: %v", err))
+			continue
+		}
+		c.stoppedComponentState(component.Name())
+		c.logger.V(0).Infof("Stopped %s in %s", component.Name(), time.Since(begin).Round(time.Millisecond))
+	}
+	c.logger.V(0).Infof("All the components are stopped in %s", time.Since(start).Round(time.Millisecond))
+
+	if err := c.hosts.Close(); err != nil {
+		c.logger.V(3).Infof("failed to close the connections to hosts: %v", err)
+	}
+}
+
+// stoppedComponentState records the component as stopped, unless it has failed or its failure stopped the cluster.
+// The component that has not started has no state to record.
+func (c *Cluster) stoppedComponentState(name string) {
+	failed := name == c.getFailedComponent()
+	if err := c.mm.UpdateClusterMetadata(func(md *config.BareMetalClusterMetadata) {
+		if md.State == nil {
+			return
+		}
+		current := md.State.Component(name)
+		if current == nil || current.Phase == config.ComponentPhaseFailed {
+			return
+		}
+		if failed {
+			md.State.SetComponent(name, config.ComponentPhaseFailed, "some replicas exited with error", time.Now())
+			return
+		}
+		md.State.SetComponent(name, config.ComponentPhaseStopped, "", time.Now())
+	}); err != nil {
+		c.logger.Warnf("failed to record the state of %s: %v", name, err)
+	}
+}
@@ -0,0 +1,66 @@
+/*
+ * Copyright 2023 Greptime Team
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package baremetal
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/GreptimeTeam/gtctl/pkg/components/greptimetest"
+	"github.com/GreptimeTeam/gtctl/pkg/config"
+)
+
+func TestShutdown(t *testing.T) {
+	cfg := config.DefaultBareMetalConfig()
+	cfg.Cluster.MetaSrv.Replicas = 1
+	cfg.Cluster.MetaSrv.HTTPAddr = greptimetest.FreeAddr(t, 1)
+	cfg.Cluster.Datanode.Replicas = 2
+	cfg.Cluster.Datanode.HTTPAddr = greptimetest.FreeAddr(t, 2)
+	cfg.Cluster.Frontend.Replicas = 1
+	cfg.Cluster.Frontend.HTTPAddr = greptimetest.FreeAddr(t, 1)
+	cfg.Cluster.ShutdownGracePeriod = 300 * time.Millisecond
+
+	// The replicas ignore SIGTERM, so each component is killed after the grace period.
+	c := newTestCluster(t, cfg, greptimetest.NewBinary(t, greptimetest.Options{IgnoreTerm: true}))
+	for _, component := range c.cc.ordered(c.withEtcd()) {
+		assert.NoError(t, component.Start(c.ctx, c.failFunc(component), c.greptimeBinPath))
+		c.setComponentState(component.Name(), config.ComponentPhaseRunning, "")
+	}
+
+	start := time.Now()
+	c.shutdown()
+	c.wg.Wait()
+	assert.GreaterOrEqual(t, time.Since(start), 3*cfg.Cluster.ShutdownGracePeriod)
+	assert.NoError(t, c.ctx.Err())
+
+	// The components are stopped in the reverse order of starting.
+	md, err := c.mm.GetClusterMetadata()
+	assert.NoError(t, err)
+	var since []time.Time
+	for _, name := range []string{"frontend", "datanode", "metasrv"} {
+		state := md.State.Component(name)
+		if assert.NotNil(t, state, name) {
+			assert.Equal(t, config.ComponentPhaseStopped, state.Phase, name)
+			since = append(since, state.Since)
+		}
+	}
+	for i := 1; i < len(since); i++ {
+		assert.GreaterOrEqual(t, since[i].Sub(since[i-1]), cfg.Cluster.ShutdownGracePeriod)
+	}
+}
@@ -0,0 +1,259 @@
+/*
+ * Copyright 2023 Greptime Team
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package baremetal
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"sync"
+	"time"
+
+	opt "github.com/GreptimeTeam/gtctl/pkg/cluster"
+	"github.com/GreptimeTeam/gtctl/pkg/config"
+)
+
+const (
+	// SupervisorName is the name of the supervisor, its logs are stored in ${ClusterDir}/logs/supervisor.
+	SupervisorName = "supervisor"
+
+	// The control commands that accepted by the supervisor.
+	supervisorCommandStatus  = "status"
+	supervisorCommandStop    = "stop"
+	supervisorCommandScale   = "scale"
+	supervisorCommandUpgrade = "upgrade"
+
+	// The status of the cluster that reported by the supervisor.
+	supervisorStatusStarting = "starting"
+	supervisorStatusRunning  = "running"
+	supervisorStatusStopping = "stopping"
+
+	supervisorDialTimeout = 3 * time.Second
+)
+
+type supervisorRequest struct {
+	Command string `json:"command"`
+
+	// Component and Replicas are the arguments of scale command.
+	Component string `json:"component,omitempty"`
+	Replicas  int    `json:"replicas,omitempty"`
+
+	// Version and UseGreptimeCNArtifacts are the arguments of upgrade command.
+	Version                string `json:"version,omitempty"`
+	UseGreptimeCNArtifacts bool   `json:"useGreptimeCNArtifacts,omitempty"`
+}
+
+type supervisorResponse struct {
+	Status string `json:"status"`
+	Error  string `json:"error,omitempty"`
+
+	// OldReplicas is the replicas of component before scaling.
+	OldReplicas int `json:"oldReplicas,omitempty"`
+
+	// OldVersion is the version of greptime before upgrading.
+	OldVersion string `json:"oldVersion,omitempty"`
+}
+
+// supervisor serves the control commands of a running cluster over the unix socket.
+type supervisor struct {
+	cluster  *Cluster
+	listener net.Listener
+
+	mu     sync.Mutex
+	status string
+
+	// opMu serializes the commands that change the running components, like scale and upgrade.
+	opMu sync.Mutex
+}
+
+// Supervise runs the cluster in current process and serves the control commands
+// on the supervisor socket until the cluster is stopped.
+// It is the entrypoint of the background process that spawned by Start.
+func (c *Cluster) Supervise(ctx context.Context, options *opt.CreateOptions) error {
+	if err := c.serveControl(); err != nil {
+		return err
+	}
+
+	if err := c.mm.UpdateClusterMetadata(func(md *config.BareMetalClusterMetadata) {
+		md.SupervisorPid = os.Getpid()
+	}); err != nil {
+		return err
+	}
+	defer func() {
+		if err := c.mm.UpdateClusterMetadata(func(md *config.BareMetalClusterMetadata) {
+			md.SupervisorPid = 0
+		}); err != nil {
+			c.logger.Errorf("failed to clean up supervisor pid: %v", err)
+		}
+	}()
+
+	c.logger.V(0).Infof("Supervisor(pid=%d) is starting cluster '%s'", os.Getpid(), options.Name)
+	if err := c.Create(ctx, options); err != nil {
+		return err
+	}
+
+	return c.Wait(ctx, false)
+}
+
+// serveControl listens on the supervisor socket and serves the control commands in background.
+// The cluster that runs in foreground also serves the control commands, so it can be scaled.
+func (c *Cluster) serveControl() error {
+	if c.supervisor != nil {
+		return nil
+	}
+
+	// The socket may be left by a supervisor that was killed.
+	csd := c.mm.GetClusterScopeDirs()
+	if err := os.Remove(csd.SocketPath); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	listener, err := net.Listen("unix", csd.SocketPath)
+	if err != nil {
+		return err
+	}
+
+	c.supervisor = &supervisor{
+		cluster:  c,
+		listener: listener,
+		status:   supervisorStatusStarting,
+	}
+	go c.supervisor.serve()
+
+	return nil
+}
+
+func (s *supervisor) serve() {
+	for {
+		conn, err := s.listener.Accept()
+		if err != nil {
+			// The listener is closed.
+			return
+		}
+		go s.handle(conn)
+	}
+}
+
+func (s *supervisor) close() {
+	if err := s.listener.Close(); err != nil {
+		s.cluster.logger.V(3).Infof("failed to close supervisor socket: %v", err)
+	}
+}
+
+func (s *supervisor) handle(conn net.Conn) {
+	defer conn.Close()
+
+	var (
+		req supervisorRequest
+		rsp supervisorResponse
+	)
+	if err := json.NewDecoder(conn).Decode(&req); err != nil {
+		rsp.Error = fmt.Sprintf("invalid request: %v", err)
+	} else {
+		switch req.Command {
+		case supervisorCommandStatus:
+			rsp.Status = s.getStatus()
+		case supervisorCommandStop:
+			s.cluster.logger.V(0).Infof("Supervisor received stop command, stopping cluster...")
+			s.setStatus(supervisorStatusStopping)
+			s.cluster.stop()
+			rsp.Status = supervisorStatusStopping
+		case supervisorCommandScale:
+			rsp.Status = s.getStatus()
+			if rsp.Status != supervisorStatusRunning {
+				rsp.Error = fmt.Sprintf("cluster is %s now, can not be scaled", rsp.Status)
+				break
+			}
+
+			s.opMu.Lock()
+			oldReplicas, err := s.cluster.scale(req.Component, req.Replicas)
+			s.opMu.Unlock()
+			rsp.OldReplicas = oldReplicas
+			if err != nil {
+				rsp.Error = err.Error()
+			}
+		case supervisorCommandUpgrade:
+			rsp.Status = s.getStatus()
+			if rsp.Status != supervisorStatusRunning {
+				rsp.Error = fmt.Sprintf("cluster is %s now, can not be upgraded", rsp.Status)
+				break
+			}
+
+			s.opMu.Lock()
+			oldVersion, err := s.cluster.upgrade(req.Version, req.UseGreptimeCNArtifacts)
+			s.opMu.Unlock()
+			rsp.OldVersion = oldVersion
+			if err != nil {
+				rsp.Error = err.Error()
+			}
+		default:
+			rsp.Error = fmt.Sprintf("unknown command '%s'", req.Command)
+		}
+	}
+
+	if err := json.NewEncoder(conn).Encode(&rsp); err != nil {
+		s.cluster.logger.V(3).Infof("failed to reply supervisor command: %v", err)
+	}
+}
+
+func (s *supervisor) setStatus(status string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.status = status
+}
+
+func (s *supervisor) getStatus() string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.status
+}
+
+// sendSupervisorCommand sends the request to the supervisor that listens on socketPath and returns its reply.
+// The deadline of ctx is used as the deadline of waiting for the reply.
+func sendSupervisorCommand(ctx context.Context, socketPath string, req *supervisorRequest) (*supervisorResponse, error) {
+	var dialer net.Dialer
+	dialCtx, cancel := context.WithTimeout(ctx, supervisorDialTimeout)
+	defer cancel()
+
+	conn, err := dialer.DialContext(dialCtx, "unix", socketPath)
+	if err != nil {
+		return nil, err
+	}
+	defer conn.Close()
+
+	if deadline, ok := ctx.Deadline(); ok {
+		if err = conn.SetDeadline(deadline); err != nil {
+			return nil, err
+		}
+	}
+
+	if err = json.NewEncoder(conn).Encode(req); err != nil {
+		return nil, err
+	}
+
+	var rsp supervisorResponse
+	if err = json.NewDecoder(conn).Decode(&rsp); err != nil {
+		return nil, err
+	}
+	if len(rsp.Error) > 0 {
+		return &rsp, errors.New(rsp.Error)
+	}
+
+	return &rsp, nil
+}
@@ -0,0 +1,116 @@
+/*
+ * Copyright 2023 Greptime Team
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package baremetal
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"os"
+	"time"
+
+	greptimedbclusterv1alpha1 "github.com/GreptimeTeam/greptimedb-operator/apis/v1alpha1"
+
+	opt "github.com/GreptimeTeam/gtctl/pkg/cluster"
+	"github.com/GreptimeTeam/gtctl/pkg/components"
+	"github.com/GreptimeTeam/gtctl/pkg/config"
+	"github.com/GreptimeTeam/gtctl/pkg/logger"
+	"github.com/GreptimeTeam/gtctl/pkg/utils/certs"
+)
+
+// TLSEnabled returns whether the clients have to connect to the cluster with TLS.
+func (c *Cluster) TLSEnabled() bool {
+	return c.config.TLS.IsEnabled()
+}
+
+// ensureCerts makes sure the certificates of the servers with TLS are valid before they start.
+func (c *Cluster) ensureCerts() error {
+	if !c.config.TLS.IsEnabled() {
+		return nil
+	}
+	csd := c.mm.GetClusterScopeDirs()
+	if err := certs.Ensure(csd.CertsDir, certServers(c.config), certsValidFor(c.config.TLS)); err != nil {
+		return fmt.Errorf("failed to ensure the certificates: %v", err)
+	}
+	return nil
+}
+
+// RotateCerts issues new certificates for the servers of the cluster with TLS. The running cluster
+// keeps serving with the old ones until it's restarted.
+func (c *Cluster) RotateCerts(ctx context.Context, options *opt.RotateCertsOptions) error {
+	md, err := c.get(ctx, &opt.GetOptions{Name: options.Name})
+	if err != nil {
+		return err
+	}
+	if !md.Config.TLS.IsEnabled() {
+		return fmt.Errorf("cluster '%s' is not created with TLS", options.Name)
+	}
+
+	csd := c.mm.GetClusterScopeDirs()
+	if err = certs.Rotate(csd.CertsDir, certServers(md.Config), certsValidFor(md.Config.TLS), options.RotateCA); err != nil {
+		return fmt.Errorf("failed to rotate the certificates: %v", err)
+	}
+
+	c.logger.V(0).Infof("The certificates of cluster '%s' are rotated in %s", options.Name, csd.CertsDir)
+	if options.RotateCA {
+		caFile, _ := certs.Files(csd.CertsDir, certs.CAName)
+		c.logger.V(0).Infof("The clients other than gtctl should trust the new CA %s", logger.Bold(caFile))
+	}
+	c.printRestartTips(md, options.Name, "certificates")
+	return nil
+}
+
+// certsValidFor returns the validity of the server certificates.
+func certsValidFor(tls *config.TLS) time.Duration {
+	if tls.ValidFor > 0 {
+		return tls.ValidFor
+	}
+	return certs.DefaultValidFor
+}
+
+// certServers returns the servers with TLS, which are valid for the local host and the hosts that they listen on.
+func certServers(cfg *config.BareMetalClusterConfig) []certs.Server {
+	hosts := []string{"localhost", "127.0.0.1", "::1"}
+	if hostname, err := os.Hostname(); err == nil && len(hostname) > 0 {
+		hosts = append(hosts, hostname)
+	}
+	addHost := func(hosts []string, addr string) []string {
+		host, _, err := net.SplitHostPort(addr)
+		if err != nil || len(host) == 0 {
+			return hosts
+		}
+		if ip := net.ParseIP(host); ip != nil && ip.IsUnspecified() {
+			return hosts
+		}
+		for _, h := range hosts {
+			if h == host {
+				return hosts
+			}
+		}
+		return append(hosts, host)
+	}
+
+	if cfg.IsStandalone() {
+		standalone := cfg.Cluster.Standalone
+		hosts = addHost(addHost(hosts, standalone.MysqlAddr), standalone.PostgresAddr)
+		return []certs.Server{{Name: components.StandaloneName, Hosts: hosts}}
+	}
+
+	frontend := cfg.Cluster.Frontend
+	hosts = addHost(addHost(hosts, frontend.MysqlAddr), frontend.PostgresAddr)
+	return []certs.Server{{Name: string(greptimedbclusterv1alpha1.FrontendComponentKind), Hosts: hosts}}
+}
@@ -0,0 +1,73 @@
+/*
+ * Copyright 2023 Greptime Team
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package baremetal
+
+import (
+	"context"
+	"io"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	opt "github.com/GreptimeTeam/gtctl/pkg/cluster"
+	"github.com/GreptimeTeam/gtctl/pkg/config"
+	"github.com/GreptimeTeam/gtctl/pkg/logger"
+	"github.com/GreptimeTeam/gtctl/pkg/metadata"
+	"github.com/GreptimeTeam/gtctl/pkg/utils/certs"
+)
+
+func TestEnsureAndRotateCerts(t *testing.T) {
+	mm, err := metadata.New(t.TempDir())
+	assert.NoError(t, err)
+	mm.AllocateClusterScopeDirs("mycluster")
+	csd := mm.GetClusterScopeDirs()
+
+	cfg := config.DefaultBareMetalConfig()
+	cfg.Cluster.Frontend.MysqlAddr = "192.168.1.10:4002"
+	cfg.Cluster.Frontend.PostgresAddr = "0.0.0.0:4003"
+	assert.NoError(t, mm.CreateClusterScopeDirs(cfg))
+	c := &Cluster{config: cfg, mm: mm, logger: logger.New(io.Discard, 0)}
+
+	// The certificates are only generated for the cluster with TLS.
+	ctx := context.Background()
+	assert.NoError(t, c.ensureCerts())
+	assert.NoDirExists(t, csd.CertsDir)
+	assert.ErrorContains(t, c.RotateCerts(ctx, &opt.RotateCertsOptions{Name: "mycluster"}), "not created with TLS")
+
+	cfg.TLS = &config.TLS{Enabled: true}
+	assert.NoError(t, mm.CreateClusterScopeDirs(cfg))
+	assert.NoError(t, c.ensureCerts())
+	cert, err := certs.LoadCertificate(csd.CertsDir, "frontend")
+	assert.NoError(t, err)
+	for _, host := range []string{"localhost", "127.0.0.1", "::1", "192.168.1.10"} {
+		assert.NoError(t, cert.VerifyHostname(host), host)
+	}
+	assert.Error(t, cert.VerifyHostname("0.0.0.0"))
+
+	ca, err := certs.LoadCertificate(csd.CertsDir, certs.CAName)
+	assert.NoError(t, err)
+	assert.NoError(t, c.RotateCerts(ctx, &opt.RotateCertsOptions{Name: "mycluster"}))
+	rotated, err := certs.LoadCertificate(csd.CertsDir, "frontend")
+	assert.NoError(t, err)
+	assert.NotEqual(t, cert.SerialNumber, rotated.SerialNumber)
+	assert.NoError(t, rotated.CheckSignatureFrom(ca))
+
+	assert.NoError(t, c.RotateCerts(ctx, &opt.RotateCertsOptions{Name: "mycluster", RotateCA: true}))
+	rotated, err = certs.LoadCertificate(csd.CertsDir, "frontend")
+	assert.NoError(t, err)
+	assert.Error(t, rotated.CheckSignatureFrom(ca))
+}
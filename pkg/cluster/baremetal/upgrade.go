@@ -0,0 +1,196 @@
+/*
+ * Copyright 2023 Greptime Team
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package baremetal
+
+import (
+	"context"
+	"fmt"
+	"sync/atomic"
+
+	"github.com/GreptimeTeam/gtctl/pkg/artifacts"
+	opt "github.com/GreptimeTeam/gtctl/pkg/cluster"
+	"github.com/GreptimeTeam/gtctl/pkg/components"
+	"github.com/GreptimeTeam/gtctl/pkg/config"
+)
+
+// Upgrade upgrades the greptime binary of a running cluster by sending upgrade command to the process
+// that runs the cluster, no matter it runs in foreground or in a background supervisor.
+func (c *Cluster) Upgrade(ctx context.Context, options *opt.UpgradeOptions) error {
+	if len(options.GreptimeBinVersion) == 0 {
+		return fmt.Errorf("the version of greptime binary should be set")
+	}
+
+	if _, err := c.get(ctx, &opt.GetOptions{Name: options.Name}); err != nil {
+		return err
+	}
+
+	spinner := options.Spinner
+	if spinner != nil {
+		spinner.Start(fmt.Sprintf("Upgrading GreptimeDB cluster '%s' to %s...", options.Name, options.GreptimeBinVersion))
+	}
+
+	csd := c.mm.GetClusterScopeDirs()
+	rsp, err := sendSupervisorCommand(ctx, csd.SocketPath, &supervisorRequest{
+		Command:                supervisorCommandUpgrade,
+		Version:                options.GreptimeBinVersion,
+		UseGreptimeCNArtifacts: options.UseGreptimeCNArtifacts,
+	})
+	if rsp == nil && err != nil {
+		err = fmt.Errorf("failed to connect to cluster '%s', is it running? error: %v", options.Name, err)
+	}
+	if err != nil {
+		if spinner != nil {
+			spinner.Stop(false, "Upgrading GreptimeDB cluster failed")
+		}
+		return err
+	}
+
+	if spinner != nil {
+		spinner.Stop(true, fmt.Sprintf("Upgrading GreptimeDB cluster from %s to %s successfully 🎉",
+			rsp.OldVersion, options.GreptimeBinVersion))
+	}
+
+	return nil
+}
+
+// upgrade installs the greptime binary of the version, and upgrades the cluster with it.
+// It returns the version of the cluster before upgrading.
+func (c *Cluster) upgrade(version string, useGreptimeCNArtifacts bool) (string, error) {
+	oldVersion := c.version()
+	c.logger.V(0).Infof("Upgrading cluster from %s to %s", oldVersion, version)
+	newBinPath, err := c.installBinary(c.ctx, artifacts.GreptimeBinName, version, useGreptimeCNArtifacts)
+	if err != nil {
+		return oldVersion, err
+	}
+
+	return oldVersion, c.upgradeTo(version, newBinPath)
+}
+
+// version returns the version of the greptime binary that the cluster runs with.
+func (c *Cluster) version() string {
+	artifact := c.config.Cluster.Artifact
+	if len(artifact.Local) > 0 {
+		return artifact.Local
+	}
+	return artifact.Version
+}
+
+// upgradeTo restarts the replicas of metasrv, datanode and frontend, or the standalone, with the new binary one by one.
+// The replica that is not running after restarting will be rolled back to the old binary, and so will be the replicas
+// upgraded before it, then the upgrading is aborted. So the cluster always runs with one binary, the one it records.
+func (c *Cluster) upgradeTo(version, newBinPath string) error {
+	type replica struct {
+		component components.ClusterComponent
+		index     int
+	}
+
+	var (
+		oldVersion = c.version()
+		oldBinPath = c.greptimeBinPath
+		upgraded   []replica
+	)
+	for _, component := range c.cc.ordered(false) {
+		for i := 0; i < c.replicas(component); i++ {
+			if err := c.upgradeReplica(component, i, oldBinPath, newBinPath); err != nil {
+				// Roll back the upgraded replicas in the reverse order of upgrading.
+				for j := len(upgraded) - 1; j >= 0; j-- {
+					if rollbackErr := c.rollbackReplica(upgraded[j].component, upgraded[j].index, oldBinPath); rollbackErr != nil {
+						return fmt.Errorf("%v, and rolling back the upgraded replicas failed: %v", err, rollbackErr)
+					}
+				}
+				return err
+			}
+			upgraded = append(upgraded, replica{component: component, index: i})
+		}
+	}
+
+	// The new scaled replicas will also run with the new binary.
+	c.greptimeBinPath = newBinPath
+	artifact := c.config.Cluster.Artifact
+	artifact.Local = ""
+	artifact.Version = version
+	if err := c.mm.UpdateClusterMetadata(func(md *config.BareMetalClusterMetadata) {
+		md.Config = c.config
+		md.GreptimeBinPath = newBinPath
+	}); err != nil {
+		return err
+	}
+
+	c.logger.V(0).Infof("Upgraded cluster from %s to %s", oldVersion, version)
+	return nil
+}
+
+// replicas returns the replicas of the greptime component in config.
+func (c *Cluster) replicas(component components.ClusterComponent) int {
+	switch component {
+	case c.cc.MetaSrv:
+		return c.config.Cluster.MetaSrv.Replicas
+	case c.cc.Datanode:
+		return c.config.Cluster.Datanode.Replicas
+	case c.cc.Frontend:
+		return c.config.Cluster.Frontend.Replicas
+	default:
+		return 1
+	}
+}
+
+// upgradeReplica restarts the replica with the new binary and waits for the component to be ready,
+// the replica is restarted with the old binary if it fails.
+func (c *Cluster) upgradeReplica(component components.ClusterComponent, replica int, oldBinPath, newBinPath string) error {
+	name := fmt.Sprintf("%s.%d", component.Name(), replica)
+	c.logger.V(0).Infof("Restarting '%s' with '%s'", name, newBinPath)
+
+	// The replica that fails before it's running will be rolled back instead of stopping the whole cluster.
+	var (
+		running int32
+		fail    = c.failFunc(component)
+	)
+	stop := func() {
+		if atomic.LoadInt32(&running) == 1 {
+			fail()
+		}
+	}
+
+	err := component.RestartReplica(c.ctx, stop, newBinPath, replica)
+	if err == nil {
+		if err = component.WaitUntilReady(c.ctx); err == nil {
+			atomic.StoreInt32(&running, 1)
+			return nil
+		}
+	}
+
+	c.logger.Errorf("'%s' failed to run with '%s': %v, rolling it back to '%s'", name, newBinPath, err, oldBinPath)
+	if rollbackErr := c.rollbackReplica(component, replica, oldBinPath); rollbackErr != nil {
+		return fmt.Errorf("upgrading '%s' failed: %v, and %v", name, err, rollbackErr)
+	}
+
+	return fmt.Errorf("upgrading '%s' failed and it has been rolled back: %v", name, err)
+}
+
+// rollbackReplica restarts the replica with the old binary and waits for the component to be ready.
+func (c *Cluster) rollbackReplica(component components.ClusterComponent, replica int, oldBinPath string) error {
+	name := fmt.Sprintf("%s.%d", component.Name(), replica)
+	c.logger.V(0).Infof("Rolling back '%s' to '%s'", name, oldBinPath)
+
+	if err := component.RestartReplica(c.ctx, c.failFunc(component), oldBinPath, replica); err != nil {
+		return fmt.Errorf("rolling back '%s' failed: %v", name, err)
+	}
+	if err := component.WaitUntilReady(c.ctx); err != nil {
+		return fmt.Errorf("'%s' is not running after rolling back: %v", name, err)
+	}
+	return nil
+}
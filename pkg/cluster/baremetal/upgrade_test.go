@@ -0,0 +1,126 @@
+/*
+ * Copyright 2023 Greptime Team
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package baremetal
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/GreptimeTeam/gtctl/pkg/components/greptimetest"
+	"github.com/GreptimeTeam/gtctl/pkg/config"
+)
+
+var upgradeTestReplicas = []string{"metasrv.0", "datanode.0", "datanode.1", "frontend.0"}
+
+// newUpgradeTestCluster starts the cluster with the old binary.
+func newUpgradeTestCluster(t *testing.T, oldBinPath string) *Cluster {
+	cfg := config.DefaultBareMetalConfig()
+	cfg.Cluster.Artifact.Version = "v0.1.0"
+	cfg.Cluster.MetaSrv.Replicas = 1
+	cfg.Cluster.MetaSrv.HTTPAddr = greptimetest.FreeAddr(t, 1)
+	cfg.Cluster.Datanode.Replicas = 2
+	cfg.Cluster.Datanode.HTTPAddr = greptimetest.FreeAddr(t, 2)
+	cfg.Cluster.Frontend.Replicas = 1
+	cfg.Cluster.Frontend.HTTPAddr = greptimetest.FreeAddr(t, 1)
+
+	c := newTestCluster(t, cfg, oldBinPath)
+	assert.NoError(t, c.mm.UpdateClusterMetadata(func(md *config.BareMetalClusterMetadata) {
+		md.GreptimeBinPath = oldBinPath
+	}))
+	for _, component := range c.cc.ordered(false) {
+		assert.NoError(t, component.Start(c.ctx, c.failFunc(component), oldBinPath))
+	}
+	t.Cleanup(func() {
+		c.shutdown()
+		c.wg.Wait()
+	})
+	return c
+}
+
+// replicaBinaries returns the binaries that the replica has run with in order.
+func replicaBinaries(t *testing.T, c *Cluster, name string) []string {
+	data, err := os.ReadFile(filepath.Join(c.mm.GetClusterScopeDirs().LogsDir, name, "log"))
+	assert.NoError(t, err)
+
+	var binaries []string
+	for _, line := range strings.Split(string(data), "\n") {
+		if strings.HasPrefix(line, "binary: ") {
+			binaries = append(binaries, strings.TrimPrefix(line, "binary: "))
+		}
+	}
+	return binaries
+}
+
+func TestUpgrade(t *testing.T) {
+	oldBinPath := greptimetest.NewBinary(t, greptimetest.Options{})
+	newBinPath := greptimetest.NewBinary(t, greptimetest.Options{})
+	c := newUpgradeTestCluster(t, oldBinPath)
+
+	assert.NoError(t, c.upgradeTo("v0.2.0", newBinPath))
+	for _, name := range upgradeTestReplicas {
+		assert.Equal(t, []string{oldBinPath, newBinPath}, replicaBinaries(t, c, name), name)
+	}
+
+	// The new binary is recorded, so the scaled and restarted replicas run with it too.
+	assert.Equal(t, newBinPath, c.greptimeBinPath)
+	md, err := c.mm.GetClusterMetadata()
+	assert.NoError(t, err)
+	assert.Equal(t, newBinPath, md.GreptimeBinPath)
+	assert.Equal(t, "v0.2.0", md.Config.Cluster.Artifact.Version)
+	assert.NoError(t, c.ctx.Err())
+}
+
+func TestUpgradeRollback(t *testing.T) {
+	oldBinPath := greptimetest.NewBinary(t, greptimetest.Options{})
+	// The second datanode can't run with the new binary.
+	newBinPath := greptimetest.NewBinary(t, greptimetest.Options{FailArg: "--node-id=1"})
+	c := newUpgradeTestCluster(t, oldBinPath)
+	frontendPid, err := os.ReadFile(filepath.Join(c.mm.GetClusterScopeDirs().PidsDir, "frontend.0", "pid"))
+	assert.NoError(t, err)
+
+	start := time.Now()
+	err = c.upgradeTo("v0.2.0", newBinPath)
+	assert.ErrorContains(t, err, "upgrading 'datanode.1' failed and it has been rolled back")
+	assert.Less(t, time.Since(start), time.Minute)
+
+	// The failed replica and the ones upgraded before it are rolled back.
+	for _, name := range []string{"metasrv.0", "datanode.0", "datanode.1"} {
+		assert.Equal(t, []string{oldBinPath, newBinPath, oldBinPath}, replicaBinaries(t, c, name), name)
+	}
+
+	// The upgrading is aborted, the replicas after the failed one are not restarted.
+	assert.Equal(t, []string{oldBinPath}, replicaBinaries(t, c, "frontend.0"))
+	pid, err := os.ReadFile(filepath.Join(c.mm.GetClusterScopeDirs().PidsDir, "frontend.0", "pid"))
+	assert.NoError(t, err)
+	assert.Equal(t, frontendPid, pid)
+
+	// The cluster keeps running with the old binary.
+	assert.Equal(t, oldBinPath, c.greptimeBinPath)
+	md, err := c.mm.GetClusterMetadata()
+	assert.NoError(t, err)
+	assert.Equal(t, oldBinPath, md.GreptimeBinPath)
+	assert.Equal(t, "v0.1.0", md.Config.Cluster.Artifact.Version)
+	for _, component := range c.cc.ordered(false) {
+		assert.True(t, component.IsRunning(c.ctx), component.Name())
+	}
+	assert.NoError(t, c.ctx.Err())
+}
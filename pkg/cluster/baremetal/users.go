@@ -0,0 +1,119 @@
+/*
+ * Copyright 2023 Greptime Team
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package baremetal
+
+import (
+	"context"
+	"fmt"
+
+	opt "github.com/GreptimeTeam/gtctl/pkg/cluster"
+	"github.com/GreptimeTeam/gtctl/pkg/components"
+	"github.com/GreptimeTeam/gtctl/pkg/config"
+	"github.com/GreptimeTeam/gtctl/pkg/utils/users"
+)
+
+// AddUser adds the user into the users file of cluster, the frontend authenticates the clients with
+// the users file once it exists.
+func (c *Cluster) AddUser(ctx context.Context, options *opt.UserOptions) error {
+	if err := users.Validate(options.User, options.Password); err != nil {
+		return err
+	}
+
+	return c.updateUsers(ctx, options.Name, func(all map[string]string) error {
+		all[options.User] = options.Password
+		return nil
+	}, fmt.Sprintf("User '%s' is added to cluster '%s'", options.User, options.Name))
+}
+
+// RemoveUser removes the user from the users file of cluster, the file is removed with the last user,
+// and the frontend accepts the clients without authentication then.
+func (c *Cluster) RemoveUser(ctx context.Context, options *opt.UserOptions) error {
+	return c.updateUsers(ctx, options.Name, func(all map[string]string) error {
+		if _, ok := all[options.User]; !ok {
+			return fmt.Errorf("user '%s' is not found in cluster '%s'", options.User, options.Name)
+		}
+		delete(all, options.User)
+		return nil
+	}, fmt.Sprintf("User '%s' is removed from cluster '%s'", options.User, options.Name))
+}
+
+func (c *Cluster) ListUsers(ctx context.Context, options *opt.UserOptions) ([]string, error) {
+	if _, err := c.get(ctx, &opt.GetOptions{Name: options.Name}); err != nil {
+		return nil, err
+	}
+
+	all, err := users.ReadFile(c.mm.GetClusterScopeDirs().UsersFile)
+	if err != nil {
+		return nil, err
+	}
+	return users.Names(all), nil
+}
+
+// updateUsers updates the users of cluster by update and prints the message, it fails if the frontend, or the
+// standalone, uses its own user provider. The users file is updated under the lock of cluster metadata.
+func (c *Cluster) updateUsers(ctx context.Context, name string, update func(map[string]string) error, message string) error {
+	md, err := c.get(ctx, &opt.GetOptions{Name: name})
+	if err != nil {
+		return err
+	}
+	if component, provider := userProvider(md.Config); len(provider) > 0 {
+		return fmt.Errorf("the %s of cluster '%s' uses the user provider '%s' in its config", component, name, provider)
+	}
+
+	usersFile := c.mm.GetClusterScopeDirs().UsersFile
+	if err = c.mm.LockCluster(func() error {
+		all, err := users.ReadFile(usersFile)
+		if err != nil {
+			return err
+		}
+		if err = update(all); err != nil {
+			return err
+		}
+		return users.WriteFile(usersFile, all)
+	}); err != nil {
+		return err
+	}
+
+	c.logger.V(0).Infof("%s", message)
+	c.printRestartTips(md, name, "users")
+	return nil
+}
+
+// userProvider returns the component that authenticates the clients and its configured user provider,
+// which takes precedence over the users file of cluster.
+func userProvider(cfg *config.BareMetalClusterConfig) (string, string) {
+	if cfg.IsStandalone() {
+		if standalone := cfg.Cluster.Standalone; standalone != nil {
+			return components.StandaloneName, standalone.UserProvider
+		}
+		return components.StandaloneName, ""
+	}
+	return "frontend", cfg.Cluster.Frontend.UserProvider
+}
+
+// userPassword returns the stored password of the user of cluster.
+func (c *Cluster) userPassword(name, user string) (string, error) {
+	all, err := users.ReadFile(c.mm.GetClusterScopeDirs().UsersFile)
+	if err != nil {
+		return "", err
+	}
+	password, ok := all[user]
+	if !ok {
+		return "", fmt.Errorf("user '%s' is not found in cluster '%s'", user, name)
+	}
+	return password, nil
+}
@@ -0,0 +1,112 @@
+/*
+ * Copyright 2023 Greptime Team
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package baremetal
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	opt "github.com/GreptimeTeam/gtctl/pkg/cluster"
+	"github.com/GreptimeTeam/gtctl/pkg/components"
+	"github.com/GreptimeTeam/gtctl/pkg/config"
+	"github.com/GreptimeTeam/gtctl/pkg/logger"
+	"github.com/GreptimeTeam/gtctl/pkg/metadata"
+	"github.com/GreptimeTeam/gtctl/pkg/utils/users"
+)
+
+func TestUsers(t *testing.T) {
+	mm, err := metadata.New(t.TempDir())
+	assert.NoError(t, err)
+	mm.AllocateClusterScopeDirs("mycluster")
+	csd := mm.GetClusterScopeDirs()
+
+	cfg := config.DefaultBareMetalConfig()
+	assert.NoError(t, mm.CreateClusterScopeDirs(cfg))
+	c := &Cluster{config: cfg, mm: mm, logger: logger.New(io.Discard, 0)}
+	frontend := components.NewFrontend(cfg.Cluster.Frontend, "127.0.0.1:3002", nil,
+		components.WorkingDirs{UsersFile: csd.UsersFile}, nil, nil, nil, c.logger)
+
+	ctx := context.Background()
+	names, err := c.ListUsers(ctx, &opt.UserOptions{Name: "mycluster"})
+	assert.NoError(t, err)
+	assert.Empty(t, names)
+	assert.NotContains(t, frontend.BuildArgs(0), "--user-provider="+users.FileProviderPrefix+csd.UsersFile)
+
+	// The frontend authenticates the clients with the users file once there are users.
+	assert.NoError(t, c.AddUser(ctx, &opt.UserOptions{Name: "mycluster", User: "bob", Password: "secret1"}))
+	assert.NoError(t, c.AddUser(ctx, &opt.UserOptions{Name: "mycluster", User: "alice", Password: "secret2"}))
+	assert.NoError(t, c.AddUser(ctx, &opt.UserOptions{Name: "mycluster", User: "bob", Password: "secret3"}))
+	assert.ErrorContains(t, c.AddUser(ctx, &opt.UserOptions{Name: "mycluster", User: "bad,name", Password: "secret"}), "invalid user name")
+	names, err = c.ListUsers(ctx, &opt.UserOptions{Name: "mycluster"})
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"alice", "bob"}, names)
+	password, err := c.userPassword("mycluster", "bob")
+	assert.NoError(t, err)
+	assert.Equal(t, "secret3", password)
+	assert.Contains(t, frontend.BuildArgs(0), "--user-provider="+users.FileProviderPrefix+csd.UsersFile)
+
+	assert.ErrorContains(t, c.RemoveUser(ctx, &opt.UserOptions{Name: "mycluster", User: "carol"}), "user 'carol' is not found")
+	assert.NoError(t, c.RemoveUser(ctx, &opt.UserOptions{Name: "mycluster", User: "alice"}))
+	assert.NoError(t, c.RemoveUser(ctx, &opt.UserOptions{Name: "mycluster", User: "bob"}))
+	assert.NoFileExists(t, csd.UsersFile)
+	_, err = c.userPassword("mycluster", "bob")
+	assert.ErrorContains(t, err, "user 'bob' is not found")
+
+	// The users can't be managed if the frontend uses its own user provider.
+	cfg.Cluster.Frontend.UserProvider = "static_user_provider:cmd:admin=admin"
+	assert.NoError(t, mm.CreateClusterScopeDirs(cfg))
+	assert.ErrorContains(t, c.AddUser(ctx, &opt.UserOptions{Name: "mycluster", User: "bob", Password: "secret"}),
+		"uses the user provider")
+	assert.Contains(t, frontend.BuildArgs(0), "--user-provider=static_user_provider:cmd:admin=admin")
+}
+
+func TestUsersStandaloneProvider(t *testing.T) {
+	cfg := config.DefaultBareMetalConfig()
+	cfg.Topology = config.TopologyStandalone
+	cfg.Cluster.Standalone = config.DefaultStandaloneConfig()
+	cfg.Cluster.Standalone.UserProvider = "static_user_provider:cmd:admin=admin"
+	c := newTestCluster(t, cfg, "")
+
+	// The standalone uses its own user provider like the frontend does.
+	assert.ErrorContains(t, c.AddUser(context.Background(), &opt.UserOptions{Name: "mycluster", User: "bob", Password: "secret"}),
+		"the standalone of cluster 'mycluster' uses the user provider")
+}
+
+func TestUsersConcurrent(t *testing.T) {
+	c := newTestCluster(t, config.DefaultBareMetalConfig(), "")
+
+	// The concurrent updates are serialized by the lock of cluster, so none of them is lost.
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			assert.NoError(t, c.AddUser(context.Background(),
+				&opt.UserOptions{Name: "mycluster", User: fmt.Sprintf("user%d", i), Password: "secret"}))
+		}(i)
+	}
+	wg.Wait()
+
+	names, err := c.ListUsers(context.Background(), &opt.UserOptions{Name: "mycluster"})
+	assert.NoError(t, err)
+	assert.Len(t, names, 10)
+}
@@ -38,13 +38,27 @@ func (c *Cluster) Connect(ctx context.Context, options *opt.ConnectOptions) erro
 		return nil
 	}
 
+	// The client authenticates with the stored password of user.
+	clientOptions := &connector.ClientOptions{User: options.User}
+	if len(options.User) > 0 {
+		users, err := c.getUsers(ctx, options.Namespace, options.Name)
+		if err != nil {
+			return err
+		}
+		password, ok := users[options.User]
+		if !ok {
+			return fmt.Errorf("user '%s' is not found in cluster '%s'", options.User, options.Name)
+		}
+		clientOptions.Password = password
+	}
+
 	switch options.Protocol {
 	case opt.MySQL:
-		if err = c.connectMySQL(cluster); err != nil {
+		if err = c.connectMySQL(cluster, clientOptions); err != nil {
 			return fmt.Errorf("error connecting to mysql: %v", err)
 		}
 	case opt.Postgres:
-		if err = c.connectPostgres(cluster); err != nil {
+		if err = c.connectPostgres(cluster, clientOptions); err != nil {
 			return fmt.Errorf("error connecting to postgres: %v", err)
 		}
 	default:
@@ -54,10 +68,10 @@ func (c *Cluster) Connect(ctx context.Context, options *opt.ConnectOptions) erro
 	return nil
 }
 
-func (c *Cluster) connectMySQL(cluster *greptimedbclusterv1alpha1.GreptimeDBCluster) error {
-	return connector.Mysql(strconv.Itoa(int(cluster.Spec.MySQLServicePort)), cluster.Name, c.logger)
+func (c *Cluster) connectMySQL(cluster *greptimedbclusterv1alpha1.GreptimeDBCluster, options *connector.ClientOptions) error {
+	return connector.Mysql(strconv.Itoa(int(cluster.Spec.MySQLServicePort)), cluster.Name, options, c.logger)
 }
 
-func (c *Cluster) connectPostgres(cluster *greptimedbclusterv1alpha1.GreptimeDBCluster) error {
-	return connector.PostgresSQL(strconv.Itoa(int(cluster.Spec.PostgresServicePort)), cluster.Name, c.logger)
+func (c *Cluster) connectPostgres(cluster *greptimedbclusterv1alpha1.GreptimeDBCluster, options *connector.ClientOptions) error {
+	return connector.PostgresSQL(strconv.Itoa(int(cluster.Spec.PostgresServicePort)), cluster.Name, options, c.logger)
 }
@@ -50,31 +50,33 @@ func (c *Cluster) list(ctx context.Context) (*greptimedbclusterv1alpha1.Greptime
 	return clusters, nil
 }
 
-func (c *Cluster) configListView(table *tablewriter.Table) {
-	table.SetAutoWrapText(false)
-	table.SetAutoFormatHeaders(true)
-	table.SetHeaderAlignment(tablewriter.ALIGN_LEFT)
-	table.SetAlignment(tablewriter.ALIGN_LEFT)
-	table.SetCenterSeparator("")
-	table.SetColumnSeparator("")
-	table.SetRowSeparator("")
-	table.SetHeaderLine(false)
-	table.SetBorder(false)
-	table.SetTablePadding("\t")
-	table.SetNoWhiteSpace(true)
-}
-
 func (c *Cluster) renderListView(table *tablewriter.Table, data *greptimedbclusterv1alpha1.GreptimeDBClusterList) {
-	c.configListView(table)
+	for _, cluster := range data.Items {
+		var frontend, datanode, meta int
+		if cluster.Spec.Frontend != nil {
+			frontend = int(cluster.Spec.Frontend.Replicas)
+		}
+		if cluster.Spec.Datanode != nil {
+			datanode = int(cluster.Spec.Datanode.Replicas)
+		}
+		if cluster.Spec.Meta != nil {
+			meta = int(cluster.Spec.Meta.Replicas)
+		}
 
-	table.SetHeader([]string{"Name", "Namespace", "Creation Date"})
-	defer table.Render()
+		status := cluster.Status
+		ready := status.Frontend.ReadyReplicas + status.Datanode.ReadyReplicas + status.Meta.ReadyReplicas
+		total := status.Frontend.Replicas + status.Datanode.Replicas + status.Meta.Replicas
 
-	for _, cluster := range data.Items {
 		table.Append([]string{
 			cluster.Name,
+			opt.KubernetesClusterType,
 			cluster.Namespace,
 			cluster.CreationTimestamp.String(),
+			cluster.Spec.Version,
+			opt.NotAvailable,
+			opt.ReplicasView(frontend, datanode, meta),
+			string(status.ClusterPhase),
+			fmt.Sprintf("pods: %d/%d ready", ready, total),
 		})
 	}
 }
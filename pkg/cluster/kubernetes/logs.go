@@ -0,0 +1,127 @@
+/*
+ * Copyright 2023 Greptime Team
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package kubernetes
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"sort"
+
+	greptimedbclusterv1alpha1 "github.com/GreptimeTeam/greptimedb-operator/apis/v1alpha1"
+	corev1 "k8s.io/api/core/v1"
+
+	opt "github.com/GreptimeTeam/gtctl/pkg/cluster"
+)
+
+// componentLabel is the label of pods that created by greptimedb-operator, its value is '${cluster}-${component}'.
+const componentLabel = "app.greptime.io/component"
+
+func (c *Cluster) Logs(ctx context.Context, options *opt.LogsOptions) error {
+	if _, err := c.get(ctx, &opt.GetOptions{
+		Namespace: options.Namespace,
+		Name:      options.Name,
+	}); err != nil {
+		return err
+	}
+
+	kinds := []greptimedbclusterv1alpha1.ComponentKind{
+		greptimedbclusterv1alpha1.MetaComponentKind,
+		greptimedbclusterv1alpha1.DatanodeComponentKind,
+		greptimedbclusterv1alpha1.FrontendComponentKind,
+	}
+	if len(options.Component) > 0 {
+		kind, err := componentKind(options.Component)
+		if err != nil {
+			return err
+		}
+		kinds = []greptimedbclusterv1alpha1.ComponentKind{kind}
+	}
+
+	var sources []*opt.LogSource
+	closeSources := func() {
+		for _, source := range sources {
+			_ = source.Reader.Close()
+		}
+	}
+	for _, kind := range kinds {
+		pods, err := c.client.ListPods(ctx, options.Namespace,
+			fmt.Sprintf("%s=%s-%s", componentLabel, options.Name, kind))
+		if err != nil {
+			closeSources()
+			return err
+		}
+
+		// The pods of deployment have random names, so the replica index is their order by name.
+		items := pods.Items
+		sort.Slice(items, func(i, j int) bool { return items[i].Name < items[j].Name })
+		if options.Replica >= 0 {
+			if options.Replica >= len(items) {
+				closeSources()
+				return fmt.Errorf("replica %d of %s is out of range, there are %d pods", options.Replica, kind, len(items))
+			}
+			items = items[options.Replica : options.Replica+1]
+		}
+
+		for _, pod := range items {
+			stream, err := c.client.StreamPodLogs(ctx, pod.Name, pod.Namespace, podLogOptions(&pod, options))
+			if err != nil {
+				closeSources()
+				return fmt.Errorf("failed to get logs of pod '%s': %v", pod.Name, err)
+			}
+			sources = append(sources, &opt.LogSource{Name: pod.Name, Reader: stream})
+		}
+	}
+
+	if len(sources) == 0 {
+		return fmt.Errorf("no pods found for cluster '%s' in namespace '%s'", options.Name, options.Namespace)
+	}
+
+	return opt.PrintLogs(ctx, options.Writer, sources, options.Follow)
+}
+
+func podLogOptions(pod *corev1.Pod, options *opt.LogsOptions) *corev1.PodLogOptions {
+	logOptions := &corev1.PodLogOptions{
+		Follow: options.Follow,
+	}
+	// The main container is the first one.
+	if len(pod.Spec.Containers) > 0 {
+		logOptions.Container = pod.Spec.Containers[0].Name
+	}
+	if options.Since > 0 {
+		sinceSeconds := int64(math.Ceil(options.Since.Seconds()))
+		logOptions.SinceSeconds = &sinceSeconds
+	}
+	if options.Tail >= 0 {
+		tailLines := options.Tail
+		logOptions.TailLines = &tailLines
+	}
+	return logOptions
+}
+
+func componentKind(component string) (greptimedbclusterv1alpha1.ComponentKind, error) {
+	switch component {
+	case string(greptimedbclusterv1alpha1.FrontendComponentKind):
+		return greptimedbclusterv1alpha1.FrontendComponentKind, nil
+	case string(greptimedbclusterv1alpha1.DatanodeComponentKind):
+		return greptimedbclusterv1alpha1.DatanodeComponentKind, nil
+	case string(greptimedbclusterv1alpha1.MetaComponentKind), "metasrv":
+		return greptimedbclusterv1alpha1.MetaComponentKind, nil
+	default:
+		return "", fmt.Errorf("unknown component '%s', it should be one of frontend, datanode and meta", component)
+	}
+}
@@ -0,0 +1,296 @@
+/*
+ * Copyright 2023 Greptime Team
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package kubernetes
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/json"
+	"fmt"
+	"path/filepath"
+	"strings"
+
+	greptimedbclusterv1alpha1 "github.com/GreptimeTeam/greptimedb-operator/apis/v1alpha1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	opt "github.com/GreptimeTeam/gtctl/pkg/cluster"
+	"github.com/GreptimeTeam/gtctl/pkg/utils/users"
+)
+
+const (
+	// usersChecksumAnnotation is the checksum of users in the pod template of frontend,
+	// the frontend pods are recreated to load the users once they are changed.
+	usersChecksumAnnotation = "gtctl.greptime.io/users-checksum"
+
+	// usersArgsAnnotation records the args of frontend that gtctl added to the cluster in JSON, so only they
+	// are removed when the users are changed, and the args set by others are kept.
+	usersArgsAnnotation = "gtctl.greptime.io/users-args"
+
+	// userEnvPrefix is the prefix of the environment variables that pass the passwords of users to frontend,
+	// they are referred by the user provider arg, so the passwords are only in the Secret.
+	userEnvPrefix = "GTCTL_USER_"
+
+	userProviderArg = "--user-provider="
+)
+
+// UsersSecretName returns the name of the Secret that stores the users of cluster, the keys are the names
+// of users and the values are their passwords.
+func UsersSecretName(clusterName string) string {
+	return fmt.Sprintf("%s-users", clusterName)
+}
+
+// AddUser adds the user into the users Secret of cluster, and the frontend is configured to authenticate
+// the clients with the users in it.
+func (c *Cluster) AddUser(ctx context.Context, options *opt.UserOptions) error {
+	if err := users.Validate(options.User, options.Password); err != nil {
+		return err
+	}
+
+	return c.updateUsers(ctx, options, func(all map[string]string) error {
+		all[options.User] = options.Password
+		return nil
+	}, fmt.Sprintf("User '%s' is added to cluster '%s' in '%s'", options.User, options.Name, options.Namespace))
+}
+
+// RemoveUser removes the user from the users Secret of cluster, the Secret is deleted with the last user,
+// and the frontend accepts the clients without authentication then.
+func (c *Cluster) RemoveUser(ctx context.Context, options *opt.UserOptions) error {
+	return c.updateUsers(ctx, options, func(all map[string]string) error {
+		if _, ok := all[options.User]; !ok {
+			return fmt.Errorf("user '%s' is not found in cluster '%s'", options.User, options.Name)
+		}
+		delete(all, options.User)
+		return nil
+	}, fmt.Sprintf("User '%s' is removed from cluster '%s' in '%s'", options.User, options.Name, options.Namespace))
+}
+
+func (c *Cluster) ListUsers(ctx context.Context, options *opt.UserOptions) ([]string, error) {
+	all, err := c.getUsers(ctx, options.Namespace, options.Name)
+	if err != nil {
+		return nil, err
+	}
+	return users.Names(all), nil
+}
+
+// getUsers returns the users in the users Secret of cluster, there are no users if it doesn't exist.
+func (c *Cluster) getUsers(ctx context.Context, namespace, name string) (map[string]string, error) {
+	all := make(map[string]string)
+	secret, err := c.client.GetSecret(ctx, UsersSecretName(name), namespace)
+	if errors.IsNotFound(err) {
+		return all, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	for user, password := range secret.Data {
+		all[user] = string(password)
+	}
+	return all, nil
+}
+
+// updateUsers updates the users Secret of cluster by update, configures the frontend with the updated users
+// and prints the message.
+func (c *Cluster) updateUsers(ctx context.Context, options *opt.UserOptions, update func(map[string]string) error,
+	message string) error {
+	cluster, err := c.get(ctx, &opt.GetOptions{Namespace: options.Namespace, Name: options.Name})
+	if err != nil {
+		return err
+	}
+	if cluster.Spec.Frontend == nil {
+		return fmt.Errorf("cluster '%s' in '%s' has no frontend", options.Name, options.Namespace)
+	}
+
+	all, err := c.getUsers(ctx, options.Namespace, options.Name)
+	if err != nil {
+		return err
+	}
+	if err = update(all); err != nil {
+		return err
+	}
+	if err = configureFrontendUsers(cluster, all); err != nil {
+		return err
+	}
+
+	// The Secret is deleted with the cluster since it's owned by the cluster.
+	if len(all) > 0 {
+		secret := &corev1.Secret{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      UsersSecretName(options.Name),
+				Namespace: options.Namespace,
+				OwnerReferences: []metav1.OwnerReference{{
+					APIVersion: greptimedbclusterv1alpha1.GroupVersion.String(),
+					Kind:       "GreptimeDBCluster",
+					Name:       cluster.Name,
+					UID:        cluster.UID,
+				}},
+			},
+			Type: corev1.SecretTypeOpaque,
+			Data: make(map[string][]byte, len(all)),
+		}
+		for user, password := range all {
+			secret.Data[user] = []byte(password)
+		}
+		if err = c.client.CreateOrUpdateSecret(ctx, secret); err != nil {
+			return err
+		}
+	} else if err = c.client.DeleteSecret(ctx, UsersSecretName(options.Name), options.Namespace); err != nil {
+		return err
+	}
+
+	if err = c.client.UpdateCluster(ctx, options.Namespace, cluster); err != nil {
+		return err
+	}
+	c.logger.V(0).Infof("%s", message)
+	c.logger.V(0).Infof("The frontend of cluster '%s' in '%s' is restarting to apply the users", options.Name, options.Namespace)
+
+	return nil
+}
+
+// configureFrontendUsers configures the frontend to authenticate the clients with the users. The passwords
+// are passed by the environment variables from the users Secret, and the user provider arg refers to them.
+//
+// The user provider arg is appended to the args of frontend, which are the default args built by the operator
+// if they are not specified. The args added by gtctl are recorded in usersArgsAnnotation of cluster, and only
+// they are removed when the users are changed. It fails if the frontend has its own user provider arg.
+func configureFrontendUsers(cluster *greptimedbclusterv1alpha1.GreptimeDBCluster, all map[string]string) error {
+	frontend := cluster.Spec.Frontend
+	if frontend.Template == nil {
+		frontend.Template = &greptimedbclusterv1alpha1.PodTemplateSpec{}
+	}
+	if frontend.Template.MainContainer == nil {
+		frontend.Template.MainContainer = &greptimedbclusterv1alpha1.MainContainerSpec{Resources: &corev1.ResourceRequirements{}}
+	}
+	template, main := frontend.Template, frontend.Template.MainContainer
+
+	var added []string
+	if annotation, ok := cluster.Annotations[usersArgsAnnotation]; ok {
+		if err := json.Unmarshal([]byte(annotation), &added); err != nil {
+			return fmt.Errorf("invalid annotation '%s' of cluster '%s': %v", usersArgsAnnotation, cluster.Name, err)
+		}
+	}
+
+	var env []corev1.EnvVar
+	for _, e := range main.Env {
+		if !strings.HasPrefix(e.Name, userEnvPrefix) {
+			env = append(env, e)
+		}
+	}
+	args := removeArgs(main.Args, added)
+	for _, arg := range args {
+		if strings.HasPrefix(arg, userProviderArg) {
+			return fmt.Errorf("the frontend of cluster '%s' uses its own user provider '%s'",
+				cluster.Name, strings.TrimPrefix(arg, userProviderArg))
+		}
+	}
+	main.Env, main.Args = env, args
+	delete(template.Annotations, usersChecksumAnnotation)
+	delete(cluster.Annotations, usersArgsAnnotation)
+
+	// The operator builds the default args again once there are no args.
+	if len(all) == 0 {
+		return nil
+	}
+
+	added = nil
+	if len(main.Args) == 0 {
+		main.Args = defaultFrontendArgs(cluster)
+		added = append(added, main.Args...)
+	}
+	var (
+		checksum = sha256.New()
+		refs     []string
+	)
+	for i, user := range users.Names(all) {
+		name := fmt.Sprintf("%s%d", userEnvPrefix, i)
+		main.Env = append(main.Env, corev1.EnvVar{
+			Name: name,
+			ValueFrom: &corev1.EnvVarSource{
+				SecretKeyRef: &corev1.SecretKeySelector{
+					LocalObjectReference: corev1.LocalObjectReference{Name: UsersSecretName(cluster.Name)},
+					Key:                  user,
+				},
+			},
+		})
+		refs = append(refs, fmt.Sprintf("%s=$(%s)", user, name))
+		checksum.Write([]byte(fmt.Sprintf("%s=%s\n", user, all[user])))
+	}
+	providerArg := userProviderArg + users.CmdProviderPrefix + strings.Join(refs, ",")
+	main.Args = append(main.Args, providerArg)
+	added = append(added, providerArg)
+
+	annotation, err := json.Marshal(added)
+	if err != nil {
+		return err
+	}
+	if cluster.Annotations == nil {
+		cluster.Annotations = make(map[string]string)
+	}
+	cluster.Annotations[usersArgsAnnotation] = string(annotation)
+
+	if template.Annotations == nil {
+		template.Annotations = make(map[string]string)
+	}
+	template.Annotations[usersChecksumAnnotation] = fmt.Sprintf("%x", checksum.Sum(nil))
+	return nil
+}
+
+// removeArgs returns args without the ones in removed, each of removed is only removed once.
+func removeArgs(args, removed []string) []string {
+	counts := make(map[string]int, len(removed))
+	for _, arg := range removed {
+		counts[arg]++
+	}
+
+	var ret []string
+	for _, arg := range args {
+		if counts[arg] > 0 {
+			counts[arg]--
+			continue
+		}
+		ret = append(ret, arg)
+	}
+	return ret
+}
+
+// defaultFrontendArgs returns the args that the operator starts frontend with if they are not specified.
+// They are the ones built by greptimedb-operator v0.1.0-alpha.9, whose frontend spec can't pass the user provider
+// but by replacing all the args, so they should be updated along with the operator.
+func defaultFrontendArgs(cluster *greptimedbclusterv1alpha1.GreptimeDBCluster) []string {
+	args := []string{
+		"frontend", "start",
+		"--grpc-addr", fmt.Sprintf("0.0.0.0:%d", cluster.Spec.GRPCServicePort),
+		"--metasrv-addr", fmt.Sprintf("%s-%s.%s:%d", cluster.Name, greptimedbclusterv1alpha1.MetaComponentKind,
+			cluster.Namespace, cluster.Spec.Meta.ServicePort),
+		"--http-addr", fmt.Sprintf("0.0.0.0:%d", cluster.Spec.HTTPServicePort),
+		"--mysql-addr", fmt.Sprintf("0.0.0.0:%d", cluster.Spec.MySQLServicePort),
+		"--postgres-addr", fmt.Sprintf("0.0.0.0:%d", cluster.Spec.PostgresServicePort),
+		"--opentsdb-addr", fmt.Sprintf("0.0.0.0:%d", cluster.Spec.OpenTSDBServicePort),
+	}
+
+	if tls := cluster.Spec.Frontend.TLS; tls != nil {
+		args = append(args,
+			"--tls-mode", "require",
+			"--tls-cert-path", filepath.Join(tls.CertificateMountPath, corev1.TLSCertKey),
+			"--tls-key-path", filepath.Join(tls.CertificateMountPath, corev1.TLSPrivateKeyKey),
+		)
+	}
+
+	return args
+}
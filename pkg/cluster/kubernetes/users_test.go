@@ -0,0 +1,98 @@
+/*
+ * Copyright 2023 Greptime Team
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package kubernetes
+
+import (
+	"testing"
+
+	greptimedbclusterv1alpha1 "github.com/GreptimeTeam/greptimedb-operator/apis/v1alpha1"
+	"github.com/stretchr/testify/assert"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestConfigureFrontendUsers(t *testing.T) {
+	cluster := &greptimedbclusterv1alpha1.GreptimeDBCluster{
+		ObjectMeta: metav1.ObjectMeta{Name: "mycluster", Namespace: "default"},
+		Spec: greptimedbclusterv1alpha1.GreptimeDBClusterSpec{
+			Frontend: &greptimedbclusterv1alpha1.FrontendSpec{
+				ComponentSpec: greptimedbclusterv1alpha1.ComponentSpec{
+					Template: &greptimedbclusterv1alpha1.PodTemplateSpec{
+						MainContainer: &greptimedbclusterv1alpha1.MainContainerSpec{
+							Env: []corev1.EnvVar{{Name: "RUST_LOG", Value: "info"}},
+						},
+					},
+				},
+			},
+			Meta:                &greptimedbclusterv1alpha1.MetaSpec{ServicePort: 3002},
+			HTTPServicePort:     4000,
+			GRPCServicePort:     4001,
+			MySQLServicePort:    4002,
+			PostgresServicePort: 4003,
+			OpenTSDBServicePort: 4242,
+		},
+	}
+	template := cluster.Spec.Frontend.Template
+
+	assert.NoError(t, configureFrontendUsers(cluster, map[string]string{"bob": "secret1", "alice": "secret2"}))
+	main := template.MainContainer
+	assert.Equal(t, "--metasrv-addr", main.Args[4])
+	assert.Equal(t, "mycluster-meta.default:3002", main.Args[5])
+	assert.Equal(t, "--user-provider=static_user_provider:cmd:alice=$(GTCTL_USER_0),bob=$(GTCTL_USER_1)",
+		main.Args[len(main.Args)-1])
+	assert.Len(t, main.Env, 3)
+	assert.Equal(t, "bob", main.Env[2].ValueFrom.SecretKeyRef.Key)
+	assert.Equal(t, "mycluster-users", main.Env[2].ValueFrom.SecretKeyRef.Name)
+	checksum := template.Annotations[usersChecksumAnnotation]
+	assert.NotEmpty(t, checksum)
+
+	// The pods are recreated once the password is changed, and the user provider is not appended again.
+	assert.NoError(t, configureFrontendUsers(cluster, map[string]string{"bob": "secret3", "alice": "secret2"}))
+	assert.NotEqual(t, checksum, template.Annotations[usersChecksumAnnotation])
+	assert.Len(t, main.Args, len(defaultFrontendArgs(cluster))+1)
+	assert.Len(t, main.Env, 3)
+
+	// The frontend is started by the default args of operator again without users.
+	assert.NoError(t, configureFrontendUsers(cluster, nil))
+	assert.Nil(t, main.Args)
+	assert.Equal(t, []corev1.EnvVar{{Name: "RUST_LOG", Value: "info"}}, main.Env)
+	assert.NotContains(t, template.Annotations, usersChecksumAnnotation)
+	assert.NotContains(t, cluster.Annotations, usersArgsAnnotation)
+
+	// The args set by others are kept, only the user provider arg is added to and removed from them.
+	custom := []string{"frontend", "start", "--metasrv-addr", "meta.example:3002", "--http-addr", "0.0.0.0:4000"}
+	main.Args = append([]string(nil), custom...)
+	assert.NoError(t, configureFrontendUsers(cluster, map[string]string{"bob": "secret1"}))
+	assert.Equal(t, append(custom, "--user-provider=static_user_provider:cmd:bob=$(GTCTL_USER_0)"), main.Args)
+	assert.NoError(t, configureFrontendUsers(cluster, nil))
+	assert.Equal(t, custom, main.Args)
+
+	// The default args added by gtctl are removed even if they are stale, e.g. the ports are changed.
+	main.Args = nil
+	assert.NoError(t, configureFrontendUsers(cluster, map[string]string{"bob": "secret1"}))
+	cluster.Spec.HTTPServicePort = 5000
+	assert.NoError(t, configureFrontendUsers(cluster, map[string]string{"bob": "secret1"}))
+	assert.Contains(t, main.Args, "0.0.0.0:5000")
+	assert.NotContains(t, main.Args, "0.0.0.0:4000")
+	assert.NoError(t, configureFrontendUsers(cluster, nil))
+	assert.Nil(t, main.Args)
+
+	// The user provider arg set by others takes precedence.
+	main.Args = append(custom, "--user-provider=static_user_provider:cmd:admin=admin")
+	assert.ErrorContains(t, configureFrontendUsers(cluster, map[string]string{"bob": "secret1"}),
+		"uses its own user provider 'static_user_provider:cmd:admin=admin'")
+}
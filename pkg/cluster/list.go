@@ -0,0 +1,59 @@
+/*
+ * Copyright 2023 Greptime Team
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package cluster
+
+import (
+	"fmt"
+
+	"github.com/olekukonko/tablewriter"
+)
+
+const (
+	// The types of cluster that shown in the list view.
+	KubernetesClusterType = "Kubernetes"
+	BareMetalClusterType  = "Bare-Metal"
+
+	// NotAvailable is shown in the list view if the cluster of some type has no such field.
+	NotAvailable = "N/A"
+)
+
+// ListViewHeaders are the headers of the list view. They are shared by all types of clusters,
+// so the clusters of different types can be listed in one table.
+var ListViewHeaders = []string{
+	"Name", "Type", "Namespace", "Creation Date", "GreptimeDB Version", "Etcd Version", "Replicas", "Status", "Processes",
+}
+
+// ConfigListView configures the table to render the list view.
+func ConfigListView(table *tablewriter.Table) {
+	table.SetAutoWrapText(false)
+	table.SetAutoFormatHeaders(true)
+	table.SetHeaderAlignment(tablewriter.ALIGN_LEFT)
+	table.SetAlignment(tablewriter.ALIGN_LEFT)
+	table.SetCenterSeparator("")
+	table.SetColumnSeparator("")
+	table.SetRowSeparator("")
+	table.SetHeaderLine(false)
+	table.SetBorder(false)
+	table.SetTablePadding("\t")
+	table.SetNoWhiteSpace(true)
+	table.SetHeader(ListViewHeaders)
+}
+
+// ReplicasView returns the replicas of each component in the list view.
+func ReplicasView(frontend, datanode, meta int) string {
+	return fmt.Sprintf("frontend=%d,datanode=%d,meta=%d", frontend, datanode, meta)
+}
@@ -0,0 +1,203 @@
+/*
+ * Copyright 2023 Greptime Team
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package cluster
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"io"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+)
+
+// LogSource is the log stream of one replica, its lines are prefixed by Name when printing.
+type LogSource struct {
+	Name   string
+	Reader io.ReadCloser
+}
+
+// logTimestampRegexp matches the timestamp at the beginning of log lines, like the ones of greptime, etcd and
+// Kubernetes: '2023-10-01T10:00:00.123456Z', '2023-10-01 10:00:00.123+08:00' or '{"ts":"2023-10-01T10:00:00Z"'.
+var logTimestampRegexp = regexp.MustCompile(`\d{4}-\d{2}-\d{2}[T ]\d{2}:\d{2}:\d{2}(\.\d+)?(Z|[+-]\d{2}:?\d{2})?`)
+
+// logTimestampSearchLimit is the max number of leading bytes that are searched for the timestamp of log line.
+const logTimestampSearchLimit = 64
+
+// ParseLogTimestamp returns the timestamp at the beginning of the log line.
+func ParseLogTimestamp(line string) (time.Time, bool) {
+	if len(line) > logTimestampSearchLimit {
+		line = line[:logTimestampSearchLimit]
+	}
+	raw := logTimestampRegexp.FindString(line)
+	if len(raw) == 0 {
+		return time.Time{}, false
+	}
+
+	raw = strings.Replace(raw, " ", "T", 1)
+	for _, layout := range []string{time.RFC3339Nano, "2006-01-02T15:04:05.999999999Z0700", "2006-01-02T15:04:05.999999999"} {
+		if ts, err := time.Parse(layout, raw); err == nil {
+			return ts, true
+		}
+	}
+	return time.Time{}, false
+}
+
+// PrintLogs writes the lines of all the sources into w, each line is prefixed by the name of its source.
+// If follow is false, the lines of sources are interleaved by their timestamps, otherwise they are written
+// once they arrive until ctx is done. The sources are closed after printing.
+func PrintLogs(ctx context.Context, w io.Writer, sources []*LogSource, follow bool) error {
+	defer func() {
+		for _, source := range sources {
+			_ = source.Reader.Close()
+		}
+	}()
+
+	width := 0
+	for _, source := range sources {
+		if len(source.Name) > width {
+			width = len(source.Name)
+		}
+	}
+	prefix := func(source *LogSource) string {
+		return fmt.Sprintf("[%s]%s ", source.Name, strings.Repeat(" ", width-len(source.Name)))
+	}
+
+	if follow {
+		return followLogs(ctx, w, sources, prefix)
+	}
+	return mergeLogs(w, sources, prefix)
+}
+
+// logLine is one line of log source, and the line without timestamp takes the one of its previous line.
+type logLine struct {
+	ts   time.Time
+	text string
+}
+
+func readLogLines(r io.Reader) ([]logLine, error) {
+	var (
+		lines []logLine
+		ts    time.Time
+	)
+	reader := bufio.NewReader(r)
+	for {
+		text, err := reader.ReadString('\n')
+		if len(text) > 0 {
+			if parsed, ok := ParseLogTimestamp(text); ok {
+				ts = parsed
+			}
+			lines = append(lines, logLine{ts: ts, text: strings.TrimRight(text, "\n")})
+		}
+		if err == io.EOF {
+			return lines, nil
+		}
+		if err != nil {
+			return lines, err
+		}
+	}
+}
+
+// mergeLogs writes the lines of sources in the order of their timestamps,
+// and the order of lines in the same source is kept.
+func mergeLogs(w io.Writer, sources []*LogSource, prefix func(*LogSource) string) error {
+	lines := make([][]logLine, len(sources))
+	for i, source := range sources {
+		var err error
+		if lines[i], err = readLogLines(source.Reader); err != nil {
+			return fmt.Errorf("failed to read logs of '%s': %v", source.Name, err)
+		}
+	}
+
+	for {
+		next := -1
+		for i := range lines {
+			if len(lines[i]) == 0 {
+				continue
+			}
+			if next < 0 || lines[i][0].ts.Before(lines[next][0].ts) {
+				next = i
+			}
+		}
+		if next < 0 {
+			return nil
+		}
+
+		if _, err := fmt.Fprintf(w, "%s%s\n", prefix(sources[next]), lines[next][0].text); err != nil {
+			return err
+		}
+		lines[next] = lines[next][1:]
+	}
+}
+
+// followLogs writes the lines of sources once they arrive until all the sources are drained or ctx is done.
+func followLogs(ctx context.Context, w io.Writer, sources []*LogSource, prefix func(*LogSource) string) error {
+	var (
+		wg   sync.WaitGroup
+		mu   sync.Mutex
+		errs = make([]error, len(sources))
+	)
+	for i, source := range sources {
+		wg.Add(1)
+		go func(i int, source *LogSource) {
+			defer wg.Done()
+
+			reader := bufio.NewReader(source.Reader)
+			for {
+				text, err := reader.ReadString('\n')
+				if len(text) > 0 {
+					mu.Lock()
+					_, writeErr := fmt.Fprintf(w, "%s%s\n", prefix(source), strings.TrimRight(text, "\n"))
+					mu.Unlock()
+					if writeErr != nil {
+						errs[i] = writeErr
+						return
+					}
+				}
+				if err != nil {
+					if err != io.EOF && ctx.Err() == nil {
+						errs[i] = fmt.Errorf("failed to read logs of '%s': %v", source.Name, err)
+					}
+					return
+				}
+			}
+		}(i, source)
+	}
+
+	// Unblock the readers when ctx is done.
+	done := make(chan struct{})
+	go func() {
+		select {
+		case <-ctx.Done():
+			for _, source := range sources {
+				_ = source.Reader.Close()
+			}
+		case <-done:
+		}
+	}()
+	wg.Wait()
+	close(done)
+
+	for _, err := range errs {
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,91 @@
+/*
+ * Copyright 2023 Greptime Team
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package cluster
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseLogTimestamp(t *testing.T) {
+	tests := []struct {
+		line string
+		want time.Time
+		ok   bool
+	}{
+		{
+			line: "2023-10-01T10:00:00.123456Z  INFO frontend: started",
+			want: time.Date(2023, 10, 1, 10, 0, 0, 123456000, time.UTC),
+			ok:   true,
+		},
+		{
+			line: `{"level":"info","ts":"2023-10-01T18:00:00.123+0800","caller":"etcdmain/etcd.go:73"}`,
+			want: time.Date(2023, 10, 1, 10, 0, 0, 123000000, time.UTC),
+			ok:   true,
+		},
+		{
+			line: "2023-10-01 10:00:00 started",
+			want: time.Date(2023, 10, 1, 10, 0, 0, 0, time.UTC),
+			ok:   true,
+		},
+		{
+			line: "    at main.rs:10",
+			ok:   false,
+		},
+	}
+
+	for _, tt := range tests {
+		got, ok := ParseLogTimestamp(tt.line)
+		assert.Equal(t, tt.ok, ok, tt.line)
+		if tt.ok {
+			assert.True(t, tt.want.Equal(got), "%s: want %s, got %s", tt.line, tt.want, got)
+		}
+	}
+}
+
+func TestPrintLogs(t *testing.T) {
+	sources := []*LogSource{
+		{
+			Name: "datanode.0",
+			Reader: io.NopCloser(strings.NewReader(
+				"2023-10-01T10:00:01Z datanode 0 line 1\n" +
+					"  stack line\n" +
+					"2023-10-01T10:00:03Z datanode 0 line 2\n")),
+		},
+		{
+			Name: "frontend.0",
+			Reader: io.NopCloser(strings.NewReader(
+				"2023-10-01T10:00:02Z frontend 0 line 1\n" +
+					"2023-10-01T10:00:04Z frontend 0 line 2")),
+		},
+	}
+
+	var out bytes.Buffer
+	assert.NoError(t, PrintLogs(context.Background(), &out, sources, false))
+	assert.Equal(t, ""+
+		"[datanode.0] 2023-10-01T10:00:01Z datanode 0 line 1\n"+
+		"[datanode.0]   stack line\n"+
+		"[frontend.0] 2023-10-01T10:00:02Z frontend 0 line 1\n"+
+		"[datanode.0] 2023-10-01T10:00:03Z datanode 0 line 2\n"+
+		"[frontend.0] 2023-10-01T10:00:04Z frontend 0 line 2\n", out.String())
+}
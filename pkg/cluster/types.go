@@ -18,6 +18,8 @@ package cluster
 
 import (
 	"context"
+	"io"
+	"time"
 
 	greptimedbclusterv1alpha1 "github.com/GreptimeTeam/greptimedb-operator/apis/v1alpha1"
 	"github.com/olekukonko/tablewriter"
@@ -29,7 +31,8 @@ type Operations interface {
 	// Get gets the current cluster profile.
 	Get(ctx context.Context, options *GetOptions) error
 
-	// List lists all cluster profiles.
+	// List appends all cluster profiles to the table of ListOptions in list view,
+	// the caller configures the table by ConfigListView and renders it.
 	List(ctx context.Context, options *ListOptions) error
 
 	// Scale scales the current cluster according to NewReplicas in ScaleOptions,
@@ -44,6 +47,19 @@ type Operations interface {
 
 	// Connect connects to a specific cluster.
 	Connect(ctx context.Context, options *ConnectOptions) error
+
+	// Logs prints the logs of the components of a specific cluster.
+	Logs(ctx context.Context, options *LogsOptions) error
+
+	// AddUser adds the static user that the frontend of a specific cluster authenticates the clients with,
+	// or changes the password of the existing user.
+	AddUser(ctx context.Context, options *UserOptions) error
+
+	// RemoveUser removes the static user of a specific cluster.
+	RemoveUser(ctx context.Context, options *UserOptions) error
+
+	// ListUsers returns the sorted names of the static users of a specific cluster.
+	ListUsers(ctx context.Context, options *UserOptions) ([]string, error)
 }
 
 type GetOptions struct {
@@ -122,6 +138,58 @@ type CreateEtcdOptions struct {
 	ConfigValues         string `helm:"*"`
 }
 
+// StartOptions is the options to start a persisted cluster in bare-metal mode.
+type StartOptions struct {
+	Name                   string
+	UseGreptimeCNArtifacts bool
+
+	Spinner *status.Spinner
+}
+
+// StopOptions is the options to stop a running cluster in bare-metal mode.
+type StopOptions struct {
+	Name string
+}
+
+// UpgradeOptions is the options to upgrade a running cluster in bare-metal mode.
+type UpgradeOptions struct {
+	Name                   string
+	GreptimeBinVersion     string
+	UseGreptimeCNArtifacts bool
+
+	Spinner *status.Spinner
+}
+
+// BackupOptions is the options to back up a cluster in bare-metal mode.
+type BackupOptions struct {
+	Name string
+
+	// Output is the path of the archive, e.g. 'mycluster.tar.zst'.
+	Output string
+
+	// Stop stops the running cluster before backing up and starts it again in background afterwards,
+	// so the data is consistent. The running cluster can not be backed up without it.
+	Stop                   bool
+	UseGreptimeCNArtifacts bool
+}
+
+// RestoreOptions is the options to restore a cluster from the archive in bare-metal mode.
+type RestoreOptions struct {
+	// File is the path of the archive that is created by backup.
+	File string
+
+	// Name is the name of restored cluster, default is the name of the backed up cluster.
+	Name string
+}
+
+// RotateCertsOptions is the options to rotate the certificates of a cluster with TLS in bare-metal mode.
+type RotateCertsOptions struct {
+	Name string
+
+	// RotateCA recreates the CA before issuing the certificates, the clients have to trust the new CA then.
+	RotateCA bool
+}
+
 type ConnectProtocol int
 
 const (
@@ -133,4 +201,43 @@ type ConnectOptions struct {
 	Namespace string
 	Name      string
 	Protocol  ConnectProtocol
+
+	// Replica is the index of frontend replica to connect, only used in bare-metal mode.
+	Replica int
+
+	// User is the static user to connect as, its password is the stored one.
+	User string
+}
+
+// UserOptions is the options to manage the static users of a cluster.
+type UserOptions struct {
+	Namespace string
+	Name      string
+
+	// User and Password are the user to add or remove, they are not used in listing.
+	User     string
+	Password string
+}
+
+type LogsOptions struct {
+	Namespace string
+	Name      string
+
+	// Component is the component to print logs, all the components if it's empty.
+	Component string
+
+	// Replica is the index of replica to print logs, all the replicas if it's negative.
+	Replica int
+
+	// Follow keeps printing the new logs until the context is done.
+	Follow bool
+
+	// Since only prints the logs newer than the relative duration, all the logs if it's 0.
+	Since time.Duration
+
+	// Tail is the number of lines from the end of logs of each replica to print, all the lines if it's negative.
+	Tail int64
+
+	// Writer is where the logs are printed to.
+	Writer io.Writer
 }
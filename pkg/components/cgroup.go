@@ -0,0 +1,208 @@
+/*
+ * Copyright 2023 Greptime Team
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package components
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"os"
+	"path"
+	"strconv"
+	"strings"
+	"time"
+
+	"k8s.io/apimachinery/pkg/api/resource"
+
+	"github.com/GreptimeTeam/gtctl/pkg/config"
+)
+
+const (
+	// cgroupSlice is the cgroup that the replicas of all the clusters are placed in.
+	cgroupSlice = "gtctl.slice"
+
+	// cpuMaxPeriod is the period in microseconds of 'cpu.max'.
+	cpuMaxPeriod = 100000
+)
+
+// cgroupRoot is the mount point of cgroup v2 unified hierarchy.
+var cgroupRoot = "/sys/fs/cgroup"
+
+// ClusterCgroup returns the cgroup that the replicas of the cluster are placed in, relative to the cgroup root.
+func ClusterCgroup(cluster string) string {
+	return path.Join(cgroupSlice, cluster)
+}
+
+// replicaCgroup returns the cgroup of the replica, it's empty if there are no resource limits
+// or the cgroup of cluster is not set.
+func replicaCgroup(workingDirs WorkingDirs, name string, resources *config.Resources) string {
+	if resources == nil || len(workingDirs.Cgroup) == 0 {
+		return ""
+	}
+	return path.Join(workingDirs.Cgroup, name)
+}
+
+// setupCgroup creates the cgroup of replica and applies the resource limits to it.
+// The controllers of the limits are enabled in all its ancestors.
+func setupCgroup(group string, resources *config.Resources) error {
+	controllers, err := os.ReadFile(path.Join(cgroupRoot, "cgroup.controllers"))
+	if err != nil {
+		return fmt.Errorf("cgroup v2 is not available: %v", err)
+	}
+
+	var (
+		enabled []string
+		limits  = make(map[string]string)
+	)
+	if len(resources.Memory) > 0 {
+		q, err := resource.ParseQuantity(resources.Memory)
+		if err != nil {
+			return err
+		}
+		enabled = append(enabled, "memory")
+		limits["memory.max"] = strconv.FormatInt(q.Value(), 10)
+	}
+	if len(resources.CPU) > 0 {
+		q, err := resource.ParseQuantity(resources.CPU)
+		if err != nil {
+			return err
+		}
+		enabled = append(enabled, "cpu")
+		limits["cpu.max"] = fmt.Sprintf("%d %d", q.MilliValue()*cpuMaxPeriod/1000, cpuMaxPeriod)
+	}
+
+	available := strings.Fields(string(controllers))
+	for _, controller := range enabled {
+		if !contains(available, controller) {
+			return fmt.Errorf("cgroup controller '%s' is not available", controller)
+		}
+	}
+
+	dir := path.Join(cgroupRoot, group)
+	if err = os.MkdirAll(dir, 0755); err != nil {
+		return err
+	}
+
+	// The controllers have to be enabled from the root down to the parent of replica.
+	parent := cgroupRoot
+	for _, elem := range strings.Split(path.Dir(group), "/") {
+		if err = enableControllers(parent, enabled); err != nil {
+			return err
+		}
+		parent = path.Join(parent, elem)
+	}
+	if err = enableControllers(parent, enabled); err != nil {
+		return err
+	}
+
+	for file, limit := range limits {
+		if err = os.WriteFile(path.Join(dir, file), []byte(limit), 0644); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func enableControllers(dir string, controllers []string) error {
+	file := path.Join(dir, "cgroup.subtree_control")
+	current, err := os.ReadFile(file)
+	if err != nil && !os.IsNotExist(err) {
+		return err
+	}
+
+	var missing []string
+	for _, controller := range controllers {
+		if !contains(strings.Fields(string(current)), controller) {
+			missing = append(missing, "+"+controller)
+		}
+	}
+	if len(missing) == 0 {
+		return nil
+	}
+
+	return os.WriteFile(file, []byte(strings.Join(missing, " ")), 0644)
+}
+
+// cgroupCommand returns the command that places itself in the cgroup before it executes the binary, so all the
+// threads and children of the binary are limited from the start. The shell replaces itself with the binary, so the
+// pid of the binary is the one of the command. The binary runs without limits if it can't be placed in the cgroup.
+func cgroupCommand(group, binary string, args []string) (string, []string) {
+	const script = `echo $$ > "$0" || echo "failed to move into cgroup '$0', it runs without limits" >&2; exec "$@"`
+	return "/bin/sh", append([]string{"-c", script, path.Join(cgroupRoot, group, "cgroup.procs"), binary}, args...)
+}
+
+// RemoveCgroup removes the cgroup, it only works after all the processes in it exit.
+func RemoveCgroup(group string) error {
+	if err := os.Remove(path.Join(cgroupRoot, group)); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	return nil
+}
+
+// CgroupUsage is the current resource usage of a cgroup.
+type CgroupUsage struct {
+	// Memory is the memory in bytes that the processes in cgroup are using, it's negative if it's not accounted.
+	Memory int64
+
+	// CPU is the total CPU time that the processes in cgroup have used.
+	CPU time.Duration
+}
+
+// GetCgroupUsage returns the current resource usage of the cgroup.
+func GetCgroupUsage(group string) (*CgroupUsage, error) {
+	dir := path.Join(cgroupRoot, group)
+
+	// The memory is only accounted if its controller is enabled.
+	usage := CgroupUsage{Memory: -1}
+	memory, err := os.ReadFile(path.Join(dir, "memory.current"))
+	if err != nil && !os.IsNotExist(err) {
+		return nil, err
+	}
+	if err == nil {
+		if usage.Memory, err = strconv.ParseInt(strings.TrimSpace(string(memory)), 10, 64); err != nil {
+			return nil, err
+		}
+	}
+
+	stat, err := os.ReadFile(path.Join(dir, "cpu.stat"))
+	if err != nil {
+		return nil, err
+	}
+	scanner := bufio.NewScanner(bytes.NewReader(stat))
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) == 2 && fields[0] == "usage_usec" {
+			usec, err := strconv.ParseInt(fields[1], 10, 64)
+			if err != nil {
+				return nil, err
+			}
+			usage.CPU = time.Duration(usec) * time.Microsecond
+		}
+	}
+
+	return &usage, nil
+}
+
+func contains(items []string, item string) bool {
+	for _, i := range items {
+		if i == item {
+			return true
+		}
+	}
+	return false
+}
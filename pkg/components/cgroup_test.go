@@ -0,0 +1,108 @@
+/*
+ * Copyright 2023 Greptime Team
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package components
+
+import (
+	"os"
+	"os/exec"
+	"path"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/GreptimeTeam/gtctl/pkg/config"
+)
+
+// fakeCgroupRoot replaces the cgroup root with a temp dir that has the given controllers.
+func fakeCgroupRoot(t *testing.T, controllers string) string {
+	root := t.TempDir()
+	if err := os.WriteFile(path.Join(root, "cgroup.controllers"), []byte(controllers), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	origin := cgroupRoot
+	cgroupRoot = root
+	t.Cleanup(func() { cgroupRoot = origin })
+
+	return root
+}
+
+func TestSetupCgroup(t *testing.T) {
+	root := fakeCgroupRoot(t, "cpuset cpu io memory pids")
+	group := path.Join(ClusterCgroup("mycluster"), "datanode.0")
+
+	err := setupCgroup(group, &config.Resources{Memory: "512Mi", CPU: "500m"})
+	assert.NoError(t, err)
+
+	for _, dir := range []string{root, path.Join(root, cgroupSlice), path.Join(root, cgroupSlice, "mycluster")} {
+		assert.Equal(t, "+memory +cpu", readFile(t, path.Join(dir, "cgroup.subtree_control")))
+	}
+	assert.Equal(t, "536870912", readFile(t, path.Join(root, group, "memory.max")))
+	assert.Equal(t, "50000 100000", readFile(t, path.Join(root, group, "cpu.max")))
+}
+
+func TestCgroupCommand(t *testing.T) {
+	root := fakeCgroupRoot(t, "cpu memory")
+	group := path.Join(ClusterCgroup("mycluster"), "datanode.0")
+	assert.NoError(t, setupCgroup(group, &config.Resources{CPU: "1"}))
+
+	// The binary is in the cgroup as soon as it runs, since it replaces the shell that placed itself in the cgroup.
+	name, args := cgroupCommand(group, "/bin/sh", []string{"-c", "echo $$"})
+	out, err := exec.Command(name, args...).Output()
+	assert.NoError(t, err)
+	assert.Equal(t, strings.TrimSpace(string(out)), strings.TrimSpace(readFile(t, path.Join(root, group, "cgroup.procs"))))
+
+	// The binary still runs if it can't be placed in the cgroup.
+	name, args = cgroupCommand(path.Join(ClusterCgroup("mycluster"), "not-exist"), "/bin/sh", []string{"-c", "echo running"})
+	out, err = exec.Command(name, args...).CombinedOutput()
+	assert.NoError(t, err)
+	assert.Contains(t, string(out), "it runs without limits")
+	assert.Contains(t, string(out), "running")
+}
+
+func TestSetupCgroupUnavailable(t *testing.T) {
+	fakeCgroupRoot(t, "cpu pids")
+	assert.Error(t, setupCgroup("gtctl.slice/mycluster/datanode.0", &config.Resources{Memory: "512Mi"}))
+
+	cgroupRoot = path.Join(t.TempDir(), "not-exist")
+	assert.Error(t, setupCgroup("gtctl.slice/mycluster/datanode.0", &config.Resources{CPU: "1"}))
+}
+
+func TestGetCgroupUsage(t *testing.T) {
+	root := fakeCgroupRoot(t, "cpu memory")
+	group := "gtctl.slice/mycluster/frontend.0"
+	if err := os.MkdirAll(path.Join(root, group), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path.Join(root, group, "cpu.stat"),
+		[]byte("usage_usec 1500000\nuser_usec 1000000\nsystem_usec 500000\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	usage, err := GetCgroupUsage(group)
+	assert.NoError(t, err)
+	assert.Equal(t, &CgroupUsage{Memory: -1, CPU: 1500 * time.Millisecond}, usage)
+
+	if err = os.WriteFile(path.Join(root, group, "memory.current"), []byte("1048576\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	usage, err = GetCgroupUsage(group)
+	assert.NoError(t, err)
+	assert.Equal(t, int64(1048576), usage.Memory)
+}
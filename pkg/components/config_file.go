@@ -0,0 +1,85 @@
+/*
+ * Copyright 2023 Greptime Team
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package components
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"path"
+
+	"github.com/BurntSushi/toml"
+
+	"github.com/GreptimeTeam/gtctl/pkg/config"
+	fileutils "github.com/GreptimeTeam/gtctl/pkg/utils/file"
+)
+
+// configFilePath returns the config file that the component runs with. It's the generated one in configsDir
+// if the component has options to render, otherwise the config file of component, which may be empty.
+func configFilePath(name, configFile string, generated bool, configsDir string) string {
+	if !generated {
+		return configFile
+	}
+	return path.Join(configsDir, fmt.Sprintf("%s.toml", name))
+}
+
+// renderConfigFile renders the options of component into the generated config file if it has options.
+// The config file of component is merged as the base, and the options override the same keys in it.
+func renderConfigFile(name, configFile string, options config.ConfigOptions, configsDir string) error {
+	if len(options) == 0 {
+		return nil
+	}
+
+	merged := make(map[string]interface{})
+	if len(configFile) > 0 {
+		if _, err := toml.DecodeFile(configFile, &merged); err != nil {
+			return fmt.Errorf("failed to parse the config '%s' of %s: %v", configFile, name, err)
+		}
+	}
+	mergeOptions(merged, options)
+
+	var buf bytes.Buffer
+	buf.WriteString(fmt.Sprintf("# Generated by gtctl from the options of %s, DO NOT EDIT.\n\n", name))
+	enc := toml.NewEncoder(&buf)
+	enc.Indent = ""
+	if err := enc.Encode(merged); err != nil {
+		return fmt.Errorf("failed to render the options of %s: %v", name, err)
+	}
+
+	if err := fileutils.EnsureDir(configsDir); err != nil {
+		return err
+	}
+	// The generated config may contain the credentials of storage.
+	return os.WriteFile(configFilePath(name, configFile, true, configsDir), buf.Bytes(), 0600)
+}
+
+// mergeOptions merges src into dst recursively, the tables are merged and the other values in dst are replaced.
+func mergeOptions(dst, src map[string]interface{}) {
+	for k, v := range src {
+		srcTable, ok := v.(map[string]interface{})
+		if !ok {
+			dst[k] = v
+			continue
+		}
+		dstTable, ok := dst[k].(map[string]interface{})
+		if !ok {
+			dstTable = make(map[string]interface{}, len(srcTable))
+			dst[k] = dstTable
+		}
+		mergeOptions(dstTable, srcTable)
+	}
+}
@@ -0,0 +1,88 @@
+/*
+ * Copyright 2023 Greptime Team
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package components
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/BurntSushi/toml"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/GreptimeTeam/gtctl/pkg/config"
+)
+
+func TestRenderConfigFile(t *testing.T) {
+	var (
+		dir        = t.TempDir()
+		configsDir = filepath.Join(dir, "configs")
+		configFile = filepath.Join(dir, "datanode.toml")
+	)
+	assert.NoError(t, os.WriteFile(configFile, []byte(`
+mode = "distributed"
+
+[storage]
+type = "File"
+data_home = "/tmp/greptimedb"
+
+[wal]
+file_size = "1GB"
+`), 0644))
+
+	// The config file is used as it is if there's no options.
+	assert.Equal(t, configFile, configFilePath("datanode", configFile, false, configsDir))
+	assert.NoError(t, renderConfigFile("datanode", configFile, nil, configsDir))
+	assert.NoDirExists(t, configsDir)
+
+	options := config.ConfigOptions{
+		"storage": map[string]interface{}{
+			"type":   "S3",
+			"bucket": "mybucket",
+		},
+		"enable_telemetry": false,
+	}
+	generated := configFilePath("datanode", configFile, true, configsDir)
+	assert.Equal(t, filepath.Join(configsDir, "datanode.toml"), generated)
+	assert.NoError(t, renderConfigFile("datanode", configFile, options, configsDir))
+
+	// The options override the same keys in the config file, and the other keys are kept.
+	var rendered map[string]interface{}
+	_, err := toml.DecodeFile(generated, &rendered)
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]interface{}{
+		"mode":             "distributed",
+		"enable_telemetry": false,
+		"storage": map[string]interface{}{
+			"type":      "S3",
+			"bucket":    "mybucket",
+			"data_home": "/tmp/greptimedb",
+		},
+		"wal": map[string]interface{}{
+			"file_size": "1GB",
+		},
+	}, rendered)
+
+	// The options are rendered without the config file.
+	assert.NoError(t, renderConfigFile("frontend", "", config.ConfigOptions{"mode": "distributed"}, configsDir))
+	out, err := os.ReadFile(filepath.Join(configsDir, "frontend.toml"))
+	assert.NoError(t, err)
+	assert.Contains(t, string(out), `mode = "distributed"`)
+
+	assert.ErrorContains(t, renderConfigFile("datanode", filepath.Join(dir, "missing.toml"), options, configsDir),
+		"failed to parse the config")
+}
@@ -19,8 +19,7 @@ package components
 import (
 	"context"
 	"fmt"
-	"net"
-	"net/http"
+	"os"
 	"path"
 	"sync"
 	"time"
@@ -42,19 +41,24 @@ type datanode struct {
 	metaSrvAddr string
 
 	workingDirs WorkingDirs
+	logConfig   *config.Log
+	hosts       RemoteHosts
 	wg          *sync.WaitGroup
 	logger      logger.Logger
 
 	dataHomeDirs []string
 	allocatedDirs
+	processes []*process
 }
 
-func NewDataNode(config *config.Datanode, metaSrvAddr string, workingDirs WorkingDirs,
-	wg *sync.WaitGroup, logger logger.Logger) ClusterComponent {
+func NewDataNode(config *config.Datanode, metaSrvAddr string, workingDirs WorkingDirs, logConfig *config.Log,
+	hosts RemoteHosts, wg *sync.WaitGroup, logger logger.Logger) ClusterComponent {
 	return &datanode{
 		config:      config,
 		metaSrvAddr: metaSrvAddr,
 		workingDirs: workingDirs,
+		logConfig:   logConfig,
+		hosts:       hosts,
 		wg:          wg,
 		logger:      logger,
 	}
@@ -65,113 +69,213 @@ func (d *datanode) Name() string {
 }
 
 func (d *datanode) Start(ctx context.Context, stop context.CancelFunc, binary string) error {
-	for i := 0; i < d.config.Replicas; i++ {
-		dirName := fmt.Sprintf("%s.%d", d.Name(), i)
+	if err := d.renderConfigFile(); err != nil {
+		return err
+	}
 
-		homeDir := path.Join(d.workingDirs.DataDir, dirName, dataHomeDir)
-		if err := fileutils.EnsureDir(homeDir); err != nil {
+	for i := 0; i < d.config.Replicas; i++ {
+		if err := d.startReplica(ctx, stop, binary, i); err != nil {
 			return err
 		}
-		d.dataHomeDirs = append(d.dataHomeDirs, homeDir)
+	}
 
-		datanodeLogDir := path.Join(d.workingDirs.LogsDir, dirName)
-		if err := fileutils.EnsureDir(datanodeLogDir); err != nil {
-			return err
-		}
-		d.logsDirs = append(d.logsDirs, datanodeLogDir)
+	return d.WaitUntilReady(ctx)
+}
 
-		datanodePidDir := path.Join(d.workingDirs.PidsDir, dirName)
-		if err := fileutils.EnsureDir(datanodePidDir); err != nil {
-			return err
-		}
-		d.pidsDirs = append(d.pidsDirs, datanodePidDir)
+func (d *datanode) Scale(ctx context.Context, stop context.CancelFunc, binary string, replicas int) error {
+	if replicas < d.config.Replicas {
+		processes, err := stopReplicas(d.processes, replicas, d.workingDirs.PidsDir, d.logger)
+		d.processes = processes
+		d.config.Replicas = len(processes)
+		return err
+	}
 
-		walDir := path.Join(d.workingDirs.DataDir, dirName, dataWalDir)
-		if err := fileutils.EnsureDir(walDir); err != nil {
-			return err
+	if err := d.renderConfigFile(); err != nil {
+		return err
+	}
+
+	// The node id of new replicas is after the existing ones.
+	// The new replicas are stopped if any of them fails to start, the running ones are left as they are.
+	var (
+		oldReplicas = d.config.Replicas
+		guard       = newScaleGuard(stop)
+	)
+	err := func() error {
+		for i := d.config.Replicas; i < replicas; i++ {
+			if err := d.startReplica(ctx, guard.cancel, binary, i); err != nil {
+				return err
+			}
+			d.config.Replicas = i + 1
 		}
-		d.dataDirs = append(d.dataDirs, path.Join(d.workingDirs.DataDir, dirName))
-
-		option := &RunOptions{
-			Binary: binary,
-			Name:   dirName,
-			logDir: datanodeLogDir,
-			pidDir: datanodePidDir,
-			args:   d.BuildArgs(i, walDir, homeDir),
+		return d.WaitUntilReady(ctx)
+	}()
+	if err != nil {
+		d.processes, err = rollbackScale(d.processes, oldReplicas, d.workingDirs.PidsDir, d.logger, err)
+		d.config.Replicas = len(d.processes)
+		return err
+	}
+	guard.done()
+
+	return nil
+}
+
+func (d *datanode) startReplica(ctx context.Context, stop context.CancelFunc, binary string, nodeID int) error {
+	dirName := fmt.Sprintf("%s.%d", d.Name(), nodeID)
+
+	// The replica on remote host stores its data in the cluster directory of the host by default.
+	options := ReplicaProcessOptions(d.config.Process, nodeID)
+	host, err := d.hosts.replicaHost(options)
+	if err != nil {
+		return err
+	}
+	dataDir, err := d.hosts.DataDir(options.Host, d.workingDirs.DataDir)
+	if err != nil {
+		return err
+	}
+
+	dirs := DatanodeReplicaDirs(d.config, dataDir, nodeID)
+	for _, dir := range []string{dirs.DataHome, dirs.WalDir, dirs.ProcedureDir} {
+		if len(dir) == 0 {
+			continue
 		}
-		if err := runBinary(ctx, stop, option, d.wg, d.logger); err != nil {
+		if err := ensureDirs(host, dir); err != nil {
 			return err
 		}
 	}
+	d.dataHomeDirs = append(d.dataHomeDirs, dirs.DataHome)
+
+	datanodeLogDir := path.Join(d.workingDirs.LogsDir, dirName)
+	if err := fileutils.EnsureDir(datanodeLogDir); err != nil {
+		return err
+	}
+	d.logsDirs = append(d.logsDirs, datanodeLogDir)
 
-	// Checking component running status with intervals.
-	ticker := time.NewTicker(500 * time.Millisecond)
-	defer ticker.Stop()
+	datanodePidDir := path.Join(d.workingDirs.PidsDir, dirName)
+	if err := fileutils.EnsureDir(datanodePidDir); err != nil {
+		return err
+	}
+	d.pidsDirs = append(d.pidsDirs, datanodePidDir)
+	d.dataDirs = append(d.dataDirs, path.Join(d.workingDirs.DataDir, dirName))
 
-CHECKER:
-	for {
-		select {
-		case <-ticker.C:
-			if d.IsRunning(ctx) {
-				break CHECKER
-			}
-		case <-ctx.Done():
-			return fmt.Errorf("status checking failed: %v", ctx.Err())
-		}
+	option := &RunOptions{
+		Binary:    binary,
+		Name:      dirName,
+		logDir:    datanodeLogDir,
+		pidDir:    datanodePidDir,
+		log:       d.logConfig,
+		args:      d.BuildArgs(nodeID, dirs),
+		restart:   d.config.Restart,
+		cgroup:    replicaCgroup(d.workingDirs, dirName, d.config.Resources),
+		resources: d.config.Resources,
+		host:      host,
 	}
+	if err := option.withProcessOptions(options); err != nil {
+		return err
+	}
+	p, err := runBinary(ctx, stop, option, d.wg, d.logger)
+	if err != nil {
+		return err
+	}
+	d.processes = append(d.processes, p)
 
 	return nil
 }
 
+// DatanodeReplicaDirs returns the directories of the datanode replica. The replica stores data in the
+// directories of datanode config if they are set, otherwise in dataDir of the cluster.
+func DatanodeReplicaDirs(cfg *config.Datanode, dataDir string, nodeID int) config.DatanodeDirs {
+	dirName := fmt.Sprintf("%s.%d", greptimev1alpha1.DatanodeComponentKind, nodeID)
+
+	replicaDir := path.Join(dataDir, dirName)
+	if len(cfg.DataDir) > 0 {
+		replicaDir = path.Join(cfg.DataDir, dirName)
+	}
+
+	dirs := config.DatanodeDirs{
+		Name:     dirName,
+		DataHome: path.Join(replicaDir, dataHomeDir),
+		WalDir:   path.Join(replicaDir, dataWalDir),
+	}
+	if len(cfg.WalDir) > 0 {
+		dirs.WalDir = path.Join(cfg.WalDir, dirName)
+	}
+	if len(cfg.ProcedureDir) > 0 {
+		dirs.ProcedureDir = path.Join(cfg.ProcedureDir, dirName)
+	}
+
+	return dirs
+}
+
+// configFile returns the config file that datanode runs with, the options and storage are rendered into it.
+func (d *datanode) configFile() string {
+	generated := len(d.config.Options) > 0 || d.config.Storage != nil
+	return configFilePath(d.Name(), d.config.Config, generated, d.workingDirs.ConfigsDir)
+}
+
+// renderConfigFile renders the storage and options of datanode, the options override the storage options.
+func (d *datanode) renderConfigFile() error {
+	options := d.config.Options
+	if d.config.Storage != nil {
+		storage, err := storageOptions(d.config.Storage, os.Getenv)
+		if err != nil {
+			return fmt.Errorf("invalid storage of %s: %v", d.Name(), err)
+		}
+		mergeOptions(storage, options)
+		options = storage
+	}
+	return renderConfigFile(d.Name(), d.config.Config, options, d.workingDirs.ConfigsDir)
+}
+
+func (d *datanode) RestartReplica(ctx context.Context, stop context.CancelFunc, binary string, replica int) error {
+	return restartReplica(ctx, stop, d.processes, replica, binary, d.wg, d.logger)
+}
+
+func (d *datanode) Stop(timeout time.Duration) error {
+	return terminateReplicas(d.processes, timeout)
+}
+
 func (d *datanode) BuildArgs(params ...interface{}) []string {
 	logLevel := d.config.LogLevel
 	if logLevel == "" {
 		logLevel = DefaultLogLevel
 	}
 
-	nodeID_, _, homeDir := params[0], params[1], params[2]
-	nodeID := nodeID_.(int)
+	nodeID_, dirs_ := params[0], params[1]
+	nodeID, dirs := nodeID_.(int), dirs_.(config.DatanodeDirs)
 
 	args := []string{
 		fmt.Sprintf("--log-level=%s", logLevel),
 		d.Name(), "start",
 		fmt.Sprintf("--node-id=%d", nodeID),
 		fmt.Sprintf("--metasrv-addr=%s", d.metaSrvAddr),
-		fmt.Sprintf("--data-home=%s", homeDir),
+		fmt.Sprintf("--data-home=%s", dirs.DataHome),
+		fmt.Sprintf("--wal-dir=%s", dirs.WalDir),
+	}
+	if len(dirs.ProcedureDir) > 0 {
+		args = append(args, fmt.Sprintf("--procedure-dir=%s", dirs.ProcedureDir))
 	}
 	args = GenerateAddrArg("--http-addr", d.config.HTTPAddr, nodeID, args)
 	args = GenerateAddrArg("--rpc-addr", d.config.RPCAddr, nodeID, args)
 
-	if len(d.config.Config) > 0 {
-		args = append(args, fmt.Sprintf("-c=%s", d.config.Config))
+	if configFile := d.configFile(); len(configFile) > 0 {
+		args = append(args, fmt.Sprintf("-c=%s", configFile))
 	}
 
 	return args
 }
 
-func (d *datanode) IsRunning(_ context.Context) bool {
-	for i := 0; i < d.config.Replicas; i++ {
-		addr := FormatAddrArg(d.config.HTTPAddr, i)
-		_, httpPort, err := net.SplitHostPort(addr)
-		if err != nil {
-			d.logger.V(5).Infof("failed to split host port in %s: %s", d.Name(), err)
-			return false
-		}
-
-		rsp, err := http.Get(fmt.Sprintf("http://localhost:%s/health", httpPort))
-		if err != nil {
-			d.logger.V(5).Infof("failed to get %s health: %s", d.Name(), err)
-			return false
-		}
-
-		if rsp.StatusCode != http.StatusOK {
-			return false
-		}
+// IsRunning returns true if all the replicas of datanode are healthy.
+func (d *datanode) IsRunning(ctx context.Context) bool {
+	return isReplicasRunning(ctx, d.Name(), d.config.Replicas, d.checkReplica, d.logger)
+}
 
-		if err = rsp.Body.Close(); err != nil {
-			return false
-		}
-	}
+// WaitUntilReady waits for all the replicas of datanode to be healthy within its startup timeout.
+func (d *datanode) WaitUntilReady(ctx context.Context) error {
+	return waitUntilReady(ctx, d.Name(), d.processes, d.config.StartupTimeout, d.checkReplica)
+}
 
-	return true
+// checkReplica checks the '/health' endpoint of the replica.
+func (d *datanode) checkReplica(ctx context.Context, replica int) error {
+	return checkHTTPHealth(ctx, d.hosts.AdvertiseAddr(FormatAddrArg(d.config.HTTPAddr, replica),
+		ReplicaProcessOptions(d.config.Process, replica)))
 }
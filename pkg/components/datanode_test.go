@@ -0,0 +1,92 @@
+/*
+ * Copyright 2023 Greptime Team
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package components
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/GreptimeTeam/gtctl/pkg/config"
+)
+
+func TestDatanodeReplicaDirs(t *testing.T) {
+	tests := []struct {
+		name   string
+		config *config.Datanode
+		want   config.DatanodeDirs
+	}{
+		{
+			name:   "default",
+			config: &config.Datanode{},
+			want: config.DatanodeDirs{
+				Name:     "datanode.1",
+				DataHome: "/cluster/data/datanode.1/home",
+				WalDir:   "/cluster/data/datanode.1/wal",
+			},
+		},
+		{
+			name:   "data dir",
+			config: &config.Datanode{DataDir: "/mnt/data"},
+			want: config.DatanodeDirs{
+				Name:     "datanode.1",
+				DataHome: "/mnt/data/datanode.1/home",
+				WalDir:   "/mnt/data/datanode.1/wal",
+			},
+		},
+		{
+			name:   "all dirs",
+			config: &config.Datanode{DataDir: "/mnt/data", WalDir: "/mnt/wal/", ProcedureDir: "/mnt/procedure"},
+			want: config.DatanodeDirs{
+				Name:         "datanode.1",
+				DataHome:     "/mnt/data/datanode.1/home",
+				WalDir:       "/mnt/wal/datanode.1",
+				ProcedureDir: "/mnt/procedure/datanode.1",
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, DatanodeReplicaDirs(tt.config, "/cluster/data", 1))
+		})
+	}
+}
+
+func TestDatanodeBuildArgs(t *testing.T) {
+	d := &datanode{config: &config.Datanode{
+		RPCAddr:  "0.0.0.0:14100",
+		HTTPAddr: "0.0.0.0:14300",
+	}, metaSrvAddr: "127.0.0.1:3002"}
+
+	args := d.BuildArgs(0, config.DatanodeDirs{DataHome: "/data/home", WalDir: "/wal", ProcedureDir: "/procedure"})
+	assert.Contains(t, args, "--data-home=/data/home")
+	assert.Contains(t, args, "--wal-dir=/wal")
+	assert.Contains(t, args, "--procedure-dir=/procedure")
+
+	args = d.BuildArgs(0, config.DatanodeDirs{DataHome: "/data/home", WalDir: "/wal"})
+	for _, arg := range args {
+		assert.NotContains(t, arg, "--procedure-dir")
+	}
+
+	// The datanode runs with the generated config if it has options.
+	d.config.Config = "/etc/datanode.toml"
+	assert.Contains(t, d.BuildArgs(0, config.DatanodeDirs{}), "-c=/etc/datanode.toml")
+	d.config.Options = config.ConfigOptions{"storage": map[string]interface{}{"type": "S3"}}
+	d.workingDirs.ConfigsDir = "/cluster/configs"
+	assert.Contains(t, d.BuildArgs(0, config.DatanodeDirs{}), "-c=/cluster/configs/datanode.toml")
+}
@@ -18,24 +18,49 @@ package components
 
 import (
 	"context"
+	"encoding/json"
+	"fmt"
+	"net"
+	"net/http"
 	"path"
+	"strconv"
+	"strings"
 	"sync"
+	"time"
 
+	"github.com/GreptimeTeam/gtctl/pkg/config"
 	"github.com/GreptimeTeam/gtctl/pkg/logger"
 	fileutils "github.com/GreptimeTeam/gtctl/pkg/utils/file"
 )
 
+const (
+	defaultEtcdClientAddr = "127.0.0.1:2379"
+
+	// defaultEtcdHealthCheckTimeout is the default timeout of waiting for etcd to be healthy after it starts.
+	defaultEtcdHealthCheckTimeout = 10 * time.Second
+
+	// etcdHealthRequestTimeout is the timeout of each request to the health endpoint of etcd member.
+	etcdHealthRequestTimeout = 1 * time.Second
+)
+
 type etcd struct {
+	config *config.Etcd
+
 	workingDirs WorkingDirs
+	logConfig   *config.Log
 	wg          *sync.WaitGroup
 	logger      logger.Logger
 
 	allocatedDirs
+	processes []*process
 }
 
-func NewEtcd(workingDirs WorkingDirs, wg *sync.WaitGroup, logger logger.Logger) ClusterComponent {
+func NewEtcd(config *config.Etcd, workingDirs WorkingDirs, logConfig *config.Log,
+	wg *sync.WaitGroup, logger logger.Logger) ClusterComponent {
 	return &etcd{
+		config:      config,
 		workingDirs: workingDirs,
+		logConfig:   logConfig,
 		wg:          wg,
 		logger:      logger,
 	}
@@ -46,10 +71,21 @@ func (e *etcd) Name() string {
 }
 
 func (e *etcd) Start(ctx context.Context, stop context.CancelFunc, binary string) error {
+	for i := 0; i < EtcdReplicas(e.config); i++ {
+		if err := e.startMember(ctx, stop, binary, i); err != nil {
+			return err
+		}
+	}
+
+	return e.WaitUntilReady(ctx)
+}
+
+func (e *etcd) startMember(ctx context.Context, stop context.CancelFunc, binary string, memberID int) error {
 	var (
-		etcdDataDir = path.Join(e.workingDirs.DataDir, e.Name())
-		etcdLogDir  = path.Join(e.workingDirs.LogsDir, e.Name())
-		etcdPidDir  = path.Join(e.workingDirs.PidsDir, e.Name())
+		dirName     = fmt.Sprintf("%s.%d", e.Name(), memberID)
+		etcdDataDir = path.Join(e.workingDirs.DataDir, dirName)
+		etcdLogDir  = path.Join(e.workingDirs.LogsDir, dirName)
+		etcdPidDir  = path.Join(e.workingDirs.PidsDir, dirName)
 		etcdDirs    = []string{etcdDataDir, etcdLogDir, etcdPidDir}
 	)
 	for _, dir := range etcdDirs {
@@ -63,23 +99,141 @@ func (e *etcd) Start(ctx context.Context, stop context.CancelFunc, binary string
 
 	option := &RunOptions{
 		Binary: binary,
-		Name:   e.Name(),
+		Name:   dirName,
 		logDir: etcdLogDir,
 		pidDir: etcdPidDir,
-		args:   e.BuildArgs(etcdDataDir),
+		log:    e.logConfig,
+		args:   e.BuildArgs(memberID, etcdDataDir),
 	}
-	if err := runBinary(ctx, stop, option, e.wg, e.logger); err != nil {
+	p, err := runBinary(ctx, stop, option, e.wg, e.logger)
+	if err != nil {
 		return err
 	}
+	e.processes = append(e.processes, p)
 
 	return nil
 }
 
+func (e *etcd) Scale(_ context.Context, _ context.CancelFunc, _ string, _ int) error {
+	return fmt.Errorf("scaling %s is not supported", e.Name())
+}
+
+func (e *etcd) RestartReplica(ctx context.Context, stop context.CancelFunc, binary string, replica int) error {
+	return restartReplica(ctx, stop, e.processes, replica, binary, e.wg, e.logger)
+}
+
+func (e *etcd) Stop(timeout time.Duration) error {
+	return terminateReplicas(e.processes, timeout)
+}
+
 func (e *etcd) BuildArgs(params ...interface{}) []string {
-	return []string{"--data-dir", params[0].(string)}
+	memberID_, dataDir_ := params[0], params[1]
+	memberID := memberID_.(int)
+	dataDir := dataDir_.(string)
+
+	var initialCluster []string
+	for i := 0; i < EtcdReplicas(e.config); i++ {
+		initialCluster = append(initialCluster, fmt.Sprintf("%s.%d=http://%s", e.Name(), i, AdvertiseAddr(EtcdPeerAddr(e.config, i))))
+	}
+
+	clientAddr := EtcdClientAddr(e.config, memberID)
+	peerAddr := EtcdPeerAddr(e.config, memberID)
+	return []string{
+		"--name", fmt.Sprintf("%s.%d", e.Name(), memberID),
+		"--data-dir", dataDir,
+		"--listen-client-urls", fmt.Sprintf("http://%s", clientAddr),
+		"--advertise-client-urls", fmt.Sprintf("http://%s", AdvertiseAddr(clientAddr)),
+		"--listen-peer-urls", fmt.Sprintf("http://%s", peerAddr),
+		"--initial-advertise-peer-urls", fmt.Sprintf("http://%s", AdvertiseAddr(peerAddr)),
+		"--initial-cluster", strings.Join(initialCluster, ","),
+		"--initial-cluster-state", "new",
+	}
 }
 
-func (e *etcd) IsRunning(_ context.Context) bool {
-	// Have not implemented the healthy checker now.
-	return false
+// IsRunning checks the '/health' endpoint of all the etcd members,
+// the member is healthy only if it's connected to the cluster and the cluster has a leader.
+func (e *etcd) IsRunning(ctx context.Context) bool {
+	return isReplicasRunning(ctx, e.Name(), EtcdReplicas(e.config), e.checkMember, e.logger)
+}
+
+// WaitUntilReady waits for all the etcd members to be healthy within the health check timeout.
+func (e *etcd) WaitUntilReady(ctx context.Context) error {
+	timeout := e.config.HealthCheckTimeout
+	if timeout <= 0 {
+		timeout = defaultEtcdHealthCheckTimeout
+	}
+	return waitUntilReady(ctx, e.Name(), e.processes, timeout, e.checkMember)
+}
+
+func (e *etcd) checkMember(ctx context.Context, memberID int) error {
+	client := &http.Client{Timeout: etcdHealthRequestTimeout}
+	return checkEtcdHealth(ctx, client, AdvertiseAddr(EtcdClientAddr(e.config, memberID)))
+}
+
+// etcdHealth is the response of etcd '/health' endpoint, e.g. {"health":"true","reason":""}.
+type etcdHealth struct {
+	Health string `json:"health"`
+	Reason string `json:"reason"`
+}
+
+func checkEtcdHealth(ctx context.Context, client *http.Client, endpoint string) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, fmt.Sprintf("http://%s/health", endpoint), nil)
+	if err != nil {
+		return err
+	}
+
+	rsp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer rsp.Body.Close()
+
+	// The body is still decoded when the status is not ok, it contains the reason.
+	var health etcdHealth
+	if err = json.NewDecoder(rsp.Body).Decode(&health); err != nil {
+		return fmt.Errorf("invalid health response with status '%s': %v", rsp.Status, err)
+	}
+	if rsp.StatusCode != http.StatusOK || health.Health != "true" {
+		return fmt.Errorf("status: '%s', health: '%s', reason: '%s'", rsp.Status, health.Health, health.Reason)
+	}
+
+	return nil
+}
+
+// EtcdClientEndpoints returns the client endpoints of all the etcd members, which can be used by metasrv.
+func EtcdClientEndpoints(config *config.Etcd) []string {
+	var endpoints []string
+	for i := 0; i < EtcdReplicas(config); i++ {
+		endpoints = append(endpoints, AdvertiseAddr(EtcdClientAddr(config, i)))
+	}
+	return endpoints
+}
+
+// EtcdReplicas returns the number of etcd members, it's 1 if not specified.
+func EtcdReplicas(config *config.Etcd) int {
+	if config.Replicas <= 0 {
+		return 1
+	}
+	return config.Replicas
+}
+
+// EtcdClientAddr returns the address that the member listens on for client traffic.
+func EtcdClientAddr(config *config.Etcd, memberID int) string {
+	addr := config.ClientAddr
+	if len(addr) == 0 {
+		addr = defaultEtcdClientAddr
+	}
+	return FormatAddrArg(addr, memberID)
+}
+
+// EtcdPeerAddr returns the peer address of the member. The peer addresses are right after
+// the client addresses of all members by default, so they won't conflict with each other.
+func EtcdPeerAddr(config *config.Etcd, memberID int) string {
+	if len(config.PeerAddr) > 0 {
+		return FormatAddrArg(config.PeerAddr, memberID)
+	}
+
+	host, port, _ := net.SplitHostPort(EtcdClientAddr(config, 0))
+	portInt, _ := strconv.Atoi(port)
+	return net.JoinHostPort(host, strconv.Itoa(portInt+EtcdReplicas(config)+memberID))
 }
@@ -0,0 +1,129 @@
+/*
+ * Copyright 2023 Greptime Team
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package components
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/GreptimeTeam/gtctl/pkg/config"
+)
+
+func TestCheckEtcdHealth(t *testing.T) {
+	tests := []struct {
+		name    string
+		status  int
+		body    string
+		wantErr bool
+	}{
+		{name: "healthy", status: http.StatusOK, body: `{"health":"true","reason":""}`},
+		{name: "no leader", status: http.StatusServiceUnavailable, body: `{"health":"false","reason":"RAFT NO LEADER"}`, wantErr: true},
+		{name: "unhealthy", status: http.StatusOK, body: `{"health":"false","reason":""}`, wantErr: true},
+		{name: "invalid body", status: http.StatusOK, body: `ok`, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				assert.Equal(t, "/health", r.URL.Path)
+				w.WriteHeader(tt.status)
+				fmt.Fprint(w, tt.body)
+			}))
+			defer server.Close()
+
+			err := checkEtcdHealth(context.Background(), server.Client(), strings.TrimPrefix(server.URL, "http://"))
+			if tt.wantErr {
+				assert.Error(t, err)
+			} else {
+				assert.NoError(t, err)
+			}
+		})
+	}
+}
+
+func TestEtcdBuildArgs(t *testing.T) {
+	tests := []struct {
+		name       string
+		config     *config.Etcd
+		member     int
+		want       []string
+		wantClient []string
+	}{
+		{
+			name:   "single member",
+			config: &config.Etcd{},
+			want: []string{
+				"--name", "etcd.0",
+				"--data-dir", "/data/etcd.0",
+				"--listen-client-urls", "http://127.0.0.1:2379",
+				"--advertise-client-urls", "http://127.0.0.1:2379",
+				"--listen-peer-urls", "http://127.0.0.1:2380",
+				"--initial-advertise-peer-urls", "http://127.0.0.1:2380",
+				"--initial-cluster", "etcd.0=http://127.0.0.1:2380",
+				"--initial-cluster-state", "new",
+			},
+			wantClient: []string{"127.0.0.1:2379"},
+		},
+		{
+			// The peer ports are right after the client ports of all members.
+			name:   "three members",
+			config: &config.Etcd{Replicas: 3, ClientAddr: "0.0.0.0:2379"},
+			member: 1,
+			want: []string{
+				"--name", "etcd.1",
+				"--data-dir", "/data/etcd.1",
+				"--listen-client-urls", "http://0.0.0.0:2380",
+				"--advertise-client-urls", "http://127.0.0.1:2380",
+				"--listen-peer-urls", "http://0.0.0.0:2383",
+				"--initial-advertise-peer-urls", "http://127.0.0.1:2383",
+				"--initial-cluster", "etcd.0=http://127.0.0.1:2382,etcd.1=http://127.0.0.1:2383,etcd.2=http://127.0.0.1:2384",
+				"--initial-cluster-state", "new",
+			},
+			wantClient: []string{"127.0.0.1:2379", "127.0.0.1:2380", "127.0.0.1:2381"},
+		},
+		{
+			name:   "three members with peer addr",
+			config: &config.Etcd{Replicas: 3, ClientAddr: "127.0.0.1:12379", PeerAddr: "127.0.0.1:12480"},
+			member: 2,
+			want: []string{
+				"--name", "etcd.2",
+				"--data-dir", "/data/etcd.2",
+				"--listen-client-urls", "http://127.0.0.1:12381",
+				"--advertise-client-urls", "http://127.0.0.1:12381",
+				"--listen-peer-urls", "http://127.0.0.1:12482",
+				"--initial-advertise-peer-urls", "http://127.0.0.1:12482",
+				"--initial-cluster", "etcd.0=http://127.0.0.1:12480,etcd.1=http://127.0.0.1:12481,etcd.2=http://127.0.0.1:12482",
+				"--initial-cluster-state", "new",
+			},
+			wantClient: []string{"127.0.0.1:12379", "127.0.0.1:12380", "127.0.0.1:12381"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			e := &etcd{config: tt.config}
+			assert.Equal(t, tt.want, e.BuildArgs(tt.member, fmt.Sprintf("/data/etcd.%d", tt.member)))
+			assert.Equal(t, tt.wantClient, EtcdClientEndpoints(tt.config))
+		})
+	}
+}
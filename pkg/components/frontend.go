@@ -19,9 +19,9 @@ package components
 import (
 	"context"
 	"fmt"
-	"net/http"
 	"path"
 	"sync"
+	"time"
 
 	greptimedbclusterv1alpha1 "github.com/GreptimeTeam/greptimedb-operator/apis/v1alpha1"
 
@@ -30,23 +30,33 @@ import (
 	fileutils "github.com/GreptimeTeam/gtctl/pkg/utils/file"
 )
 
+// DefaultFrontendHTTPAddr is the default http address that frontend listens on.
+const DefaultFrontendHTTPAddr = "127.0.0.1:4000"
+
 type frontend struct {
 	config      *config.Frontend
 	metaSrvAddr string
+	tls         *config.TLS
 
 	workingDirs WorkingDirs
+	logConfig   *config.Log
+	hosts       RemoteHosts
 	wg          *sync.WaitGroup
 	logger      logger.Logger
 
 	allocatedDirs
+	processes []*process
 }
 
-func NewFrontend(config *config.Frontend, metaSrvAddr string, workingDirs WorkingDirs,
-	wg *sync.WaitGroup, logger logger.Logger) ClusterComponent {
+func NewFrontend(config *config.Frontend, metaSrvAddr string, tls *config.TLS, workingDirs WorkingDirs,
+	logConfig *config.Log, hosts RemoteHosts, wg *sync.WaitGroup, logger logger.Logger) ClusterComponent {
 	return &frontend{
 		config:      config,
 		metaSrvAddr: metaSrvAddr,
+		tls:         tls,
 		workingDirs: workingDirs,
+		logConfig:   logConfig,
+		hosts:       hosts,
 		wg:          wg,
 		logger:      logger,
 	}
@@ -57,36 +67,119 @@ func (f *frontend) Name() string {
 }
 
 func (f *frontend) Start(ctx context.Context, stop context.CancelFunc, binary string) error {
-	for i := 0; i < f.config.Replicas; i++ {
-		dirName := fmt.Sprintf("%s.%d", f.Name(), i)
+	if err := f.renderConfigFile(); err != nil {
+		return err
+	}
 
-		frontendLogDir := path.Join(f.workingDirs.LogsDir, dirName)
-		if err := fileutils.EnsureDir(frontendLogDir); err != nil {
+	for i := 0; i < f.config.Replicas; i++ {
+		if err := f.startReplica(ctx, stop, binary, i); err != nil {
 			return err
 		}
-		f.logsDirs = append(f.logsDirs, frontendLogDir)
+	}
 
-		frontendPidDir := path.Join(f.workingDirs.PidsDir, dirName)
-		if err := fileutils.EnsureDir(frontendPidDir); err != nil {
-			return err
-		}
-		f.pidsDirs = append(f.pidsDirs, frontendPidDir)
-
-		option := &RunOptions{
-			Binary: binary,
-			Name:   dirName,
-			logDir: frontendLogDir,
-			pidDir: frontendPidDir,
-			args:   f.BuildArgs(i),
-		}
-		if err := runBinary(ctx, stop, option, f.wg, f.logger); err != nil {
-			return err
+	return f.WaitUntilReady(ctx)
+}
+
+func (f *frontend) Scale(ctx context.Context, stop context.CancelFunc, binary string, replicas int) error {
+	if replicas < f.config.Replicas {
+		processes, err := stopReplicas(f.processes, replicas, f.workingDirs.PidsDir, f.logger)
+		f.processes = processes
+		f.config.Replicas = len(processes)
+		return err
+	}
+
+	if err := f.renderConfigFile(); err != nil {
+		return err
+	}
+
+	// The new replicas are stopped if any of them fails to start, the running ones are left as they are.
+	var (
+		oldReplicas = f.config.Replicas
+		guard       = newScaleGuard(stop)
+	)
+	err := func() error {
+		for i := f.config.Replicas; i < replicas; i++ {
+			if err := f.startReplica(ctx, guard.cancel, binary, i); err != nil {
+				return err
+			}
+			f.config.Replicas = i + 1
 		}
+		return f.WaitUntilReady(ctx)
+	}()
+	if err != nil {
+		f.processes, err = rollbackScale(f.processes, oldReplicas, f.workingDirs.PidsDir, f.logger, err)
+		f.config.Replicas = len(f.processes)
+		return err
 	}
+	guard.done()
 
 	return nil
 }
 
+func (f *frontend) startReplica(ctx context.Context, stop context.CancelFunc, binary string, nodeID int) error {
+	dirName := fmt.Sprintf("%s.%d", f.Name(), nodeID)
+
+	frontendLogDir := path.Join(f.workingDirs.LogsDir, dirName)
+	if err := fileutils.EnsureDir(frontendLogDir); err != nil {
+		return err
+	}
+	f.logsDirs = append(f.logsDirs, frontendLogDir)
+
+	frontendPidDir := path.Join(f.workingDirs.PidsDir, dirName)
+	if err := fileutils.EnsureDir(frontendPidDir); err != nil {
+		return err
+	}
+	f.pidsDirs = append(f.pidsDirs, frontendPidDir)
+
+	options := ReplicaProcessOptions(f.config.Process, nodeID)
+	host, err := f.hosts.replicaHost(options)
+	if err != nil {
+		return err
+	}
+
+	option := &RunOptions{
+		Binary:    binary,
+		Name:      dirName,
+		logDir:    frontendLogDir,
+		pidDir:    frontendPidDir,
+		log:       f.logConfig,
+		args:      f.BuildArgs(nodeID),
+		restart:   f.config.Restart,
+		cgroup:    replicaCgroup(f.workingDirs, dirName, f.config.Resources),
+		resources: f.config.Resources,
+		host:      host,
+	}
+	if err := option.withProcessOptions(options); err != nil {
+		return err
+	}
+	p, err := runBinary(ctx, stop, option, f.wg, f.logger)
+	if err != nil {
+		return err
+	}
+	f.processes = append(f.processes, p)
+
+	return nil
+}
+
+// configFile returns the config file that frontend runs with, the options and TLS are rendered into it.
+func (f *frontend) configFile() string {
+	generated := len(f.config.Options) > 0 || f.tls.IsEnabled()
+	return configFilePath(f.Name(), f.config.Config, generated, f.workingDirs.ConfigsDir)
+}
+
+func (f *frontend) renderConfigFile() error {
+	options := serverOptions(f.Name(), f.config.Options, f.tls, f.workingDirs.CertsDir)
+	return renderConfigFile(f.Name(), f.config.Config, options, f.workingDirs.ConfigsDir)
+}
+
+func (f *frontend) RestartReplica(ctx context.Context, stop context.CancelFunc, binary string, replica int) error {
+	return restartReplica(ctx, stop, f.processes, replica, binary, f.wg, f.logger)
+}
+
+func (f *frontend) Stop(timeout time.Duration) error {
+	return terminateReplicas(f.processes, timeout)
+}
+
 func (f *frontend) BuildArgs(params ...interface{}) []string {
 	logLevel := f.config.LogLevel
 	if logLevel == "" {
@@ -106,35 +199,29 @@ func (f *frontend) BuildArgs(params ...interface{}) []string {
 	args = GenerateAddrArg("--mysql-addr", f.config.MysqlAddr, nodeId, args)
 	args = GenerateAddrArg("--postgres-addr", f.config.PostgresAddr, nodeId, args)
 
-	if len(f.config.Config) > 0 {
-		args = append(args, fmt.Sprintf("-c=%s", f.config.Config))
+	if configFile := f.configFile(); len(configFile) > 0 {
+		args = append(args, fmt.Sprintf("-c=%s", configFile))
 	}
-	if len(f.config.UserProvider) > 0 {
-		args = append(args, fmt.Sprintf("--user-provider=%s", f.config.UserProvider))
-	}
-	return args
+	return GenerateUserProviderArg(f.config.UserProvider, f.workingDirs.UsersFile, args)
 }
 
-func (f *frontend) IsRunning(_ context.Context) bool {
-	for i := 0; i < f.config.Replicas; i++ {
-		addr := FormatAddrArg(f.config.HTTPAddr, i)
-		healthy := fmt.Sprintf("http://%s/health", addr)
-
-		resp, err := http.Get(healthy)
-		if err != nil {
-			f.logger.V(5).Infof("Failed to get %s healthy: %s", f.Name(), err)
-			return false
-		}
+// IsRunning returns true if all the replicas of frontend are healthy.
+func (f *frontend) IsRunning(ctx context.Context) bool {
+	return isReplicasRunning(ctx, f.Name(), f.config.Replicas, f.checkReplica, f.logger)
+}
 
-		if resp.StatusCode != http.StatusOK {
-			f.logger.V(5).Infof("%s is not healthy: %s", f.Name(), resp)
-			return false
-		}
+// WaitUntilReady waits for all the replicas of frontend to be healthy within its startup timeout.
+func (f *frontend) WaitUntilReady(ctx context.Context) error {
+	return waitUntilReady(ctx, f.Name(), f.processes, f.config.StartupTimeout, f.checkReplica)
+}
 
-		if err = resp.Body.Close(); err != nil {
-			f.logger.V(5).Infof("%s is not healthy: %s, err: %s", f.Name(), resp, err)
-			return false
-		}
+// checkReplica checks the '/health' endpoint of the replica.
+func (f *frontend) checkReplica(ctx context.Context, replica int) error {
+	// Frontend listens on the default http address if it's not specified.
+	httpAddr := f.config.HTTPAddr
+	if len(httpAddr) == 0 {
+		httpAddr = DefaultFrontendHTTPAddr
 	}
-	return true
+	return checkHTTPHealth(ctx, f.hosts.AdvertiseAddr(FormatAddrArg(httpAddr, replica),
+		ReplicaProcessOptions(f.config.Process, replica)))
 }
@@ -0,0 +1,174 @@
+/*
+ * Copyright 2023 Greptime Team
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+// Package greptimetest provides a fake greptime binary for testing the bare-metal components.
+// The fake binary runs the test binary itself, which serves '/health' on its '--http-addr' like greptime does,
+// so the tests of package that uses it must be run by Main.
+package greptimetest
+
+import (
+	"fmt"
+	"net"
+	"net/http"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"strings"
+	"syscall"
+	"testing"
+)
+
+const (
+	envFake       = "GREPTIMETEST_FAKE"
+	envFailArg    = "GREPTIMETEST_FAIL_ARG"
+	envExitArg    = "GREPTIMETEST_EXIT_ARG"
+	envIgnoreTerm = "GREPTIMETEST_IGNORE_TERM"
+)
+
+// Options are the behaviors of the fake binary.
+type Options struct {
+	// FailArg makes the binary exit with error at once if any of its args contains it, e.g. '--node-id=1'.
+	FailArg string
+
+	// ExitArg makes the binary exit without error at once if any of its args contains it.
+	ExitArg string
+
+	// IgnoreTerm makes the binary ignore SIGTERM, so it only exits when it's killed.
+	IgnoreTerm bool
+}
+
+// Main runs the fake binary if the test binary is started as it, otherwise it runs the tests.
+// It should be called by TestMain of the package.
+func Main(m *testing.M) {
+	if os.Getenv(envFake) == "1" {
+		os.Exit(run(os.Args[1:]))
+	}
+	os.Exit(m.Run())
+}
+
+// NewBinary writes the fake binary to a temporary directory of the test and returns its path.
+func NewBinary(t *testing.T, options Options) string {
+	t.Helper()
+
+	self, err := os.Executable()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	env := []string{envFake + "=1"}
+	if len(options.FailArg) > 0 {
+		env = append(env, envFailArg+"="+quote(options.FailArg))
+	}
+	if len(options.ExitArg) > 0 {
+		env = append(env, envExitArg+"="+quote(options.ExitArg))
+	}
+	if options.IgnoreTerm {
+		env = append(env, envIgnoreTerm+"=1")
+	}
+
+	// The binary prints its own path, so the tests can tell which binary the replica runs with. The shell replaces
+	// itself with the test binary, so the pid of the binary is the one of the started process.
+	script := fmt.Sprintf("#!/bin/sh\necho \"binary: $0\"\n%s exec %s \"$@\"\n", strings.Join(env, " "), quote(self))
+	binary := filepath.Join(t.TempDir(), "greptime")
+	if err = os.WriteFile(binary, []byte(script), 0755); err != nil {
+		t.Fatal(err)
+	}
+	return binary
+}
+
+// FreeAddr returns a local address whose port and the next n-1 ones are free, so that n replicas can listen on them.
+func FreeAddr(t *testing.T, n int) string {
+	t.Helper()
+
+	for attempt := 0; attempt < 100; attempt++ {
+		listener, err := net.Listen("tcp", "127.0.0.1:0")
+		if err != nil {
+			t.Fatal(err)
+		}
+		addr := listener.Addr().(*net.TCPAddr)
+
+		listeners := []net.Listener{listener}
+		for i := 1; i < n; i++ {
+			l, err := net.Listen("tcp", (&net.TCPAddr{IP: addr.IP, Port: addr.Port + i}).String())
+			if err != nil {
+				break
+			}
+			listeners = append(listeners, l)
+		}
+		for _, l := range listeners {
+			_ = l.Close()
+		}
+		if len(listeners) == n {
+			return addr.String()
+		}
+	}
+
+	t.Fatalf("no %d free ports in a row", n)
+	return ""
+}
+
+// run is the fake binary, it prints its args and serves '/health' until it's terminated.
+// The signals are handled before printing the args, so the binary is ready for them once its args are printed.
+func run(args []string) int {
+	signals := make(chan os.Signal, 1)
+	if os.Getenv(envIgnoreTerm) == "1" {
+		signal.Ignore(syscall.SIGTERM)
+	} else {
+		signal.Notify(signals, syscall.SIGTERM)
+	}
+
+	fmt.Printf("args: %s\n", strings.Join(args, " "))
+
+	if failArg := os.Getenv(envFailArg); len(failArg) > 0 {
+		for _, arg := range args {
+			if strings.Contains(arg, failArg) {
+				fmt.Printf("failed by arg '%s'\n", arg)
+				return 1
+			}
+		}
+	}
+	if exitArg := os.Getenv(envExitArg); len(exitArg) > 0 {
+		for _, arg := range args {
+			if strings.Contains(arg, exitArg) {
+				fmt.Printf("exited by arg '%s'\n", arg)
+				return 0
+			}
+		}
+	}
+
+	for _, arg := range args {
+		if !strings.HasPrefix(arg, "--http-addr=") {
+			continue
+		}
+		listener, err := net.Listen("tcp", strings.TrimPrefix(arg, "--http-addr="))
+		if err != nil {
+			fmt.Printf("failed to listen: %v\n", err)
+			return 1
+		}
+		mux := http.NewServeMux()
+		mux.HandleFunc("/health", func(http.ResponseWriter, *http.Request) {})
+		go func() { _ = http.Serve(listener, mux) }()
+	}
+
+	<-signals
+	fmt.Println("terminated")
+	return 0
+}
+
+// quote quotes the string for shell by single quotes.
+func quote(s string) string {
+	return "'" + strings.ReplaceAll(s, "'", `'"'"'`) + "'"
+}
@@ -0,0 +1,263 @@
+/*
+ * Copyright 2023 Greptime Team
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package components
+
+import (
+	"bytes"
+	"compress/gzip"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/GreptimeTeam/gtctl/pkg/config"
+	"github.com/GreptimeTeam/gtctl/pkg/logger"
+)
+
+const (
+	// defaultLogMaxSize is the default max size in megabytes of the log file before it's rotated.
+	defaultLogMaxSize = 100
+
+	// defaultLogMaxBackups is the default number of the compressed backups that are kept.
+	defaultLogMaxBackups = 5
+
+	// maxLogLineSize is the max size of the buffered partial line, it's written even without the line ending.
+	maxLogLineSize = 64 * 1024
+
+	// logBackupTimeFormat is the time format in the name of backups, which keeps the backups sorted by name.
+	logBackupTimeFormat = "2006-01-02T15-04-05.000"
+)
+
+// logWriter writes the output of component into its log file line by line,
+// the log file is rotated when its size or age exceeds the limit, and the rotated ones
+// are compressed as backups like 'log.2006-01-02T15-04-05.000.gz'.
+// The writer is shared by the restarts of the replica, so the logs are appended instead of truncated.
+type logWriter struct {
+	filename   string
+	maxSize    int64
+	maxAge     time.Duration
+	maxBackups int
+
+	mu       sync.Mutex
+	file     *os.File
+	size     int64
+	openedAt time.Time
+	// line is the partial line that is waiting for its line ending.
+	line []byte
+
+	// compressing waits for the rotated files to be compressed.
+	compressing sync.WaitGroup
+	compressMu  sync.Mutex
+
+	logger logger.Logger
+	// now is used for testing.
+	now func() time.Time
+}
+
+func newLogWriter(filename string, logConfig *config.Log, logger logger.Logger) (*logWriter, error) {
+	w := &logWriter{
+		filename:   filename,
+		logger:     logger,
+		maxSize:    defaultLogMaxSize * 1024 * 1024,
+		maxBackups: defaultLogMaxBackups,
+		now:        time.Now,
+	}
+	if logConfig != nil {
+		if logConfig.MaxSize > 0 {
+			w.maxSize = int64(logConfig.MaxSize) * 1024 * 1024
+		}
+		if logConfig.MaxBackups > 0 {
+			w.maxBackups = logConfig.MaxBackups
+		}
+		w.maxAge = logConfig.MaxAge
+	}
+
+	if err := w.open(); err != nil {
+		return nil, err
+	}
+	return w, nil
+}
+
+// Write buffers the partial line and writes the complete lines into the log file immediately.
+func (w *logWriter) Write(p []byte) (int, error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	w.line = append(w.line, p...)
+	end := bytes.LastIndexByte(w.line, '\n')
+	if end < 0 {
+		if len(w.line) < maxLogLineSize {
+			return len(p), nil
+		}
+		end = len(w.line) - 1
+	}
+
+	if err := w.write(w.line[:end+1]); err != nil {
+		return 0, err
+	}
+	w.line = append(w.line[:0], w.line[end+1:]...)
+
+	return len(p), nil
+}
+
+// Close writes the remaining partial line, closes the log file and waits for the backups to be compressed.
+func (w *logWriter) Close() error {
+	w.mu.Lock()
+	var err error
+	if len(w.line) > 0 {
+		err = w.write(w.line)
+		w.line = nil
+	}
+	if closeErr := w.file.Close(); err == nil {
+		err = closeErr
+	}
+	w.mu.Unlock()
+
+	w.compressing.Wait()
+	return err
+}
+
+func (w *logWriter) write(p []byte) error {
+	if w.shouldRotate(len(p)) {
+		if err := w.rotate(); err != nil {
+			return err
+		}
+	}
+
+	n, err := w.file.Write(p)
+	w.size += int64(n)
+	return err
+}
+
+func (w *logWriter) shouldRotate(size int) bool {
+	if w.size == 0 {
+		return false
+	}
+	if w.size+int64(size) > w.maxSize {
+		return true
+	}
+	return w.maxAge > 0 && w.now().Sub(w.openedAt) >= w.maxAge
+}
+
+// open opens the log file in append mode.
+func (w *logWriter) open() error {
+	file, err := os.OpenFile(w.filename, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0644)
+	if err != nil {
+		return err
+	}
+	info, err := file.Stat()
+	if err != nil {
+		file.Close()
+		return err
+	}
+
+	w.file = file
+	w.size = info.Size()
+	w.openedAt = w.now()
+	return nil
+}
+
+// rotate renames current log file as backup and opens a new one, the backup is compressed in background.
+func (w *logWriter) rotate() error {
+	if err := w.file.Close(); err != nil {
+		return err
+	}
+
+	backup := fmt.Sprintf("%s.%s", w.filename, w.now().Format(logBackupTimeFormat))
+	if err := os.Rename(w.filename, backup); err != nil {
+		return err
+	}
+	if err := w.open(); err != nil {
+		return err
+	}
+
+	w.compressing.Add(1)
+	go func() {
+		defer w.compressing.Done()
+
+		w.compressMu.Lock()
+		defer w.compressMu.Unlock()
+
+		// The backup is kept uncompressed if it fails.
+		if err := compressFile(backup); err != nil {
+			w.logger.Warnf("failed to compress log backup '%s': %v", backup, err)
+			return
+		}
+		if err := w.removeStaleBackups(); err != nil {
+			w.logger.Warnf("failed to remove stale log backups of '%s': %v", w.filename, err)
+		}
+	}()
+
+	return nil
+}
+
+// removeStaleBackups removes the oldest compressed backups that exceeds the max backups.
+func (w *logWriter) removeStaleBackups() error {
+	backups, err := filepath.Glob(fmt.Sprintf("%s.*.gz", w.filename))
+	if err != nil {
+		return err
+	}
+	if len(backups) <= w.maxBackups {
+		return nil
+	}
+
+	sort.Strings(backups)
+	for _, backup := range backups[:len(backups)-w.maxBackups] {
+		if err = os.Remove(backup); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// compressFile compresses the file into 'filename.gz' and removes the original one.
+func compressFile(filename string) error {
+	src, err := os.Open(filename)
+	if err != nil {
+		return err
+	}
+	defer src.Close()
+
+	// Write into a temporary file first, so the incomplete one won't be taken as a backup.
+	tmp := fmt.Sprintf("%s.gz.tmp", filename)
+	dst, err := os.OpenFile(tmp, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0644)
+	if err != nil {
+		return err
+	}
+
+	gz := gzip.NewWriter(dst)
+	gz.Name = path.Base(filename)
+	if _, err = io.Copy(gz, src); err == nil {
+		err = gz.Close()
+	}
+	if closeErr := dst.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		os.Remove(tmp)
+		return err
+	}
+
+	if err = os.Rename(tmp, fmt.Sprintf("%s.gz", filename)); err != nil {
+		return err
+	}
+	return os.Remove(filename)
+}
@@ -0,0 +1,142 @@
+/*
+ * Copyright 2023 Greptime Team
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package components
+
+import (
+	"compress/gzip"
+	"io"
+	"os"
+	"path"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/GreptimeTeam/gtctl/pkg/config"
+	"github.com/GreptimeTeam/gtctl/pkg/logger"
+)
+
+func newTestLogWriter(t *testing.T, logConfig *config.Log) (*logWriter, string) {
+	filename := path.Join(t.TempDir(), "log")
+	w, err := newLogWriter(filename, logConfig, logger.New(io.Discard, 0))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return w, filename
+}
+
+func readFile(t *testing.T, filename string) string {
+	data, err := os.ReadFile(filename)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return string(data)
+}
+
+func readGzipFile(t *testing.T, filename string) string {
+	f, err := os.Open(filename)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+
+	r, err := gzip.NewReader(f)
+	if err != nil {
+		t.Fatal(err)
+	}
+	data, err := io.ReadAll(r)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return string(data)
+}
+
+func TestLogWriterFlushLines(t *testing.T) {
+	w, filename := newTestLogWriter(t, nil)
+
+	_, err := w.Write([]byte("line 1\nline"))
+	assert.NoError(t, err)
+	// The complete lines are written immediately, and the partial line waits for its ending.
+	assert.Equal(t, "line 1\n", readFile(t, filename))
+
+	_, err = w.Write([]byte(" 2\n"))
+	assert.NoError(t, err)
+	assert.Equal(t, "line 1\nline 2\n", readFile(t, filename))
+
+	_, err = w.Write([]byte("line 3"))
+	assert.NoError(t, err)
+	assert.NoError(t, w.Close())
+	assert.Equal(t, "line 1\nline 2\nline 3", readFile(t, filename))
+
+	// The log is appended when it's opened again.
+	w, err = newLogWriter(filename, nil, logger.New(io.Discard, 0))
+	assert.NoError(t, err)
+	_, err = w.Write([]byte("\nline 4\n"))
+	assert.NoError(t, err)
+	assert.NoError(t, w.Close())
+	assert.Equal(t, "line 1\nline 2\nline 3\nline 4\n", readFile(t, filename))
+}
+
+func TestLogWriterRotate(t *testing.T) {
+	w, filename := newTestLogWriter(t, &config.Log{MaxBackups: 2})
+	w.maxSize = 10
+
+	now := time.Date(2023, 10, 1, 0, 0, 0, 0, time.UTC)
+	w.now = func() time.Time {
+		now = now.Add(time.Second)
+		return now
+	}
+
+	for _, line := range []string{"line 1\n", "line 2\n", "line 3\n", "line 4\n"} {
+		_, err := w.Write([]byte(line))
+		assert.NoError(t, err)
+	}
+	assert.NoError(t, w.Close())
+
+	// Each line exceeds the max size with the previous one, only the latest 2 backups are kept.
+	assert.Equal(t, "line 4\n", readFile(t, filename))
+	backups, err := filepath.Glob(filename + ".*")
+	assert.NoError(t, err)
+	assert.Len(t, backups, 2)
+	assert.Equal(t, "line 2\n", readGzipFile(t, backups[0]))
+	assert.Equal(t, "line 3\n", readGzipFile(t, backups[1]))
+}
+
+func TestLogWriterRotateByAge(t *testing.T) {
+	w, filename := newTestLogWriter(t, &config.Log{MaxAge: time.Hour})
+
+	now := time.Now()
+	w.now = func() time.Time { return now }
+	w.openedAt = now
+
+	_, err := w.Write([]byte("line 1\n"))
+	assert.NoError(t, err)
+	now = now.Add(30 * time.Minute)
+	_, err = w.Write([]byte("line 2\n"))
+	assert.NoError(t, err)
+	now = now.Add(30 * time.Minute)
+	_, err = w.Write([]byte("line 3\n"))
+	assert.NoError(t, err)
+	assert.NoError(t, w.Close())
+
+	assert.Equal(t, "line 3\n", readFile(t, filename))
+	backups, err := filepath.Glob(filename + ".*.gz")
+	assert.NoError(t, err)
+	assert.Len(t, backups, 1)
+	assert.Equal(t, "line 1\nline 2\n", readGzipFile(t, backups[0]))
+}
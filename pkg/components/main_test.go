@@ -14,23 +14,14 @@
  * limitations under the License.
  */
 
-package baremetal
+package components
 
 import (
-	"context"
-	"fmt"
+	"testing"
 
-	opt "github.com/GreptimeTeam/gtctl/pkg/cluster"
+	"github.com/GreptimeTeam/gtctl/pkg/components/greptimetest"
 )
 
-func (c *Cluster) List(ctx context.Context, options *opt.ListOptions) error {
-	return fmt.Errorf("do not support")
-}
-
-func (c *Cluster) Scale(ctx context.Context, options *opt.ScaleOptions) error {
-	return fmt.Errorf("do not support")
-}
-
-func (c *Cluster) Connect(ctx context.Context, options *opt.ConnectOptions) error {
-	return fmt.Errorf("do not support")
+func TestMain(m *testing.M) {
+	greptimetest.Main(m)
 }
@@ -19,8 +19,6 @@ package components
 import (
 	"context"
 	"fmt"
-	"net"
-	"net/http"
 	"path"
 	"strconv"
 	"sync"
@@ -31,22 +29,32 @@ import (
 	fileutils "github.com/GreptimeTeam/gtctl/pkg/utils/file"
 )
 
+// DefaultMetaSrvBindAddr is the default bind address for meta srv.
+const DefaultMetaSrvBindAddr = "127.0.0.1:3002"
+
 type metaSrv struct {
-	config *config.MetaSrv
+	config    *config.MetaSrv
+	storeAddr string
 
 	workingDirs   WorkingDirs
+	logConfig     *config.Log
+	hosts         RemoteHosts
 	wg            *sync.WaitGroup
 	logger        logger.Logger
 	useMemoryMeta bool
 
 	allocatedDirs
+	processes []*process
 }
 
-func NewMetaSrv(config *config.MetaSrv, workingDirs WorkingDirs,
-	wg *sync.WaitGroup, logger logger.Logger, useMemoryMeta bool) ClusterComponent {
+func NewMetaSrv(config *config.MetaSrv, storeAddr string, workingDirs WorkingDirs, logConfig *config.Log,
+	hosts RemoteHosts, wg *sync.WaitGroup, logger logger.Logger, useMemoryMeta bool) ClusterComponent {
 	return &metaSrv{
 		config:        config,
+		storeAddr:     storeAddr,
 		workingDirs:   workingDirs,
+		logConfig:     logConfig,
+		hosts:         hosts,
 		wg:            wg,
 		logger:        logger,
 		useMemoryMeta: useMemoryMeta,
@@ -58,57 +66,113 @@ func (m *metaSrv) Name() string {
 }
 
 func (m *metaSrv) Start(ctx context.Context, stop context.CancelFunc, binary string) error {
-	// Default bind address for meta srv.
-	bindAddr := net.JoinHostPort("127.0.0.1", "3002")
-	if len(m.config.BindAddr) > 0 {
-		bindAddr = m.config.BindAddr
+	if err := renderConfigFile(m.Name(), m.config.Config, m.config.Options, m.workingDirs.ConfigsDir); err != nil {
+		return err
 	}
 
 	for i := 0; i < m.config.Replicas; i++ {
-		dirName := fmt.Sprintf("%s.%d", m.Name(), i)
-
-		metaSrvLogDir := path.Join(m.workingDirs.LogsDir, dirName)
-		if err := fileutils.EnsureDir(metaSrvLogDir); err != nil {
+		if err := m.startReplica(ctx, stop, binary, i); err != nil {
 			return err
 		}
-		m.logsDirs = append(m.logsDirs, metaSrvLogDir)
+	}
 
-		metaSrvPidDir := path.Join(m.workingDirs.PidsDir, dirName)
-		if err := fileutils.EnsureDir(metaSrvPidDir); err != nil {
-			return err
-		}
-		m.pidsDirs = append(m.pidsDirs, metaSrvPidDir)
-		option := &RunOptions{
-			Binary: binary,
-			Name:   dirName,
-			logDir: metaSrvLogDir,
-			pidDir: metaSrvPidDir,
-			args:   m.BuildArgs(i, bindAddr),
-		}
-		if err := runBinary(ctx, stop, option, m.wg, m.logger); err != nil {
-			return err
-		}
+	return m.WaitUntilReady(ctx)
+}
+
+func (m *metaSrv) Scale(ctx context.Context, stop context.CancelFunc, binary string, replicas int) error {
+	if replicas < m.config.Replicas {
+		processes, err := stopReplicas(m.processes, replicas, m.workingDirs.PidsDir, m.logger)
+		m.processes = processes
+		m.config.Replicas = len(processes)
+		return err
 	}
 
-	// Checking component running status with intervals.
-	ticker := time.NewTicker(500 * time.Millisecond)
-	defer ticker.Stop()
+	if err := renderConfigFile(m.Name(), m.config.Config, m.config.Options, m.workingDirs.ConfigsDir); err != nil {
+		return err
+	}
 
-CHECKER:
-	for {
-		select {
-		case <-ticker.C:
-			if m.IsRunning(ctx) {
-				break CHECKER
+	// The new replicas are stopped if any of them fails to start, the running ones are left as they are.
+	var (
+		oldReplicas = m.config.Replicas
+		guard       = newScaleGuard(stop)
+	)
+	err := func() error {
+		for i := m.config.Replicas; i < replicas; i++ {
+			if err := m.startReplica(ctx, guard.cancel, binary, i); err != nil {
+				return err
 			}
-		case <-ctx.Done():
-			return fmt.Errorf("status checking failed: %v", ctx.Err())
+			m.config.Replicas = i + 1
 		}
+		return m.WaitUntilReady(ctx)
+	}()
+	if err != nil {
+		m.processes, err = rollbackScale(m.processes, oldReplicas, m.workingDirs.PidsDir, m.logger, err)
+		m.config.Replicas = len(m.processes)
+		return err
 	}
+	guard.done()
 
 	return nil
 }
 
+func (m *metaSrv) startReplica(ctx context.Context, stop context.CancelFunc, binary string, nodeID int) error {
+	bindAddr := DefaultMetaSrvBindAddr
+	if len(m.config.BindAddr) > 0 {
+		bindAddr = m.config.BindAddr
+	}
+
+	dirName := fmt.Sprintf("%s.%d", m.Name(), nodeID)
+
+	metaSrvLogDir := path.Join(m.workingDirs.LogsDir, dirName)
+	if err := fileutils.EnsureDir(metaSrvLogDir); err != nil {
+		return err
+	}
+	m.logsDirs = append(m.logsDirs, metaSrvLogDir)
+
+	metaSrvPidDir := path.Join(m.workingDirs.PidsDir, dirName)
+	if err := fileutils.EnsureDir(metaSrvPidDir); err != nil {
+		return err
+	}
+	m.pidsDirs = append(m.pidsDirs, metaSrvPidDir)
+
+	options := ReplicaProcessOptions(m.config.Process, nodeID)
+	host, err := m.hosts.replicaHost(options)
+	if err != nil {
+		return err
+	}
+
+	option := &RunOptions{
+		Binary:    binary,
+		Name:      dirName,
+		logDir:    metaSrvLogDir,
+		pidDir:    metaSrvPidDir,
+		log:       m.logConfig,
+		args:      m.BuildArgs(nodeID, bindAddr),
+		restart:   m.config.Restart,
+		cgroup:    replicaCgroup(m.workingDirs, dirName, m.config.Resources),
+		resources: m.config.Resources,
+		host:      host,
+	}
+	if err := option.withProcessOptions(options); err != nil {
+		return err
+	}
+	p, err := runBinary(ctx, stop, option, m.wg, m.logger)
+	if err != nil {
+		return err
+	}
+	m.processes = append(m.processes, p)
+
+	return nil
+}
+
+func (m *metaSrv) RestartReplica(ctx context.Context, stop context.CancelFunc, binary string, replica int) error {
+	return restartReplica(ctx, stop, m.processes, replica, binary, m.wg, m.logger)
+}
+
+func (m *metaSrv) Stop(timeout time.Duration) error {
+	return terminateReplicas(m.processes, timeout)
+}
+
 func (m *metaSrv) BuildArgs(params ...interface{}) []string {
 	logLevel := m.config.LogLevel
 	if logLevel == "" {
@@ -122,7 +186,7 @@ func (m *metaSrv) BuildArgs(params ...interface{}) []string {
 	args := []string{
 		fmt.Sprintf("--log-level=%s", logLevel),
 		m.Name(), "start",
-		fmt.Sprintf("--store-addr=%s", m.config.StoreAddr),
+		fmt.Sprintf("--store-addr=%s", m.storeAddr),
 		fmt.Sprintf("--server-addr=%s", m.config.ServerAddr),
 	}
 	args = GenerateAddrArg("--http-addr", m.config.HTTPAddr, nodeID, args)
@@ -133,36 +197,25 @@ func (m *metaSrv) BuildArgs(params ...interface{}) []string {
 		args = GenerateAddrArg("--use-memory-store", useMemoryMeta, nodeID, args)
 	}
 
-	if len(m.config.Config) > 0 {
-		args = append(args, fmt.Sprintf("-c=%s", m.config.Config))
+	if configFile := configFilePath(m.Name(), m.config.Config, len(m.config.Options) > 0, m.workingDirs.ConfigsDir); len(configFile) > 0 {
+		args = append(args, fmt.Sprintf("-c=%s", configFile))
 	}
 
 	return args
 }
 
-func (m *metaSrv) IsRunning(_ context.Context) bool {
-	for i := 0; i < m.config.Replicas; i++ {
-		addr := FormatAddrArg(m.config.HTTPAddr, i)
-		_, httpPort, err := net.SplitHostPort(addr)
-		if err != nil {
-			m.logger.V(5).Infof("failed to split host port in %s: %s", m.Name(), err)
-			return false
-		}
-
-		rsp, err := http.Get(fmt.Sprintf("http://localhost:%s/health", httpPort))
-		if err != nil {
-			m.logger.V(5).Infof("failed to get %s health: %s", m.Name(), err)
-			return false
-		}
-
-		if rsp.StatusCode != http.StatusOK {
-			return false
-		}
+// IsRunning returns true if all the replicas of metasrv are healthy.
+func (m *metaSrv) IsRunning(ctx context.Context) bool {
+	return isReplicasRunning(ctx, m.Name(), m.config.Replicas, m.checkReplica, m.logger)
+}
 
-		if err = rsp.Body.Close(); err != nil {
-			return false
-		}
-	}
+// WaitUntilReady waits for all the replicas of metasrv to be healthy within its startup timeout.
+func (m *metaSrv) WaitUntilReady(ctx context.Context) error {
+	return waitUntilReady(ctx, m.Name(), m.processes, m.config.StartupTimeout, m.checkReplica)
+}
 
-	return true
+// checkReplica checks the '/health' endpoint of the replica.
+func (m *metaSrv) checkReplica(ctx context.Context, replica int) error {
+	return checkHTTPHealth(ctx, m.hosts.AdvertiseAddr(FormatAddrArg(m.config.HTTPAddr, replica),
+		ReplicaProcessOptions(m.config.Process, replica)))
 }
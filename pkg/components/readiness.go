@@ -0,0 +1,224 @@
+/*
+ * Copyright 2023 Greptime Team
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package components
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path"
+	"strings"
+	"time"
+
+	"github.com/GreptimeTeam/gtctl/pkg/logger"
+)
+
+const (
+	// defaultStartupTimeout is the default timeout of waiting for all the replicas of component to be healthy.
+	defaultStartupTimeout = time.Minute
+
+	// readinessCheckInterval is the interval of checking the health of replicas.
+	readinessCheckInterval = 500 * time.Millisecond
+
+	// healthRequestTimeout is the timeout of each request to the health endpoint of replica.
+	healthRequestTimeout = time.Second
+
+	// unhealthyLogTailLines is the number of the last lines of log that are reported for the unhealthy replica.
+	unhealthyLogTailLines = 20
+
+	// logTailMaxBytes is the max number of bytes that are read from the end of log for its last lines.
+	logTailMaxBytes = 64 * 1024
+)
+
+// checkReplicaFunc checks the health of the replica by its index, it returns the reason if it's unhealthy.
+type checkReplicaFunc func(ctx context.Context, replica int) error
+
+// checkHTTPHealth checks the '/health' endpoint that listens on addr.
+func checkHTTPHealth(ctx context.Context, addr string) error {
+	ctx, cancel := context.WithTimeout(ctx, healthRequestTimeout)
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, fmt.Sprintf("http://%s/health", addr), nil)
+	if err != nil {
+		return err
+	}
+	rsp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer rsp.Body.Close()
+
+	if rsp.StatusCode != http.StatusOK {
+		return fmt.Errorf("health status: '%s'", rsp.Status)
+	}
+	return nil
+}
+
+// isReplicasRunning returns true if all the replicas are healthy.
+func isReplicasRunning(ctx context.Context, name string, replicas int, check checkReplicaFunc, l logger.Logger) bool {
+	for i := 0; i < replicas; i++ {
+		if err := check(ctx, i); err != nil {
+			l.V(5).Infof("%s.%d is not healthy: %v", name, i, err)
+			return false
+		}
+	}
+	return true
+}
+
+// waitUntilReady checks the health of all the replicas with intervals until they are all healthy.
+// It fails once any replica exits without being restarted, or some replicas are still unhealthy after timeout,
+// and the error names the unhealthy replicas with the last lines of their logs.
+func waitUntilReady(ctx context.Context, name string, processes []*process, timeout time.Duration,
+	check checkReplicaFunc) error {
+	if timeout <= 0 {
+		timeout = defaultStartupTimeout
+	}
+	checkCtx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	ticker := time.NewTicker(readinessCheckInterval)
+	defer ticker.Stop()
+
+	unhealthy := make(map[int]error)
+	for {
+		select {
+		case <-ticker.C:
+			if err := exitedError(name, processes); err != nil {
+				return err
+			}
+
+			// Each check is bounded by the request timeout instead of the startup timeout,
+			// so the reported reasons are not just about the deadline.
+			unhealthy = make(map[int]error)
+			for i := range processes {
+				if err := check(ctx, i); err != nil {
+					unhealthy[i] = err
+				}
+			}
+			if len(unhealthy) == 0 {
+				return nil
+			}
+		case <-checkCtx.Done():
+			// The exited replica may have stopped the cluster, which is the cause of cancellation.
+			if err := exitedError(name, processes); err != nil {
+				return err
+			}
+			if ctx.Err() != nil {
+				return fmt.Errorf("status checking of %s failed: %v", name, ctx.Err())
+			}
+			if len(unhealthy) == 0 {
+				for i := range processes {
+					unhealthy[i] = errors.New("not checked yet")
+				}
+			}
+			return readinessError(fmt.Sprintf("%s is not ready in %s, %d/%d replica(s) are unhealthy",
+				name, timeout, len(unhealthy), len(processes)), processes, unhealthy)
+		}
+	}
+}
+
+// exitedError returns the error that reports the exited replicas, it's nil if all the replicas are running.
+func exitedError(name string, processes []*process) error {
+	exited := make(map[int]error)
+	for i, p := range processes {
+		select {
+		case <-p.exited:
+			exited[i] = errors.New("exited")
+		default:
+		}
+	}
+	if len(exited) == 0 {
+		return nil
+	}
+	return readinessError(fmt.Sprintf("%s is not ready, %d/%d replica(s) have exited",
+		name, len(exited), len(processes)), processes, exited)
+}
+
+// readinessError reports the unhealthy replicas in the order of their indexes, with the last lines of their logs.
+func readinessError(summary string, processes []*process, unhealthy map[int]error) error {
+	var b strings.Builder
+	b.WriteString(summary + ":")
+	for i, p := range processes {
+		reason, ok := unhealthy[i]
+		if !ok {
+			continue
+		}
+
+		logFile := path.Join(p.option.logDir, "log")
+		b.WriteString(fmt.Sprintf("\n  - %s (pid '%d'): %v", p.option.Name, p.pid(), reason))
+		lines, err := tailLog(logFile, unhealthyLogTailLines)
+		if err != nil {
+			b.WriteString(fmt.Sprintf("\n    failed to read its log '%s': %v", logFile, err))
+			continue
+		}
+		if len(lines) == 0 {
+			b.WriteString(fmt.Sprintf("\n    its log '%s' is empty", logFile))
+			continue
+		}
+		b.WriteString(fmt.Sprintf("\n    the last %d line(s) of its log '%s':", len(lines), logFile))
+		for _, line := range lines {
+			b.WriteString("\n      | " + line)
+		}
+	}
+	return fmt.Errorf("%s", b.String())
+}
+
+// tailLog returns the last n lines of the log file, only the end of file is read if it's large.
+func tailLog(filename string, n int) ([]string, error) {
+	f, err := os.Open(filename)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	info, err := f.Stat()
+	if err != nil {
+		return nil, err
+	}
+	offset := info.Size() - logTailMaxBytes
+	if offset < 0 {
+		offset = 0
+	}
+	if _, err = f.Seek(offset, io.SeekStart); err != nil {
+		return nil, err
+	}
+	data, err := io.ReadAll(f)
+	if err != nil {
+		return nil, err
+	}
+
+	// The first line may be partial if the file is read from the middle.
+	if offset > 0 {
+		if i := bytes.IndexByte(data, '\n'); i >= 0 {
+			data = data[i+1:]
+		}
+	}
+	data = bytes.TrimRight(data, "\n")
+	if len(data) == 0 {
+		return nil, nil
+	}
+
+	lines := strings.Split(string(data), "\n")
+	if len(lines) > n {
+		lines = lines[len(lines)-n:]
+	}
+	return lines, nil
+}
@@ -0,0 +1,139 @@
+/*
+ * Copyright 2023 Greptime Team
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package components
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// fakeCommand is the running binary that is never signaled in tests.
+type fakeCommand struct {
+	pid int
+}
+
+func (c *fakeCommand) Pid() int                      { return c.pid }
+func (c *fakeCommand) Signal(_ syscall.Signal) error { return nil }
+func (c *fakeCommand) Wait() error                   { return nil }
+
+func newFakeProcess(t *testing.T, name string, pid int, logs string) *process {
+	logDir := filepath.Join(t.TempDir(), name)
+	assert.NoError(t, os.MkdirAll(logDir, 0755))
+	assert.NoError(t, os.WriteFile(filepath.Join(logDir, "log"), []byte(logs), 0644))
+
+	return &process{
+		option: &RunOptions{Name: name, logDir: logDir},
+		exited: make(chan struct{}),
+		cmd:    &fakeCommand{pid: pid},
+	}
+}
+
+func TestCheckHTTPHealth(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/health" {
+			w.WriteHeader(http.StatusServiceUnavailable)
+		}
+	}))
+	defer server.Close()
+
+	addr := strings.TrimPrefix(server.URL, "http://")
+	assert.NoError(t, checkHTTPHealth(context.Background(), addr))
+
+	server.Config.Handler = http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	})
+	assert.ErrorContains(t, checkHTTPHealth(context.Background(), addr), "503 Service Unavailable")
+}
+
+func TestWaitUntilReady(t *testing.T) {
+	processes := []*process{
+		newFakeProcess(t, "frontend.0", 100, "starting\nready\n"),
+		newFakeProcess(t, "frontend.1", 101, "starting\nfailed to bind 0.0.0.0:4001\n"),
+	}
+
+	// The replicas become healthy after a while.
+	checked := 0
+	err := waitUntilReady(context.Background(), "frontend", processes, 5*time.Second,
+		func(_ context.Context, replica int) error {
+			checked++
+			if checked < 4 {
+				return errors.New("connection refused")
+			}
+			return nil
+		})
+	assert.NoError(t, err)
+
+	// The unhealthy replica is reported with the last lines of its log.
+	err = waitUntilReady(context.Background(), "frontend", processes, time.Second,
+		func(_ context.Context, replica int) error {
+			if replica == 1 {
+				return errors.New("connection refused")
+			}
+			return nil
+		})
+	assert.Error(t, err)
+	msg := err.Error()
+	assert.Contains(t, msg, "frontend is not ready in 1s, 1/2 replica(s) are unhealthy")
+	assert.Contains(t, msg, "frontend.1 (pid '101'): connection refused")
+	assert.Contains(t, msg, "      | failed to bind 0.0.0.0:4001")
+	assert.NotContains(t, msg, "frontend.0")
+
+	// The exited replica fails the waiting without timeout.
+	close(processes[0].exited)
+	start := time.Now()
+	err = waitUntilReady(context.Background(), "frontend", processes, time.Minute,
+		func(_ context.Context, _ int) error { return errors.New("connection refused") })
+	assert.ErrorContains(t, err, "frontend is not ready, 1/2 replica(s) have exited")
+	assert.ErrorContains(t, err, "frontend.0 (pid '100'): exited")
+	assert.Less(t, time.Since(start), 10*time.Second)
+}
+
+func TestTailLog(t *testing.T) {
+	logFile := filepath.Join(t.TempDir(), "log")
+
+	var b strings.Builder
+	for i := 0; i < 10000; i++ {
+		b.WriteString(fmt.Sprintf("line %d\n", i))
+	}
+	assert.NoError(t, os.WriteFile(logFile, []byte(b.String()), 0644))
+
+	lines, err := tailLog(logFile, 3)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"line 9997", "line 9998", "line 9999"}, lines)
+
+	// All the lines are returned if the log is short.
+	assert.NoError(t, os.WriteFile(logFile, []byte("a\nb"), 0644))
+	lines, err = tailLog(logFile, 3)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"a", "b"}, lines)
+
+	assert.NoError(t, os.WriteFile(logFile, nil, 0644))
+	lines, err = tailLog(logFile, 3)
+	assert.NoError(t, err)
+	assert.Empty(t, lines)
+}
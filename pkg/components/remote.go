@@ -0,0 +1,418 @@
+/*
+ * Copyright 2023 Greptime Team
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package components
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"net"
+	"os"
+	"path"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"syscall"
+	"time"
+
+	"github.com/GreptimeTeam/gtctl/pkg/config"
+	"github.com/GreptimeTeam/gtctl/pkg/logger"
+	"github.com/GreptimeTeam/gtctl/pkg/remote"
+	fileutils "github.com/GreptimeTeam/gtctl/pkg/utils/file"
+	"github.com/GreptimeTeam/gtctl/pkg/utils/users"
+)
+
+const (
+	// remoteStartTimeout is the duration to wait for the remote binary to report its pid after starting,
+	// and for the replica left by the previous run to exit.
+	remoteStartTimeout = 30 * time.Second
+
+	// remoteCheckInterval is the interval of checking the remote binary and pulling its logs.
+	remoteCheckInterval = 500 * time.Millisecond
+
+	// remoteMaxCheckFailures is the number of consecutive failed checks after which the remote binary is
+	// given up, i.e. the host is unreachable for about a minute.
+	remoteMaxCheckFailures = 120
+
+	// remoteConfigDir is the directory in the cluster directory of remote host that the configs are uploaded to.
+	remoteConfigDir = "config"
+
+	// remoteDataDir is the directory in the cluster directory of remote host that the data is stored in.
+	remoteDataDir = "data"
+
+	// remoteLogsDir and remotePidsDir are the directories in the cluster directory of remote host that
+	// the logs and pids of replicas are written to.
+	remoteLogsDir = "logs"
+	remotePidsDir = "pids"
+)
+
+// RemoteHosts are the remote hosts that the replicas run on, keyed by the host name.
+type RemoteHosts map[string]*remote.Client
+
+// NewRemoteHosts returns the clients of hosts for the cluster, they connect to the hosts on their first use.
+func NewRemoteHosts(hosts []*config.Host, cluster string, l logger.Logger) RemoteHosts {
+	ret := make(RemoteHosts, len(hosts))
+	for _, host := range hosts {
+		ret[host.Name] = remote.NewClient(host, cluster, l)
+	}
+	return ret
+}
+
+// Close closes the connections to all the hosts.
+func (h RemoteHosts) Close() error {
+	for _, client := range h {
+		if err := client.Close(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// replicaHost returns the remote host that the replica runs on, it's nil if the replica runs locally.
+func (h RemoteHosts) replicaHost(options config.ProcessOptions) (*remote.Client, error) {
+	if len(options.Host) == 0 {
+		return nil, nil
+	}
+
+	host, ok := h[options.Host]
+	if !ok {
+		return nil, fmt.Errorf("host '%s' is not found in hosts", options.Host)
+	}
+	return host, nil
+}
+
+// DataDir returns the data directory of the cluster on the host, it's dataDir if the host is local.
+func (h RemoteHosts) DataDir(host, dataDir string) (string, error) {
+	client, err := h.replicaHost(config.ProcessOptions{Host: host})
+	if err != nil || client == nil {
+		return dataDir, err
+	}
+
+	clusterDir, err := client.ClusterDir()
+	if err != nil {
+		return "", err
+	}
+	return path.Join(clusterDir, remoteDataDir), nil
+}
+
+// AdvertiseAddr returns the address that can be used to access the listening addr of replica, the unspecified
+// or loopback host of the replica on remote host is replaced by the hostname of remote host.
+func (h RemoteHosts) AdvertiseAddr(addr string, options config.ProcessOptions) string {
+	client, err := h.replicaHost(options)
+	if err != nil || client == nil {
+		return AdvertiseAddr(addr)
+	}
+
+	host, port, err := net.SplitHostPort(addr)
+	if err != nil {
+		return addr
+	}
+	if ip := net.ParseIP(host); len(host) == 0 || host == "localhost" || (ip != nil && (ip.IsUnspecified() || ip.IsLoopback())) {
+		host = client.Hostname()
+	}
+	return net.JoinHostPort(host, port)
+}
+
+// ensureDirs creates the directories on the host, or locally if the host is nil.
+func ensureDirs(host *remote.Client, dirs ...string) error {
+	if host != nil {
+		return host.MkdirAll(dirs...)
+	}
+
+	for _, dir := range dirs {
+		if err := fileutils.EnsureDir(dir); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// KillRemoteReplicas kills the replicas of the cluster that are left running on the host, e.g. gtctl exited
+// without stopping them, by the pid files in the cluster directory of host.
+func KillRemoteReplicas(host *remote.Client) error {
+	clusterDir, err := host.ClusterDir()
+	if err != nil {
+		return err
+	}
+
+	pidFiles := remote.Quote(path.Join(clusterDir, remotePidsDir)) + "/*/pid"
+	_, err = host.Run(fmt.Sprintf(`for f in %s; do
+  [ -s "$f" ] && pid=$(cat "$f") && grep -qF "$f" /proc/$pid/cmdline 2>/dev/null && kill -KILL -$pid 2>/dev/null
+done; true`, pidFiles))
+	return err
+}
+
+// remoteCommand is the binary that runs detached on the remote host, so it keeps running if the connection
+// to the host is lost or gtctl exits. Its liveness is checked and its new logs are pulled over SSH with intervals.
+type remoteCommand struct {
+	host   *remote.Client
+	name   string
+	pid    int
+	logger logger.Logger
+
+	// logFile and exitFile are on the remote host, offset is the size of logFile that has been pulled to output.
+	logFile  string
+	exitFile string
+	offset   int64
+	output   io.Writer
+
+	// exited will be closed after the binary exits, and err is the exit error of the binary.
+	exited chan struct{}
+	err    error
+
+	// signaled is set once a signal is sent, the binary is given up on the first failed check after it.
+	signaled int32
+}
+
+// remoteWrapper runs the binary in $@ in the process group of the shell that runs it, whose pid is written to
+// the file $0. The shell traps SIGTERM, so it waits for the binary to exit and records its exit status.
+const remoteWrapper = `trap : TERM; echo $$ > "$0.tmp" && mv "$0.tmp" "$0"; "$@"; echo $? > "$0.exit"`
+
+// startRemote uploads the binary and the config of replica to the remote host and starts it there in a new session,
+// its output is appended to the log file of replica in the cluster directory of host. The pid of the binary is the
+// process group of the shell that runs it, so the signals are sent to the group.
+// The replica left by the previous run, e.g. gtctl exited without stopping it, is stopped before starting.
+func startRemote(option *RunOptions, output io.Writer, l logger.Logger) (command, error) {
+	host := option.host
+
+	binary, err := host.UploadBinary(option.Binary)
+	if err != nil {
+		return nil, err
+	}
+
+	clusterDir, err := host.ClusterDir()
+	if err != nil {
+		return nil, err
+	}
+
+	// The config file and users file are uploaded to the directory of replica, and the arg is replaced by
+	// the uploaded one. They are only readable by the user since they may contain the credentials.
+	args := make([]string, 0, len(option.args))
+	for _, arg := range option.args {
+		for _, prefix := range []string{"-c=", "--user-provider=" + users.FileProviderPrefix} {
+			if !strings.HasPrefix(arg, prefix) {
+				continue
+			}
+			local := strings.TrimPrefix(arg, prefix)
+			uploaded := path.Join(clusterDir, remoteConfigDir, option.Name, filepath.Base(local))
+			if err = host.Upload(local, uploaded, 0600); err != nil {
+				return nil, err
+			}
+			arg = prefix + uploaded
+		}
+		args = append(args, arg)
+	}
+
+	var (
+		logFile = path.Join(clusterDir, remoteLogsDir, option.Name, "log")
+		pidFile = path.Join(clusterDir, remotePidsDir, option.Name, "pid")
+		command strings.Builder
+	)
+	if len(option.env) > 0 {
+		command.WriteString("env ")
+		for _, e := range option.env {
+			command.WriteString(remote.Quote(e) + " ")
+		}
+	}
+	command.WriteString(remote.Quote(binary))
+	for _, arg := range args {
+		command.WriteString(" " + remote.Quote(arg))
+	}
+	cd := ""
+	if len(option.workingDir) > 0 {
+		cd = fmt.Sprintf("cd %s && ", remote.Quote(option.workingDir))
+	}
+
+	// It prints the size of log file before starting, which is where the logs of this run begin, and the pid.
+	script := fmt.Sprintf(`command -v setsid >/dev/null || { echo "setsid is not found" >&2; exit 1; }
+mkdir -p %[1]s %[2]s || exit 1
+if [ -s %[3]s ] && pid=$(cat %[3]s) && grep -qF %[3]s /proc/$pid/cmdline 2>/dev/null; then
+  kill -TERM -$pid 2>/dev/null; i=0
+  while kill -0 $pid 2>/dev/null && [ $i -lt %[4]d ]; do sleep 0.1; i=$((i+1)); done
+  kill -KILL -$pid 2>/dev/null
+fi
+rm -f %[3]s %[3]s.exit
+offset=$(wc -c < %[5]s 2>/dev/null || echo 0)
+%[6]ssetsid nohup sh -c %[7]s %[3]s %[8]s >> %[5]s 2>&1 < /dev/null &
+i=0
+while [ ! -s %[3]s ]; do [ $i -lt %[4]d ] || exit 1; sleep 0.1; i=$((i+1)); done
+echo $offset $(cat %[3]s)`,
+		remote.Quote(path.Dir(logFile)), remote.Quote(path.Dir(pidFile)), remote.Quote(pidFile),
+		int(remoteStartTimeout/(100*time.Millisecond)), remote.Quote(logFile), cd, remote.Quote(remoteWrapper),
+		command.String())
+
+	out, err := host.Run(script)
+	if err != nil {
+		return nil, fmt.Errorf("failed to start '%s' on host '%s': %v", option.Name, host.Name(), err)
+	}
+	lines := strings.Split(strings.TrimSpace(string(out)), "\n")
+	fields := strings.Fields(lines[len(lines)-1])
+	if len(fields) != 2 {
+		return nil, fmt.Errorf("failed to start '%s' on host '%s': unexpected output '%s'", option.Name, host.Name(), out)
+	}
+	offset, err := strconv.ParseInt(fields[0], 10, 64)
+	if err != nil {
+		return nil, fmt.Errorf("invalid size of log '%s': %v", fields[0], err)
+	}
+	pid, err := strconv.Atoi(fields[1])
+	if err != nil {
+		return nil, fmt.Errorf("invalid pid '%s': %v", fields[1], err)
+	}
+
+	cmd := &remoteCommand{
+		host:     host,
+		name:     option.Name,
+		pid:      pid,
+		logger:   l,
+		logFile:  logFile,
+		exitFile: pidFile + ".exit",
+		offset:   offset,
+		output:   output,
+		exited:   make(chan struct{}),
+	}
+	go cmd.watch()
+
+	return cmd, nil
+}
+
+// watch checks whether the binary is running and pulls its new logs with intervals until it exits.
+// The binary is still running while the host is unreachable, so it's checked again after reconnecting,
+// unless it's being stopped or the host is unreachable for too long. The binary given up may still be
+// running on the host, and it's killed when the cluster is deleted.
+func (c *remoteCommand) watch() {
+	defer close(c.exited)
+
+	ticker := time.NewTicker(remoteCheckInterval)
+	defer ticker.Stop()
+
+	failures := 0
+	for range ticker.C {
+		status, err := c.check()
+		if err != nil {
+			failures++
+			if atomic.LoadInt32(&c.signaled) == 1 || failures >= remoteMaxCheckFailures {
+				c.logger.Warnf("host '%s' is unreachable, '%s' (pid '%d') is given up: %v", c.host.Name(), c.name, c.pid, err)
+				c.err = fmt.Errorf("host '%s' is unreachable, '%s' may still be running on it: %v", c.host.Name(), c.name, err)
+				return
+			}
+			if failures == 1 {
+				c.logger.Warnf("failed to check '%s' on host '%s', it's checked again later: %v", c.name, c.host.Name(), err)
+			}
+			continue
+		}
+		if failures > 0 {
+			c.logger.V(0).Infof("'%s' on host '%s' is checked again", c.name, c.host.Name())
+			failures = 0
+		}
+
+		if status == "running" {
+			continue
+		}
+		c.err = exitError(status)
+		return
+	}
+}
+
+// check returns 'running' if the binary is running, otherwise its exit status or empty if it's unknown.
+// The logs written before checking are pulled to output, so all the logs are pulled once the binary exits.
+func (c *remoteCommand) check() (string, error) {
+	script := fmt.Sprintf(`if kill -0 %d 2>/dev/null; then s=running; else s=$(cat %s 2>/dev/null); fi
+tail -c +%d %s 2>/dev/null
+echo "$s" >&2`, c.pid, remote.Quote(c.exitFile), c.offset+1, remote.Quote(c.logFile))
+
+	var status bytes.Buffer
+	session, err := c.host.Start(script, &offsetWriter{out: c.output, offset: &c.offset}, &status)
+	if err != nil {
+		return "", err
+	}
+	defer session.Close()
+	if err = session.Wait(); err != nil {
+		return "", err
+	}
+	return strings.TrimSpace(status.String()), nil
+}
+
+func (c *remoteCommand) Pid() int {
+	return c.pid
+}
+
+// Signal sends the signal to the process group of binary by running 'kill' on the remote host.
+func (c *remoteCommand) Signal(sig syscall.Signal) error {
+	select {
+	case <-c.exited:
+		return os.ErrProcessDone
+	default:
+	}
+
+	// The binary is given up by watch if the host is unreachable, even if the signal can't be sent.
+	atomic.StoreInt32(&c.signaled, 1)
+	out, err := c.host.Run(fmt.Sprintf("kill -%d -%d 2>/dev/null || echo exited", int(sig), c.pid))
+	if err != nil {
+		return err
+	}
+	if strings.TrimSpace(string(out)) == "exited" {
+		return os.ErrProcessDone
+	}
+	return nil
+}
+
+func (c *remoteCommand) Wait() error {
+	<-c.exited
+	return c.err
+}
+
+// remoteExitError is the error of the remote binary that is killed by the signal.
+type remoteExitError struct {
+	signal syscall.Signal
+}
+
+func (e *remoteExitError) Error() string {
+	return "signal: " + e.signal.String()
+}
+
+// exitError returns the error of the exit status recorded by remoteWrapper, the status above 128 means
+// the binary is killed by the signal of status-128 like what shell reports.
+func exitError(status string) error {
+	if len(status) == 0 {
+		return fmt.Errorf("exited without status, it may be killed along with its wrapper")
+	}
+	code, err := strconv.Atoi(status)
+	if err != nil {
+		return fmt.Errorf("invalid exit status '%s'", status)
+	}
+	switch {
+	case code == 0:
+		return nil
+	case code > 128:
+		return &remoteExitError{signal: syscall.Signal(code - 128)}
+	default:
+		return fmt.Errorf("exit status %d", code)
+	}
+}
+
+// offsetWriter writes to out and counts the written bytes in offset.
+type offsetWriter struct {
+	out    io.Writer
+	offset *int64
+}
+
+func (w *offsetWriter) Write(p []byte) (int, error) {
+	n, err := w.out.Write(p)
+	*w.offset += int64(n)
+	return n, err
+}
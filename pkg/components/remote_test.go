@@ -0,0 +1,242 @@
+/*
+ * Copyright 2023 Greptime Team
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package components
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"os"
+	"path"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/GreptimeTeam/gtctl/pkg/components/greptimetest"
+	"github.com/GreptimeTeam/gtctl/pkg/config"
+	"github.com/GreptimeTeam/gtctl/pkg/logger"
+	"github.com/GreptimeTeam/gtctl/pkg/remote"
+	"github.com/GreptimeTeam/gtctl/pkg/remote/remotetest"
+)
+
+func TestRunRemoteProcess(t *testing.T) {
+	server := remotetest.NewServer(t)
+	l := logger.New(io.Discard, 0)
+	hosts := NewRemoteHosts([]*config.Host{{
+		Name:                  "node-1",
+		Address:               server.Addr,
+		IdentityFile:          server.IdentityFile,
+		InsecureIgnoreHostKey: true,
+	}}, "mycluster", l)
+	defer hosts.Close()
+
+	dir := t.TempDir()
+	binary := filepath.Join(dir, "greptime")
+	assert.NoError(t, os.WriteFile(binary, []byte(`#!/bin/sh
+echo "args: $*"
+echo "env: $RUST_LOG"
+echo "pwd: $(pwd)"
+trap 'echo terminated; exit 0' TERM
+while true; do sleep 0.1; done
+`), 0755))
+	configFile := filepath.Join(dir, "datanode.toml")
+	assert.NoError(t, os.WriteFile(configFile, []byte("mode = 'distributed'\n"), 0644))
+
+	logDir, pidDir := filepath.Join(dir, "logs"), filepath.Join(dir, "pids")
+	assert.NoError(t, os.MkdirAll(logDir, 0755))
+	assert.NoError(t, os.MkdirAll(pidDir, 0755))
+
+	options := config.ProcessOptions{
+		Env:        map[string]string{"RUST_LOG": "debug"},
+		WorkingDir: path.Join(server.Home, "work"),
+		Host:       "node-1",
+	}
+	host, err := hosts.replicaHost(options)
+	assert.NoError(t, err)
+
+	option := &RunOptions{
+		Binary: binary,
+		Name:   "datanode.0",
+		logDir: logDir,
+		pidDir: pidDir,
+		log:    &config.Log{},
+		args:   []string{"datanode", "start", "-c=" + configFile},
+		host:   host,
+	}
+	assert.NoError(t, option.withProcessOptions(options))
+
+	var (
+		wg          sync.WaitGroup
+		ctx, cancel = context.WithCancel(context.Background())
+	)
+	defer cancel()
+	p, err := runBinary(ctx, cancel, option, &wg, l)
+	assert.NoError(t, err)
+
+	// The config is uploaded to the cluster directory on host.
+	uploaded := path.Join(server.Home, remote.DefaultDir, "mycluster", remoteConfigDir, "datanode.0", "datanode.toml")
+	assert.FileExists(t, uploaded)
+
+	logFile := filepath.Join(logDir, "log")
+	assert.Eventually(t, func() bool {
+		data, _ := os.ReadFile(logFile)
+		return bytes.Contains(data, []byte("pwd: "))
+	}, 5*time.Second, 50*time.Millisecond)
+	logs := readFile(t, logFile)
+	assert.Contains(t, logs, "args: datanode start -c="+uploaded)
+	assert.Contains(t, logs, "env: debug")
+	assert.Contains(t, logs, "pwd: "+path.Join(server.Home, "work"))
+
+	// The pid of remote process is recorded with its host.
+	assert.Equal(t, strconv.Itoa(p.pid()), readFile(t, filepath.Join(pidDir, "pid")))
+	assert.Equal(t, "node-1", readFile(t, filepath.Join(pidDir, "host")))
+
+	assert.NoError(t, p.terminate(5*time.Second))
+	wg.Wait()
+	assert.NoError(t, ctx.Err())
+	assert.True(t, strings.HasSuffix(readFile(t, logFile), "terminated\n"))
+}
+
+func TestRemoteProcessDetached(t *testing.T) {
+	server := remotetest.NewServer(t)
+	l := logger.New(io.Discard, 0)
+	hosts := NewRemoteHosts([]*config.Host{{
+		Name:                  "node-1",
+		Address:               server.Addr,
+		IdentityFile:          server.IdentityFile,
+		InsecureIgnoreHostKey: true,
+	}}, "mycluster", l)
+	defer hosts.Close()
+
+	dir := t.TempDir()
+	logDir, pidDir := filepath.Join(dir, "logs"), filepath.Join(dir, "pids")
+	assert.NoError(t, os.MkdirAll(logDir, 0755))
+	assert.NoError(t, os.MkdirAll(pidDir, 0755))
+
+	option := &RunOptions{
+		Binary: greptimetest.NewBinary(t, greptimetest.Options{}),
+		Name:   "frontend.0",
+		logDir: logDir,
+		pidDir: pidDir,
+		log:    &config.Log{},
+		args:   []string{"frontend", "start"},
+		host:   hosts["node-1"],
+	}
+
+	var (
+		wg          sync.WaitGroup
+		ctx, cancel = context.WithCancel(context.Background())
+	)
+	defer cancel()
+	p, err := runBinary(ctx, cancel, option, &wg, l)
+	assert.NoError(t, err)
+
+	logFile := filepath.Join(logDir, "log")
+	assert.Eventually(t, func() bool {
+		data, _ := os.ReadFile(logFile)
+		return bytes.Contains(data, []byte("args: frontend start"))
+	}, 5*time.Second, 50*time.Millisecond)
+
+	// The replica keeps running after the connection to its host is lost, and it's not taken as exited.
+	server.DropConnections()
+	time.Sleep(3 * remoteCheckInterval)
+	assert.NoError(t, syscall.Kill(p.pid(), 0))
+	assert.NoError(t, ctx.Err())
+
+	// It's stopped over the new connection, and the logs written before it exits are pulled.
+	assert.NoError(t, p.terminate(5*time.Second))
+	wg.Wait()
+	assert.NoError(t, ctx.Err())
+	assert.True(t, strings.HasSuffix(readFile(t, logFile), "terminated\n"))
+}
+
+func TestRemoteProcessUnreachable(t *testing.T) {
+	server := remotetest.NewServer(t)
+	l := logger.New(io.Discard, 0)
+	hosts := NewRemoteHosts([]*config.Host{{
+		Name:                  "node-1",
+		Address:               server.Addr,
+		IdentityFile:          server.IdentityFile,
+		InsecureIgnoreHostKey: true,
+	}}, "mycluster", l)
+	defer hosts.Close()
+
+	dir := t.TempDir()
+	logDir, pidDir := filepath.Join(dir, "logs"), filepath.Join(dir, "pids")
+	assert.NoError(t, os.MkdirAll(logDir, 0755))
+	assert.NoError(t, os.MkdirAll(pidDir, 0755))
+
+	option := &RunOptions{
+		Binary: greptimetest.NewBinary(t, greptimetest.Options{}),
+		Name:   "frontend.0",
+		logDir: logDir,
+		pidDir: pidDir,
+		log:    &config.Log{},
+		args:   []string{"frontend", "start"},
+		host:   hosts["node-1"],
+	}
+
+	var (
+		wg          sync.WaitGroup
+		ctx, cancel = context.WithCancel(context.Background())
+	)
+	defer cancel()
+	p, err := runBinary(ctx, cancel, option, &wg, l)
+	assert.NoError(t, err)
+	pid := p.pid()
+	// The replica left on the host is killed like deleting the cluster.
+	defer func() { _ = syscall.Kill(-pid, syscall.SIGKILL) }()
+
+	assert.Eventually(t, func() bool {
+		data, _ := os.ReadFile(filepath.Join(logDir, "log"))
+		return bytes.Contains(data, []byte("args: frontend start"))
+	}, 5*time.Second, 50*time.Millisecond)
+
+	// The host is unreachable, so the replica can't be stopped, but it's given up instead of waiting forever.
+	server.Close()
+	server.DropConnections()
+	assert.Error(t, p.terminate(time.Second))
+
+	done := make(chan struct{})
+	go func() {
+		wg.Wait()
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-time.After(10 * time.Second):
+		t.Fatal("the replica on the unreachable host is not given up")
+	}
+	assert.NoError(t, syscall.Kill(pid, 0))
+}
+
+func TestRemoteHostsAdvertiseAddr(t *testing.T) {
+	hosts := NewRemoteHosts([]*config.Host{{Name: "node-1", Address: "192.168.1.10:22"}},
+		"mycluster", logger.New(io.Discard, 0))
+	remoteOptions := config.ProcessOptions{Host: "node-1"}
+
+	assert.Equal(t, "127.0.0.1:4000", hosts.AdvertiseAddr("0.0.0.0:4000", config.ProcessOptions{}))
+	assert.Equal(t, "192.168.1.10:4000", hosts.AdvertiseAddr("0.0.0.0:4000", remoteOptions))
+	assert.Equal(t, "192.168.1.10:4000", hosts.AdvertiseAddr("127.0.0.1:4000", remoteOptions))
+	assert.Equal(t, "10.0.0.1:4000", hosts.AdvertiseAddr("10.0.0.1:4000", remoteOptions))
+}
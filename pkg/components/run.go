@@ -17,16 +17,21 @@
 package components
 
 import (
-	"bufio"
 	"context"
+	"fmt"
 	"os"
 	"os/exec"
 	"path"
+	"sort"
 	"strconv"
 	"sync"
 	"syscall"
+	"time"
 
+	"github.com/GreptimeTeam/gtctl/pkg/config"
 	"github.com/GreptimeTeam/gtctl/pkg/logger"
+	"github.com/GreptimeTeam/gtctl/pkg/remote"
+	fileutils "github.com/GreptimeTeam/gtctl/pkg/utils/file"
 )
 
 // RunOptions contains all the options for one component to run on bare-metal.
@@ -34,65 +39,538 @@ type RunOptions struct {
 	Binary string
 	Name   string
 
-	pidDir string
-	logDir string
-	args   []string
+	pidDir  string
+	logDir  string
+	log     *config.Log
+	args    []string
+	restart config.Restart
+
+	// env and workingDir are the environment variables and working directory of the binary.
+	env        []string
+	workingDir string
+
+	// cgroup is the cgroup that the binary is placed in with the limits of resources, it's empty if no limits.
+	cgroup    string
+	resources *config.Resources
+
+	// host is the remote host that the binary runs on, it's nil if the binary runs locally.
+	host *remote.Client
 }
 
-func runBinary(ctx context.Context, stop context.CancelFunc,
-	option *RunOptions, wg *sync.WaitGroup, logger logger.Logger) error {
-	cmd := exec.CommandContext(ctx, option.Binary, option.args...)
+// withProcessOptions appends the extra args of options to the args, and sets the env and working dir.
+func (o *RunOptions) withProcessOptions(options config.ProcessOptions) error {
+	o.args = append(o.args, options.ExtraArgs...)
 
-	// output to binary.
-	logFile := path.Join(option.logDir, "log")
-	outputFile, err := os.Create(logFile)
-	if err != nil {
-		return err
+	keys := make([]string, 0, len(options.Env))
+	for k := range options.Env {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		o.env = append(o.env, fmt.Sprintf("%s=%s", k, options.Env[k]))
+	}
+
+	if len(options.WorkingDir) > 0 {
+		if o.host != nil {
+			if err := o.host.MkdirAll(options.WorkingDir); err != nil {
+				return err
+			}
+		} else if err := fileutils.EnsureDir(options.WorkingDir); err != nil {
+			return err
+		}
+		o.workingDir = options.WorkingDir
+	}
+
+	return nil
+}
+
+// ReplicaProcessOptions returns the process options of the replica, which are the ones of component
+// with the overrides of replica.
+func ReplicaProcessOptions(process config.Process, replica int) config.ProcessOptions {
+	options := config.ProcessOptions{
+		ExtraArgs:  process.ExtraArgs,
+		WorkingDir: process.WorkingDir,
+		Host:       process.Host,
+	}
+	if len(process.Env) > 0 {
+		options.Env = make(map[string]string, len(process.Env))
+		for k, v := range process.Env {
+			options.Env[k] = v
+		}
+	}
+
+	override, ok := process.ReplicaOverrides[replica]
+	if !ok || override == nil {
+		return options
+	}
+
+	if len(override.Env) > 0 && options.Env == nil {
+		options.Env = make(map[string]string, len(override.Env))
+	}
+	for k, v := range override.Env {
+		options.Env[k] = v
+	}
+	if len(override.ExtraArgs) > 0 {
+		options.ExtraArgs = append(append([]string{}, options.ExtraArgs...), override.ExtraArgs...)
 	}
+	if len(override.WorkingDir) > 0 {
+		options.WorkingDir = override.WorkingDir
+	}
+	if len(override.Host) > 0 {
+		options.Host = override.Host
+	}
+
+	return options
+}
+
+const (
+	// defaultTerminateTimeout is the duration to wait for a process to exit after sending SIGTERM.
+	defaultTerminateTimeout = 10 * time.Second
+
+	// defaultRestartBackoff is the delay before the first restart if it's not configured.
+	defaultRestartBackoff = time.Second
+
+	// maxRestartBackoff is the upper limit of the delay before restarting.
+	maxRestartBackoff = time.Minute
+)
 
-	outputFileWriter := bufio.NewWriter(outputFile)
-	cmd.Stdout = outputFileWriter
-	cmd.Stderr = outputFileWriter
+// process is one running replica of the cluster component.
+// The replica is restarted with the same args and pid file according to its restart policy.
+type process struct {
+	option *RunOptions
+	logger logger.Logger
+
+	// exited will be closed after the replica exits and won't be restarted anymore.
+	exited chan struct{}
+	// output is the log writer of the replica, which is shared by its restarts.
+	output *logWriter
+
+	mu  sync.Mutex
+	cmd command
+	// stopped will be closed once the replica is being stopped on purpose.
+	stopped  chan struct{}
+	stopping bool
+}
+
+// command is the running binary of replica.
+type command interface {
+	Pid() int
+	Signal(sig syscall.Signal) error
+	Wait() error
+}
+
+// localCommand is the binary that runs on the local host.
+type localCommand struct {
+	cmd *exec.Cmd
+}
+
+func (c *localCommand) Pid() int {
+	return c.cmd.Process.Pid
+}
+
+func (c *localCommand) Signal(sig syscall.Signal) error {
+	return c.cmd.Process.Signal(sig)
+}
 
-	if err = cmd.Start(); err != nil {
+func (c *localCommand) Wait() error {
+	return c.cmd.Wait()
+}
+
+// terminate stops the process gracefully by sending SIGTERM,
+// and the process will be killed if it doesn't exit within the timeout.
+func (p *process) terminate(timeout time.Duration) error {
+	p.mu.Lock()
+	if !p.stopping {
+		p.stopping = true
+		close(p.stopped)
+	}
+	cmd := p.cmd
+	p.mu.Unlock()
+
+	if err := cmd.Signal(syscall.SIGTERM); err != nil && err != os.ErrProcessDone {
 		return err
 	}
 
-	pid := strconv.Itoa(cmd.Process.Pid)
-	logger.V(3).Infof("run '%s' binary '%s' with args: '%v', log: '%s', pid: '%s'",
-		option.Name, option.Binary, option.args, option.logDir, pid)
+	select {
+	case <-p.exited:
+		return nil
+	case <-time.After(timeout):
+		p.logger.Warnf("'%s' (pid '%d') didn't exit within %s, killing it", p.option.Name, cmd.Pid(), timeout)
+		if err := cmd.Signal(syscall.SIGKILL); err != nil && err != os.ErrProcessDone {
+			return err
+		}
+		<-p.exited
+		return nil
+	}
+}
 
-	pidFile := path.Join(option.pidDir, "pid")
-	f, err := os.Create(pidFile)
+func (p *process) isStopping() bool {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.stopping
+}
+
+// pid returns the pid of the current running process of the replica.
+func (p *process) pid() int {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.cmd.Pid()
+}
+
+// restartProcess stops the process gracefully and runs it again by executing binary,
+// the args, pid file and log file of the process are kept.
+func restartProcess(ctx context.Context, stop context.CancelFunc, p *process, binary string,
+	wg *sync.WaitGroup, logger logger.Logger) (*process, error) {
+	if err := p.terminate(defaultTerminateTimeout); err != nil {
+		return nil, err
+	}
+
+	option := *p.option
+	option.Binary = binary
+	return runBinary(ctx, stop, &option, wg, logger)
+}
+
+func runBinary(ctx context.Context, stop context.CancelFunc,
+	option *RunOptions, wg *sync.WaitGroup, logger logger.Logger) (*process, error) {
+	output, err := newLogWriter(path.Join(option.logDir, "log"), option.log, logger)
 	if err != nil {
-		return err
+		return nil, err
 	}
 
-	_, err = f.Write([]byte(pid))
+	// The cgroup is only set up locally, so the remote replica runs without resource limits.
+	if len(option.cgroup) > 0 && option.host != nil {
+		if option.resources != nil {
+			logger.Warnf("the resources of '%s' are not limited on remote host '%s'", option.Name, option.host.Name())
+		}
+		withoutCgroup := *option
+		withoutCgroup.cgroup = ""
+		option = &withoutCgroup
+	}
+
+	// The replica still runs without resource limits if its cgroup can't be set up, e.g. no permission.
+	if len(option.cgroup) > 0 {
+		if err = setupCgroup(option.cgroup, option.resources); err != nil {
+			logger.Warnf("failed to limit the resources of '%s' by cgroup v2, it runs without limits: %v", option.Name, err)
+			withoutCgroup := *option
+			withoutCgroup.cgroup = ""
+			option = &withoutCgroup
+		}
+	}
+
+	p := &process{
+		option:  option,
+		logger:  logger,
+		exited:  make(chan struct{}),
+		output:  output,
+		stopped: make(chan struct{}),
+	}
+
+	wait, err := p.start()
 	if err != nil {
-		return err
+		_ = output.Close()
+		return nil, err
 	}
 
+	wg.Add(1)
 	go func() {
 		defer wg.Done()
-		wg.Add(1)
-		if err := cmd.Wait(); err != nil {
-			// Caught signal kill and interrupt error then ignore.
-			if exit, ok := err.(*exec.ExitError); ok {
-				if status, ok := exit.Sys().(syscall.WaitStatus); ok && status.Signaled() {
-					if status.Signal() == syscall.SIGKILL || status.Signal() == syscall.SIGINT {
-						return
-					}
-				}
+		defer close(p.exited)
+		p.run(ctx, stop, wait)
+		if err := p.output.Close(); err != nil {
+			logger.V(3).Infof("failed to close the log of '%s': %v", option.Name, err)
+		}
+		if len(option.cgroup) > 0 {
+			if err := RemoveCgroup(option.cgroup); err != nil {
+				logger.V(3).Infof("failed to remove the cgroup of '%s': %v", option.Name, err)
 			}
-			logger.Errorf("component '%s' binary '%s' (pid '%s') exited with error: %v", option.Name, option.Binary, pid, err)
-			logger.Errorf("args: '%v'", option.args)
-			_ = outputFileWriter.Flush()
+		}
+	}()
+
+	return p, nil
+}
 
-			// If one component has failed, stop the whole context.
+// start starts the binary of the replica and writes its pid file, the returned func waits for the binary to exit.
+// The output is appended to the log file of replica, so the logs of crashes and previous runs are kept.
+// The logs of the replica on remote host are pulled to its local log file.
+func (p *process) start() (func() error, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if p.stopping {
+		return nil, fmt.Errorf("component '%s' is stopping", p.option.Name)
+	}
+
+	option := p.option
+	var (
+		cmd command
+		err error
+	)
+	if option.host != nil {
+		cmd, err = startRemote(option, p.output, p.logger)
+	} else {
+		cmd, err = p.startLocal()
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	pid := strconv.Itoa(cmd.Pid())
+	p.logger.V(3).Infof("run '%s' binary '%s' with args: '%v', env: '%v', log: '%s', pid: '%s'",
+		option.Name, option.Binary, option.args, option.env, option.logDir, pid)
+
+	p.cmd = cmd
+
+	pidFile := path.Join(option.pidDir, "pid")
+	if err := os.WriteFile(pidFile, []byte(pid), 0644); err != nil {
+		return nil, err
+	}
+
+	// The host file records the remote host that the pid belongs to.
+	hostFile := path.Join(option.pidDir, "host")
+	if option.host != nil {
+		if err := os.WriteFile(hostFile, []byte(option.host.Name()), 0644); err != nil {
+			return nil, err
+		}
+	} else if err := os.Remove(hostFile); err != nil && !os.IsNotExist(err) {
+		return nil, err
+	}
+
+	return cmd.Wait, nil
+}
+
+// startLocal starts the binary of the replica on the local host.
+func (p *process) startLocal() (command, error) {
+	option := p.option
+	name, args := option.Binary, option.args
+	if len(option.cgroup) > 0 {
+		name, args = cgroupCommand(option.cgroup, option.Binary, option.args)
+	}
+	cmd := exec.Command(name, args...)
+	// Run the binary in its own process group, so the signals from terminal(e.g. Ctrl+C) won't reach it
+	// and the components can be stopped in order when the cluster is shutting down.
+	cmd.SysProcAttr = &syscall.SysProcAttr{Setpgid: true}
+	if len(option.env) > 0 {
+		cmd.Env = append(os.Environ(), option.env...)
+	}
+	cmd.Dir = option.workingDir
+
+	// output to binary.
+	cmd.Stdout = p.output
+	cmd.Stderr = p.output
+
+	if err := cmd.Start(); err != nil {
+		return nil, err
+	}
+
+	return &localCommand{cmd: cmd}, nil
+}
+
+// run waits for the replica to exit and restarts it according to its restart policy.
+func (p *process) run(ctx context.Context, stop context.CancelFunc, wait func() error) {
+	var (
+		option   = p.option
+		restarts = 0
+		backoff  = option.restart.RestartBackoff
+	)
+	if backoff <= 0 {
+		backoff = defaultRestartBackoff
+	}
+
+	for {
+		err := wait()
+
+		// The process is stopped on purpose, e.g. scaling down, or the whole cluster is shutting down
+		// and the process will be stopped in order.
+		if p.isStopping() || ctx.Err() != nil {
+			return
+		}
+
+		switch option.restart.RestartPolicy {
+		case config.RestartPolicyOnFailure:
+			if err == nil {
+				return
+			}
+		case config.RestartPolicyAlways:
+		default:
+			p.handleFailure(stop, err)
+			return
+		}
+
+		if option.restart.MaxRetries > 0 && restarts >= option.restart.MaxRetries {
+			p.logger.Errorf("component '%s' has been restarted %d times and still exits, give up restarting",
+				option.Name, restarts)
+			p.handleFailure(stop, err)
+			return
+		}
+		restarts++
+
+		p.logger.Warnf("component '%s' (pid '%d') exited: %v, restarting it in %s (%d/%s)",
+			option.Name, p.pid(), exitReason(err), backoff, restarts, maxRetriesView(option.restart.MaxRetries))
+
+		select {
+		case <-time.After(backoff):
+		case <-p.stopped:
+			return
+		case <-ctx.Done():
+			return
+		}
+		if backoff *= 2; backoff > maxRestartBackoff {
+			backoff = maxRestartBackoff
+		}
+
+		if wait, err = p.start(); err != nil {
+			if p.isStopping() {
+				return
+			}
+			p.logger.Errorf("failed to restart component '%s': %v", option.Name, err)
 			stop()
+			return
 		}
-	}()
+		p.logger.Warnf("component '%s' is restarted, pid: '%d'", option.Name, p.pid())
+	}
+}
+
+// handleFailure stops the whole cluster if the replica exits with error and won't be restarted.
+func (p *process) handleFailure(stop context.CancelFunc, err error) {
+	if err == nil {
+		return
+	}
+
+	// Caught signal kill and interrupt error then ignore.
+	if exit, ok := err.(*exec.ExitError); ok {
+		if status, ok := exit.Sys().(syscall.WaitStatus); ok && status.Signaled() {
+			if status.Signal() == syscall.SIGKILL || status.Signal() == syscall.SIGINT {
+				return
+			}
+		}
+	}
+	if exit, ok := err.(*remoteExitError); ok && (exit.signal == syscall.SIGKILL || exit.signal == syscall.SIGINT) {
+		return
+	}
+	p.logger.Errorf("component '%s' binary '%s' (pid '%d') exited with error: %v",
+		p.option.Name, p.option.Binary, p.pid(), err)
+	p.logger.Errorf("args: '%v'", p.option.args)
+
+	// If one component has failed, stop the whole context.
+	stop()
+}
 
+func exitReason(err error) string {
+	if err == nil {
+		return "exit status 0"
+	}
+	return err.Error()
+}
+
+func maxRetriesView(maxRetries int) string {
+	if maxRetries <= 0 {
+		return "unlimited"
+	}
+	return strconv.Itoa(maxRetries)
+}
+
+// restartReplica restarts the process of replica by executing binary and replaces it in processes.
+func restartReplica(ctx context.Context, stop context.CancelFunc, processes []*process, replica int, binary string,
+	wg *sync.WaitGroup, logger logger.Logger) error {
+	if replica < 0 || replica >= len(processes) {
+		return fmt.Errorf("replica %d is out of range, there are %d replicas", replica, len(processes))
+	}
+
+	p, err := restartProcess(ctx, stop, processes[replica], binary, wg, logger)
+	if err != nil {
+		return err
+	}
+	processes[replica] = p
+
+	return nil
+}
+
+// terminateReplicas stops all the processes concurrently, and each one is killed if it doesn't exit within timeout.
+func terminateReplicas(processes []*process, timeout time.Duration) error {
+	if timeout <= 0 {
+		timeout = defaultTerminateTimeout
+	}
+
+	var (
+		wg   sync.WaitGroup
+		errs = make([]error, len(processes))
+	)
+	for i, p := range processes {
+		wg.Add(1)
+		go func(i int, p *process) {
+			defer wg.Done()
+			errs[i] = p.terminate(timeout)
+		}(i, p)
+	}
+	wg.Wait()
+
+	for _, err := range errs {
+		if err != nil {
+			return err
+		}
+	}
 	return nil
 }
+
+// stopReplicas stops the processes whose replica index is not less than replicas from the highest one,
+// removes their pid dirs and returns the remaining processes.
+func stopReplicas(processes []*process, replicas int, pidsDir string, logger logger.Logger) ([]*process, error) {
+	if replicas >= len(processes) {
+		return processes, nil
+	}
+
+	for i := len(processes) - 1; i >= replicas; i-- {
+		p := processes[i]
+		logger.V(0).Infof("Stopping '%s' (pid '%d')", p.option.Name, p.pid())
+		if err := p.terminate(defaultTerminateTimeout); err != nil {
+			return processes[:i+1], err
+		}
+		if err := os.RemoveAll(path.Join(pidsDir, p.option.Name)); err != nil {
+			return processes[:i], err
+		}
+	}
+
+	return processes[:replicas], nil
+}
+
+// scaleGuard keeps the failures of the new replicas from stopping the cluster while they are being scaled out,
+// they only fail the scaling until it's done, and then they stop the cluster by stop like other replicas.
+type scaleGuard struct {
+	mu      sync.Mutex
+	scaling bool
+	stop    context.CancelFunc
+}
+
+func newScaleGuard(stop context.CancelFunc) *scaleGuard {
+	return &scaleGuard{scaling: true, stop: stop}
+}
+
+// cancel is the stop func of the new replicas.
+func (g *scaleGuard) cancel() {
+	g.mu.Lock()
+	scaling := g.scaling
+	g.mu.Unlock()
+
+	if !scaling {
+		g.stop()
+	}
+}
+
+// done is called once the new replicas are ready.
+func (g *scaleGuard) done() {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	g.scaling = false
+}
+
+// rollbackScale stops the new replicas after scaling out to them failed by err,
+// and returns the processes of the replicas before scaling.
+func rollbackScale(processes []*process, replicas int, pidsDir string, logger logger.Logger,
+	err error) ([]*process, error) {
+	logger.Warnf("Scaling failed: %v, stopping the new replicas", err)
+	processes, stopErr := stopReplicas(processes, replicas, pidsDir, logger)
+	if stopErr != nil {
+		return processes, fmt.Errorf("%v, and failed to stop the new replicas: %v", err, stopErr)
+	}
+	return processes, err
+}
@@ -0,0 +1,248 @@
+/*
+ * Copyright 2023 Greptime Team
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package components
+
+import (
+	"bytes"
+	"context"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/GreptimeTeam/gtctl/pkg/components/greptimetest"
+	"github.com/GreptimeTeam/gtctl/pkg/config"
+	"github.com/GreptimeTeam/gtctl/pkg/logger"
+)
+
+func TestReplicaProcessOptions(t *testing.T) {
+	process := config.Process{
+		ProcessOptions: config.ProcessOptions{
+			Env:        map[string]string{"RUST_LOG": "info", "RUST_BACKTRACE": "1"},
+			ExtraArgs:  []string{"--foo=bar"},
+			WorkingDir: "/tmp/component",
+		},
+		ReplicaOverrides: map[int]*config.ProcessOptions{
+			1: {
+				Env:        map[string]string{"RUST_LOG": "debug"},
+				ExtraArgs:  []string{"--baz"},
+				WorkingDir: "/tmp/replica",
+				Host:       "node-1",
+			},
+		},
+	}
+
+	assert.Equal(t, process.ProcessOptions, ReplicaProcessOptions(process, 0))
+	assert.Equal(t, config.ProcessOptions{
+		Env:        map[string]string{"RUST_LOG": "debug", "RUST_BACKTRACE": "1"},
+		ExtraArgs:  []string{"--foo=bar", "--baz"},
+		WorkingDir: "/tmp/replica",
+		Host:       "node-1",
+	}, ReplicaProcessOptions(process, 1))
+
+	// The options of component are not changed by the overrides.
+	assert.Equal(t, "info", process.Env["RUST_LOG"])
+	assert.Equal(t, []string{"--foo=bar"}, process.ExtraArgs)
+}
+
+func TestWithProcessOptions(t *testing.T) {
+	workingDir := t.TempDir() + "/working"
+	option := &RunOptions{args: []string{"start"}}
+
+	err := option.withProcessOptions(config.ProcessOptions{
+		Env:        map[string]string{"B": "2", "A": "1"},
+		ExtraArgs:  []string{"--foo"},
+		WorkingDir: workingDir,
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"start", "--foo"}, option.args)
+	assert.Equal(t, []string{"A=1", "B=2"}, option.env)
+	assert.Equal(t, workingDir, option.workingDir)
+	assert.DirExists(t, workingDir)
+}
+
+func TestTerminate(t *testing.T) {
+	tests := []struct {
+		name       string
+		ignoreTerm bool
+		timeout    time.Duration
+		killed     bool
+	}{
+		// The timeout is generous, so the graceful binary is never killed even if the test runs slowly.
+		{name: "graceful", timeout: 5 * time.Second},
+		{name: "ignore SIGTERM", ignoreTerm: true, timeout: 500 * time.Millisecond, killed: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+			option := &RunOptions{
+				Binary: greptimetest.NewBinary(t, greptimetest.Options{IgnoreTerm: tt.ignoreTerm}),
+				Name:   "frontend.0",
+				logDir: dir,
+				pidDir: dir,
+				log:    &config.Log{},
+				args:   []string{"frontend", "start"},
+			}
+
+			var (
+				wg          sync.WaitGroup
+				ctx, cancel = context.WithCancel(context.Background())
+			)
+			defer cancel()
+			var out syncBuffer
+			p, err := runBinary(ctx, cancel, option, &wg, logger.New(&out, 0))
+			assert.NoError(t, err)
+
+			logFile := filepath.Join(dir, "log")
+			assert.Eventually(t, func() bool {
+				data, _ := os.ReadFile(logFile)
+				return strings.Contains(string(data), "args: ")
+			}, 5*time.Second, 10*time.Millisecond)
+
+			// The binary that ignores SIGTERM is killed after the timeout.
+			start := time.Now()
+			assert.NoError(t, p.terminate(tt.timeout))
+			elapsed := time.Since(start)
+			wg.Wait()
+
+			logs := readFile(t, logFile)
+			if tt.killed {
+				assert.GreaterOrEqual(t, elapsed, tt.timeout)
+				assert.NotContains(t, logs, "terminated")
+				assert.Contains(t, out.String(), "didn't exit within")
+			} else {
+				assert.Contains(t, logs, "terminated")
+				assert.NotContains(t, out.String(), "didn't exit within")
+			}
+			// The replica stopped on purpose is neither restarted nor failing the cluster.
+			assert.NoError(t, ctx.Err())
+		})
+	}
+}
+
+func TestRestart(t *testing.T) {
+	tests := []struct {
+		name    string
+		options greptimetest.Options
+		restart config.Restart
+
+		// runs is the times that the binary runs, stopped is whether the cluster is stopped.
+		runs    int
+		stopped bool
+		logs    []string
+	}{
+		{
+			name:    "never",
+			options: greptimetest.Options{FailArg: "start"},
+			runs:    1,
+			stopped: true,
+		},
+		{
+			name:    "on-failure gives up after the retries",
+			options: greptimetest.Options{FailArg: "start"},
+			restart: config.Restart{RestartPolicy: config.RestartPolicyOnFailure, MaxRetries: 2, RestartBackoff: 20 * time.Millisecond},
+			runs:    3,
+			stopped: true,
+			logs: []string{
+				"restarting it in 20ms (1/2)",
+				"restarting it in 40ms (2/2)",
+				"has been restarted 2 times and still exits, give up restarting",
+			},
+		},
+		{
+			name:    "on-failure doesn't restart the clean exit",
+			options: greptimetest.Options{ExitArg: "start"},
+			restart: config.Restart{RestartPolicy: config.RestartPolicyOnFailure, MaxRetries: 2, RestartBackoff: 20 * time.Millisecond},
+			runs:    1,
+		},
+		{
+			name:    "always restarts the clean exit",
+			options: greptimetest.Options{ExitArg: "start"},
+			restart: config.Restart{RestartPolicy: config.RestartPolicyAlways, MaxRetries: 2, RestartBackoff: 20 * time.Millisecond},
+			runs:    3,
+			logs:    []string{"exited: exit status 0, restarting it in 20ms (1/2)"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+			option := &RunOptions{
+				Binary:  greptimetest.NewBinary(t, tt.options),
+				Name:    "frontend.0",
+				logDir:  dir,
+				pidDir:  dir,
+				log:     &config.Log{},
+				args:    []string{"frontend", "start"},
+				restart: tt.restart,
+			}
+
+			var (
+				wg          sync.WaitGroup
+				out         syncBuffer
+				stops       int32
+				ctx, cancel = context.WithCancel(context.Background())
+			)
+			defer cancel()
+			stop := func() {
+				atomic.AddInt32(&stops, 1)
+				cancel()
+			}
+			p, err := runBinary(ctx, stop, option, &wg, logger.New(&out, 0))
+			assert.NoError(t, err)
+			wg.Wait()
+
+			assert.Equal(t, tt.runs, strings.Count(readFile(t, filepath.Join(dir, "log")), "args: "))
+			if tt.stopped {
+				assert.Equal(t, int32(1), atomic.LoadInt32(&stops))
+			} else {
+				assert.Equal(t, int32(0), atomic.LoadInt32(&stops))
+			}
+			for _, want := range tt.logs {
+				assert.Contains(t, out.String(), want)
+			}
+
+			// The pid file of replica is reused by the restarted process.
+			assert.Equal(t, strconv.Itoa(p.pid()), readFile(t, filepath.Join(dir, "pid")))
+		})
+	}
+}
+
+// syncBuffer is a buffer that the logs can be written to by the goroutines of replicas.
+type syncBuffer struct {
+	mu  sync.Mutex
+	buf bytes.Buffer
+}
+
+func (b *syncBuffer) Write(p []byte) (int, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buf.Write(p)
+}
+
+func (b *syncBuffer) String() string {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buf.String()
+}
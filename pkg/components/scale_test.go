@@ -0,0 +1,160 @@
+/*
+ * Copyright 2023 Greptime Team
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package components
+
+import (
+	"context"
+	"io"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/GreptimeTeam/gtctl/pkg/components/greptimetest"
+	"github.com/GreptimeTeam/gtctl/pkg/config"
+	"github.com/GreptimeTeam/gtctl/pkg/logger"
+)
+
+func testWorkingDirs(t *testing.T) WorkingDirs {
+	dir := t.TempDir()
+	return WorkingDirs{
+		DataDir:    filepath.Join(dir, "data"),
+		LogsDir:    filepath.Join(dir, "logs"),
+		PidsDir:    filepath.Join(dir, "pids"),
+		ConfigsDir: filepath.Join(dir, "configs"),
+	}
+}
+
+func TestDatanodeScale(t *testing.T) {
+	var (
+		wg          sync.WaitGroup
+		ctx, cancel = context.WithCancel(context.Background())
+		binary      = greptimetest.NewBinary(t, greptimetest.Options{})
+		dirs        = testWorkingDirs(t)
+	)
+	defer cancel()
+
+	cfg := &config.Datanode{
+		Replicas:       1,
+		HTTPAddr:       greptimetest.FreeAddr(t, 3),
+		RPCAddr:        "127.0.0.1:14100",
+		StartupTimeout: 10 * time.Second,
+	}
+	d := NewDataNode(cfg, "127.0.0.1:3002", dirs, &config.Log{}, nil, &wg, logger.New(io.Discard, 0)).(*datanode)
+	defer func() {
+		assert.NoError(t, d.Stop(5*time.Second))
+		wg.Wait()
+	}()
+	assert.NoError(t, d.Start(ctx, cancel, binary))
+
+	// The new replicas are started after the existing ones.
+	assert.NoError(t, d.Scale(ctx, cancel, binary, 3))
+	assert.Equal(t, 3, cfg.Replicas)
+	assert.Len(t, d.processes, 3)
+	assert.True(t, d.IsRunning(ctx))
+	for _, name := range []string{"datanode.0", "datanode.1", "datanode.2"} {
+		assert.FileExists(t, filepath.Join(dirs.PidsDir, name, "pid"))
+	}
+
+	// The replicas are stopped from the highest one, and their data remains.
+	stopped := d.processes[1:]
+	assert.NoError(t, d.Scale(ctx, cancel, binary, 1))
+	assert.Equal(t, 1, cfg.Replicas)
+	assert.Len(t, d.processes, 1)
+	for _, p := range stopped {
+		<-p.exited
+	}
+	assert.NoDirExists(t, filepath.Join(dirs.PidsDir, "datanode.1"))
+	assert.NoDirExists(t, filepath.Join(dirs.PidsDir, "datanode.2"))
+	assert.DirExists(t, filepath.Join(dirs.DataDir, "datanode.2", dataHomeDir))
+	assert.True(t, d.IsRunning(ctx))
+
+	// The new replicas are stopped if the scaling fails midway, e.g. the third replica can't run in its working dir.
+	notDir := filepath.Join(t.TempDir(), "file")
+	assert.NoError(t, os.WriteFile(notDir, nil, 0644))
+	cfg.Process.ReplicaOverrides = map[int]*config.ProcessOptions{2: {WorkingDir: filepath.Join(notDir, "work")}}
+	assert.Error(t, d.Scale(ctx, cancel, binary, 3))
+	assert.Equal(t, 1, cfg.Replicas)
+	assert.Len(t, d.processes, 1)
+	assert.NoDirExists(t, filepath.Join(dirs.PidsDir, "datanode.1"))
+	assert.NoError(t, ctx.Err())
+	assert.True(t, d.IsRunning(ctx))
+}
+
+func TestDatanodeScaleExited(t *testing.T) {
+	var (
+		wg          sync.WaitGroup
+		ctx, cancel = context.WithCancel(context.Background())
+		binary      = greptimetest.NewBinary(t, greptimetest.Options{FailArg: "--node-id=1"})
+	)
+	defer cancel()
+
+	cfg := &config.Datanode{
+		Replicas:       1,
+		HTTPAddr:       greptimetest.FreeAddr(t, 2),
+		RPCAddr:        "127.0.0.1:14100",
+		StartupTimeout: 10 * time.Second,
+	}
+	d := NewDataNode(cfg, "127.0.0.1:3002", testWorkingDirs(t), &config.Log{}, nil, &wg,
+		logger.New(io.Discard, 0)).(*datanode)
+	defer func() {
+		assert.NoError(t, d.Stop(5*time.Second))
+		wg.Wait()
+	}()
+	assert.NoError(t, d.Start(ctx, cancel, binary))
+
+	// The new replica that exits before it's ready only fails the scaling, the running replica is not stopped.
+	assert.ErrorContains(t, d.Scale(ctx, cancel, binary, 2), "datanode.1")
+	assert.Equal(t, 1, cfg.Replicas)
+	assert.Len(t, d.processes, 1)
+	assert.NoError(t, ctx.Err())
+	assert.True(t, d.IsRunning(ctx))
+}
+
+func TestFrontendScale(t *testing.T) {
+	var (
+		wg          sync.WaitGroup
+		ctx, cancel = context.WithCancel(context.Background())
+		binary      = greptimetest.NewBinary(t, greptimetest.Options{})
+	)
+	defer cancel()
+
+	cfg := &config.Frontend{
+		Replicas:       2,
+		HTTPAddr:       greptimetest.FreeAddr(t, 3),
+		StartupTimeout: 10 * time.Second,
+	}
+	f := NewFrontend(cfg, "127.0.0.1:3002", nil, testWorkingDirs(t), &config.Log{}, nil, &wg,
+		logger.New(io.Discard, 0)).(*frontend)
+	defer func() {
+		assert.NoError(t, f.Stop(5*time.Second))
+		wg.Wait()
+	}()
+	assert.NoError(t, f.Start(ctx, cancel, binary))
+
+	assert.NoError(t, f.Scale(ctx, cancel, binary, 3))
+	assert.Equal(t, 3, cfg.Replicas)
+	assert.True(t, f.IsRunning(ctx))
+
+	assert.NoError(t, f.Scale(ctx, cancel, binary, 1))
+	assert.Equal(t, 1, cfg.Replicas)
+	assert.Len(t, f.processes, 1)
+	assert.True(t, f.IsRunning(ctx))
+}
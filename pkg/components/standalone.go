@@ -0,0 +1,187 @@
+/*
+ * Copyright 2023 Greptime Team
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package components
+
+import (
+	"context"
+	"fmt"
+	"path"
+	"sync"
+	"time"
+
+	"github.com/GreptimeTeam/gtctl/pkg/config"
+	"github.com/GreptimeTeam/gtctl/pkg/logger"
+	fileutils "github.com/GreptimeTeam/gtctl/pkg/utils/file"
+)
+
+// StandaloneName is the name of standalone component.
+const StandaloneName = "standalone"
+
+type standalone struct {
+	config *config.Standalone
+	tls    *config.TLS
+
+	workingDirs WorkingDirs
+	logConfig   *config.Log
+	hosts       RemoteHosts
+	wg          *sync.WaitGroup
+	logger      logger.Logger
+
+	allocatedDirs
+	processes []*process
+}
+
+func NewStandalone(config *config.Standalone, tls *config.TLS, workingDirs WorkingDirs, logConfig *config.Log,
+	hosts RemoteHosts, wg *sync.WaitGroup, logger logger.Logger) ClusterComponent {
+	return &standalone{
+		config:      config,
+		tls:         tls,
+		workingDirs: workingDirs,
+		logConfig:   logConfig,
+		hosts:       hosts,
+		wg:          wg,
+		logger:      logger,
+	}
+}
+
+func (s *standalone) Name() string {
+	return StandaloneName
+}
+
+func (s *standalone) Start(ctx context.Context, stop context.CancelFunc, binary string) error {
+	if err := s.renderConfigFile(); err != nil {
+		return err
+	}
+
+	dirName := fmt.Sprintf("%s.%d", s.Name(), 0)
+
+	options := ReplicaProcessOptions(s.config.Process, 0)
+	host, err := s.hosts.replicaHost(options)
+	if err != nil {
+		return err
+	}
+	dataDir, err := s.hosts.DataDir(options.Host, s.workingDirs.DataDir)
+	if err != nil {
+		return err
+	}
+
+	homeDir := path.Join(dataDir, dirName, dataHomeDir)
+	if err := ensureDirs(host, homeDir); err != nil {
+		return err
+	}
+	s.dataDirs = append(s.dataDirs, path.Join(s.workingDirs.DataDir, dirName))
+
+	standaloneLogDir := path.Join(s.workingDirs.LogsDir, dirName)
+	if err := fileutils.EnsureDir(standaloneLogDir); err != nil {
+		return err
+	}
+	s.logsDirs = append(s.logsDirs, standaloneLogDir)
+
+	standalonePidDir := path.Join(s.workingDirs.PidsDir, dirName)
+	if err := fileutils.EnsureDir(standalonePidDir); err != nil {
+		return err
+	}
+	s.pidsDirs = append(s.pidsDirs, standalonePidDir)
+
+	option := &RunOptions{
+		Binary:    binary,
+		Name:      dirName,
+		logDir:    standaloneLogDir,
+		pidDir:    standalonePidDir,
+		log:       s.logConfig,
+		args:      s.BuildArgs(homeDir),
+		restart:   s.config.Restart,
+		cgroup:    replicaCgroup(s.workingDirs, dirName, s.config.Resources),
+		resources: s.config.Resources,
+		host:      host,
+	}
+	if err := option.withProcessOptions(options); err != nil {
+		return err
+	}
+	p, err := runBinary(ctx, stop, option, s.wg, s.logger)
+	if err != nil {
+		return err
+	}
+	s.processes = append(s.processes, p)
+
+	return s.WaitUntilReady(ctx)
+}
+
+// Scale is not supported, since standalone always runs in one process.
+func (s *standalone) Scale(_ context.Context, _ context.CancelFunc, _ string, _ int) error {
+	return fmt.Errorf("%s can not be scaled", s.Name())
+}
+
+// configFile returns the config file that standalone runs with, the options and TLS are rendered into it.
+func (s *standalone) configFile() string {
+	generated := len(s.config.Options) > 0 || s.tls.IsEnabled()
+	return configFilePath(s.Name(), s.config.Config, generated, s.workingDirs.ConfigsDir)
+}
+
+func (s *standalone) renderConfigFile() error {
+	options := serverOptions(s.Name(), s.config.Options, s.tls, s.workingDirs.CertsDir)
+	return renderConfigFile(s.Name(), s.config.Config, options, s.workingDirs.ConfigsDir)
+}
+
+func (s *standalone) RestartReplica(ctx context.Context, stop context.CancelFunc, binary string, replica int) error {
+	return restartReplica(ctx, stop, s.processes, replica, binary, s.wg, s.logger)
+}
+
+func (s *standalone) Stop(timeout time.Duration) error {
+	return terminateReplicas(s.processes, timeout)
+}
+
+func (s *standalone) BuildArgs(params ...interface{}) []string {
+	logLevel := s.config.LogLevel
+	if logLevel == "" {
+		logLevel = DefaultLogLevel
+	}
+
+	homeDir := params[0].(string)
+
+	args := []string{
+		fmt.Sprintf("--log-level=%s", logLevel),
+		s.Name(), "start",
+		fmt.Sprintf("--data-home=%s", homeDir),
+	}
+	args = GenerateAddrArg("--http-addr", s.config.HTTPAddr, 0, args)
+	args = GenerateAddrArg("--rpc-addr", s.config.GRPCAddr, 0, args)
+	args = GenerateAddrArg("--mysql-addr", s.config.MysqlAddr, 0, args)
+	args = GenerateAddrArg("--postgres-addr", s.config.PostgresAddr, 0, args)
+
+	if configFile := s.configFile(); len(configFile) > 0 {
+		args = append(args, fmt.Sprintf("-c=%s", configFile))
+	}
+
+	// The standalone authenticates the clients like frontend.
+	return GenerateUserProviderArg(s.config.UserProvider, s.workingDirs.UsersFile, args)
+}
+
+// IsRunning returns true if standalone is healthy.
+func (s *standalone) IsRunning(ctx context.Context) bool {
+	return isReplicasRunning(ctx, s.Name(), 1, s.checkReplica, s.logger)
+}
+
+// WaitUntilReady waits for standalone to be healthy within its startup timeout.
+func (s *standalone) WaitUntilReady(ctx context.Context) error {
+	return waitUntilReady(ctx, s.Name(), s.processes, s.config.StartupTimeout, s.checkReplica)
+}
+
+// checkReplica checks the '/health' endpoint of standalone, it always runs in one replica.
+func (s *standalone) checkReplica(ctx context.Context, _ int) error {
+	return checkHTTPHealth(ctx, s.hosts.AdvertiseAddr(s.config.HTTPAddr, ReplicaProcessOptions(s.config.Process, 0)))
+}
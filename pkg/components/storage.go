@@ -0,0 +1,160 @@
+/*
+ * Copyright 2023 Greptime Team
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package components
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/GreptimeTeam/gtctl/pkg/config"
+)
+
+// gcsDefaultScope is the scope of the service account to access GCS.
+const gcsDefaultScope = "https://www.googleapis.com/auth/devstorage.read_write"
+
+// storageCredentialKey maps the variable of credentials to the storage option of datanode.
+type storageCredentialKey struct {
+	variable string
+	option   string
+}
+
+// storageCredentialKeys are the credentials that each type of storage requires.
+var storageCredentialKeys = map[config.StorageType][]storageCredentialKey{
+	config.StorageTypeS3: {
+		{"AWS_ACCESS_KEY_ID", "access_key_id"},
+		{"AWS_SECRET_ACCESS_KEY", "secret_access_key"},
+	},
+	config.StorageTypeOSS: {
+		{"ALIBABA_CLOUD_ACCESS_KEY_ID", "access_key_id"},
+		{"ALIBABA_CLOUD_ACCESS_KEY_SECRET", "access_key_secret"},
+	},
+	config.StorageTypeAzure: {
+		{"AZURE_STORAGE_ACCOUNT", "account_name"},
+		{"AZURE_STORAGE_KEY", "account_key"},
+	},
+	config.StorageTypeGCS: {
+		{"GOOGLE_APPLICATION_CREDENTIALS", "credential_path"},
+	},
+}
+
+// storageOptions returns the storage options of datanode, and the credentials are read from their source.
+// The environment variables are looked up by getenv.
+func storageOptions(storage *config.Storage, getenv func(string) string) (config.ConfigOptions, error) {
+	options := map[string]interface{}{}
+	switch storage.Type {
+	case config.StorageTypeS3:
+		options["type"], options["bucket"] = "S3", storage.Bucket
+		if len(storage.Region) > 0 {
+			options["region"] = storage.Region
+		}
+	case config.StorageTypeOSS:
+		options["type"], options["bucket"] = "Oss", storage.Bucket
+	case config.StorageTypeAzure:
+		options["type"], options["container"] = "Azblob", storage.Bucket
+	case config.StorageTypeGCS:
+		options["type"], options["bucket"], options["scope"] = "Gcs", storage.Bucket, gcsDefaultScope
+	default:
+		return nil, fmt.Errorf("unsupported storage type '%s'", storage.Type)
+	}
+	if len(storage.Root) > 0 {
+		options["root"] = storage.Root
+	}
+	if len(storage.Endpoint) > 0 {
+		options["endpoint"] = storage.Endpoint
+	}
+
+	if storage.Credentials != nil {
+		credentials, err := storageCredentials(storage, getenv)
+		if err != nil {
+			return nil, err
+		}
+		for option, value := range credentials {
+			options[option] = value
+		}
+	}
+
+	return config.ConfigOptions{"storage": options}, nil
+}
+
+// storageCredentials reads the credentials of storage from their source, and returns them by the storage options.
+func storageCredentials(storage *config.Storage, getenv func(string) string) (map[string]string, error) {
+	lookup := getenv
+	switch source := storage.Credentials; source.Source {
+	case config.CredentialsSourceEnv:
+	case config.CredentialsSourceFile:
+		// The service account key file of GCS is passed to datanode as it is.
+		if storage.Type == config.StorageTypeGCS {
+			path, err := filepath.Abs(source.File)
+			if err != nil {
+				return nil, err
+			}
+			lookup = func(string) string { return path }
+			break
+		}
+
+		variables, err := readCredentialsFile(source.File)
+		if err != nil {
+			return nil, err
+		}
+		lookup = func(key string) string { return variables[key] }
+	default:
+		return nil, fmt.Errorf("unsupported credentials source '%s'", source.Source)
+	}
+
+	credentials := make(map[string]string)
+	for _, key := range storageCredentialKeys[storage.Type] {
+		value := lookup(key.variable)
+		if len(value) == 0 {
+			return nil, fmt.Errorf("no '%s' in the %s credentials of %s storage", key.variable, storage.Credentials.Source, storage.Type)
+		}
+		credentials[key.option] = value
+	}
+	return credentials, nil
+}
+
+// readCredentialsFile reads the variables in 'KEY=VALUE' lines, the empty lines and comments that start with '#'
+// are skipped, and the value can be quoted.
+func readCredentialsFile(file string) (map[string]string, error) {
+	data, err := os.ReadFile(file)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read the credentials file: %v", err)
+	}
+
+	variables := make(map[string]string)
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	for n := 1; scanner.Scan(); n++ {
+		line := strings.TrimSpace(scanner.Text())
+		if len(line) == 0 || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		key, value, ok := strings.Cut(strings.TrimPrefix(line, "export "), "=")
+		if !ok {
+			return nil, fmt.Errorf("invalid line %d in the credentials file '%s'", n, file)
+		}
+		value = strings.TrimSpace(value)
+		if len(value) >= 2 && (value[0] == '"' || value[0] == '\'') && value[len(value)-1] == value[0] {
+			value = value[1 : len(value)-1]
+		}
+		variables[strings.TrimSpace(key)] = value
+	}
+	return variables, scanner.Err()
+}
@@ -0,0 +1,182 @@
+/*
+ * Copyright 2023 Greptime Team
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package components
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/BurntSushi/toml"
+	"github.com/stretchr/testify/assert"
+	"gopkg.in/yaml.v3"
+
+	"github.com/GreptimeTeam/gtctl/pkg/config"
+)
+
+func TestStorageOptions(t *testing.T) {
+	env := map[string]string{
+		"AWS_ACCESS_KEY_ID":              "minioadmin",
+		"AWS_SECRET_ACCESS_KEY":          "minioadmin-secret",
+		"GOOGLE_APPLICATION_CREDENTIALS": "/etc/gcs.json",
+	}
+	getenv := func(key string) string { return env[key] }
+
+	credentialsFile := filepath.Join(t.TempDir(), "oss.env")
+	assert.NoError(t, os.WriteFile(credentialsFile, []byte(`
+# The credentials of OSS.
+export ALIBABA_CLOUD_ACCESS_KEY_ID=LTAI5t
+ALIBABA_CLOUD_ACCESS_KEY_SECRET = "secret=with=equals"
+`), 0600))
+
+	tests := []struct {
+		name    string
+		storage *config.Storage
+		want    map[string]interface{}
+		wantErr string
+	}{
+		{
+			name: "s3 with local server",
+			storage: &config.Storage{
+				Type:        config.StorageTypeS3,
+				Bucket:      "greptimedb",
+				Root:        "/mycluster",
+				Endpoint:    "http://127.0.0.1:9000",
+				Region:      "us-east-1",
+				Credentials: &config.StorageCredentials{Source: config.CredentialsSourceEnv},
+			},
+			want: map[string]interface{}{
+				"type":              "S3",
+				"bucket":            "greptimedb",
+				"root":              "/mycluster",
+				"endpoint":          "http://127.0.0.1:9000",
+				"region":            "us-east-1",
+				"access_key_id":     "minioadmin",
+				"secret_access_key": "minioadmin-secret",
+			},
+		},
+		{
+			name: "oss with credentials file",
+			storage: &config.Storage{
+				Type:     config.StorageTypeOSS,
+				Bucket:   "greptimedb",
+				Endpoint: "https://oss-cn-hangzhou.aliyuncs.com",
+				Credentials: &config.StorageCredentials{
+					Source: config.CredentialsSourceFile,
+					File:   credentialsFile,
+				},
+			},
+			want: map[string]interface{}{
+				"type":              "Oss",
+				"bucket":            "greptimedb",
+				"endpoint":          "https://oss-cn-hangzhou.aliyuncs.com",
+				"access_key_id":     "LTAI5t",
+				"access_key_secret": "secret=with=equals",
+			},
+		},
+		{
+			name: "gcs with env",
+			storage: &config.Storage{
+				Type:        config.StorageTypeGCS,
+				Bucket:      "greptimedb",
+				Credentials: &config.StorageCredentials{Source: config.CredentialsSourceEnv},
+			},
+			want: map[string]interface{}{
+				"type":            "Gcs",
+				"bucket":          "greptimedb",
+				"scope":           gcsDefaultScope,
+				"credential_path": "/etc/gcs.json",
+			},
+		},
+		{
+			name: "azure without credentials",
+			storage: &config.Storage{
+				Type:     config.StorageTypeAzure,
+				Bucket:   "greptimedb",
+				Endpoint: "https://myaccount.blob.core.windows.net",
+			},
+			want: map[string]interface{}{
+				"type":      "Azblob",
+				"container": "greptimedb",
+				"endpoint":  "https://myaccount.blob.core.windows.net",
+			},
+		},
+		{
+			name: "azure with missing credentials",
+			storage: &config.Storage{
+				Type:        config.StorageTypeAzure,
+				Bucket:      "greptimedb",
+				Endpoint:    "https://myaccount.blob.core.windows.net",
+				Credentials: &config.StorageCredentials{Source: config.CredentialsSourceEnv},
+			},
+			wantErr: "no 'AZURE_STORAGE_ACCOUNT' in the env credentials of azure storage",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			options, err := storageOptions(tt.storage, getenv)
+			if len(tt.wantErr) > 0 {
+				assert.ErrorContains(t, err, tt.wantErr)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, config.ConfigOptions{"storage": tt.want}, options)
+		})
+	}
+}
+
+func TestDatanodeRenderStorage(t *testing.T) {
+	t.Setenv("AWS_ACCESS_KEY_ID", "minioadmin")
+	t.Setenv("AWS_SECRET_ACCESS_KEY", "minioadmin-secret")
+
+	configsDir := t.TempDir()
+	d := &datanode{
+		config: &config.Datanode{
+			Storage: &config.Storage{
+				Type:        config.StorageTypeS3,
+				Bucket:      "greptimedb",
+				Endpoint:    "http://127.0.0.1:9000",
+				Credentials: &config.StorageCredentials{Source: config.CredentialsSourceEnv},
+			},
+			Options: config.ConfigOptions{
+				"storage": map[string]interface{}{"bucket": "override"},
+			},
+		},
+		workingDirs: WorkingDirs{ConfigsDir: configsDir},
+	}
+
+	// The credentials are only in the generated config, which is only readable by the user.
+	out, err := yaml.Marshal(d.config)
+	assert.NoError(t, err)
+	assert.NotContains(t, string(out), "minioadmin")
+
+	assert.NoError(t, d.renderConfigFile())
+	assert.Equal(t, filepath.Join(configsDir, "datanode.toml"), d.configFile())
+	info, err := os.Stat(d.configFile())
+	assert.NoError(t, err)
+	assert.Equal(t, os.FileMode(0600), info.Mode().Perm())
+
+	var rendered struct {
+		Storage map[string]interface{} `toml:"storage"`
+	}
+	_, err = toml.DecodeFile(d.configFile(), &rendered)
+	assert.NoError(t, err)
+	assert.Equal(t, "S3", rendered.Storage["type"])
+	assert.Equal(t, "override", rendered.Storage["bucket"])
+	assert.Equal(t, "minioadmin-secret", rendered.Storage["secret_access_key"])
+}
@@ -0,0 +1,49 @@
+/*
+ * Copyright 2023 Greptime Team
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package components
+
+import (
+	"github.com/GreptimeTeam/gtctl/pkg/config"
+	"github.com/GreptimeTeam/gtctl/pkg/utils/certs"
+)
+
+// tlsOptions returns the options that require the clients of MySQL and PostgreSQL servers to connect with TLS,
+// the servers use the certificate of the component in certsDir. The HTTP and gRPC servers have no TLS options.
+func tlsOptions(name, certsDir string) config.ConfigOptions {
+	certFile, keyFile := certs.Files(certsDir, name)
+	tls := func() map[string]interface{} {
+		return map[string]interface{}{
+			"tls": map[string]interface{}{
+				"mode":      "require",
+				"cert_path": certFile,
+				"key_path":  keyFile,
+			},
+		}
+	}
+	return config.ConfigOptions{"mysql": tls(), "postgres": tls()}
+}
+
+// serverOptions returns the options of the component that serves the clients, the options override the TLS
+// options if TLS is enabled.
+func serverOptions(name string, options config.ConfigOptions, tls *config.TLS, certsDir string) config.ConfigOptions {
+	if !tls.IsEnabled() {
+		return options
+	}
+	merged := tlsOptions(name, certsDir)
+	mergeOptions(merged, options)
+	return merged
+}
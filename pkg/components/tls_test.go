@@ -0,0 +1,69 @@
+/*
+ * Copyright 2023 Greptime Team
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package components
+
+import (
+	"fmt"
+	"path/filepath"
+	"testing"
+
+	"github.com/BurntSushi/toml"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/GreptimeTeam/gtctl/pkg/config"
+)
+
+func TestFrontendRenderTLS(t *testing.T) {
+	configsDir, certsDir := t.TempDir(), t.TempDir()
+	f := &frontend{
+		config: &config.Frontend{
+			Replicas: 1,
+			Options: config.ConfigOptions{
+				"postgres": map[string]interface{}{"tls": map[string]interface{}{"mode": "prefer"}},
+			},
+		},
+		tls:         &config.TLS{Enabled: true},
+		workingDirs: WorkingDirs{ConfigsDir: configsDir, CertsDir: certsDir},
+	}
+
+	assert.NoError(t, f.renderConfigFile())
+	assert.Contains(t, f.BuildArgs(0), fmt.Sprintf("-c=%s", filepath.Join(configsDir, "frontend.toml")))
+
+	var rendered struct {
+		MySQL struct {
+			TLS map[string]string `toml:"tls"`
+		} `toml:"mysql"`
+		Postgres struct {
+			TLS map[string]string `toml:"tls"`
+		} `toml:"postgres"`
+	}
+	_, err := toml.DecodeFile(f.configFile(), &rendered)
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]string{
+		"mode":      "require",
+		"cert_path": filepath.Join(certsDir, "frontend.crt"),
+		"key_path":  filepath.Join(certsDir, "frontend.key"),
+	}, rendered.MySQL.TLS)
+
+	// The options override the TLS options.
+	assert.Equal(t, "prefer", rendered.Postgres.TLS["mode"])
+	assert.Equal(t, filepath.Join(certsDir, "frontend.crt"), rendered.Postgres.TLS["cert_path"])
+
+	// The config file of frontend is used as it is without TLS.
+	f.tls, f.config.Options, f.config.Config = nil, nil, "frontend.toml"
+	assert.Equal(t, "frontend.toml", f.configFile())
+}
@@ -18,6 +18,7 @@ package components
 
 import (
 	"context"
+	"time"
 )
 
 const (
@@ -29,6 +30,18 @@ type WorkingDirs struct {
 	DataDir string `yaml:"dataDir"`
 	LogsDir string `yaml:"logsDir"`
 	PidsDir string `yaml:"pidsDir"`
+
+	// ConfigsDir is where the configs generated from the options of components are stored.
+	ConfigsDir string `yaml:"configsDir"`
+
+	// CertsDir is where the certificates of the servers with TLS are stored.
+	CertsDir string `yaml:"certsDir"`
+
+	// UsersFile is the static users that frontend authenticates the clients with, if it exists.
+	UsersFile string `yaml:"usersFile"`
+
+	// Cgroup is the cgroup v2 group that the replicas are placed in, relative to the cgroup root.
+	Cgroup string `yaml:"cgroup"`
 }
 
 // allocatedDirs include all the directories that created during bare-metal mode.
@@ -43,12 +56,29 @@ type ClusterComponent interface {
 	// Start starts cluster component by executing binary.
 	Start(ctx context.Context, stop context.CancelFunc, binary string) error
 
+	// Scale scales the replicas of cluster component to the given number. The new replicas
+	// are started by executing binary, and the redundant replicas are stopped gracefully.
+	// The new replicas that fail before they are all ready only fail the scaling instead of calling stop,
+	// and they are stopped then.
+	Scale(ctx context.Context, stop context.CancelFunc, binary string, replicas int) error
+
+	// RestartReplica stops the replica gracefully and starts it again by executing binary with the same args.
+	RestartReplica(ctx context.Context, stop context.CancelFunc, binary string, replica int) error
+
+	// Stop stops all the replicas of cluster component gracefully by sending SIGTERM,
+	// and the replica is killed if it doesn't exit within timeout.
+	Stop(timeout time.Duration) error
+
 	// BuildArgs build up args for cluster component.
 	BuildArgs(params ...interface{}) []string
 
 	// IsRunning returns the status of current cluster component.
 	IsRunning(ctx context.Context) bool
 
+	// WaitUntilReady waits for all the replicas of cluster component to be healthy within its startup timeout,
+	// the error names the replicas that are not healthy and contains the last lines of their logs.
+	WaitUntilReady(ctx context.Context) error
+
 	// Name return the name of component.
 	Name() string
 }
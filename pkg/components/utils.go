@@ -20,6 +20,9 @@ import (
 	"fmt"
 	"net"
 	"strconv"
+
+	fileutils "github.com/GreptimeTeam/gtctl/pkg/utils/file"
+	"github.com/GreptimeTeam/gtctl/pkg/utils/users"
 )
 
 // FormatAddrArg formats the given addr and nodeId to a valid socket string.
@@ -48,3 +51,32 @@ func GenerateAddrArg(config string, addr string, nodeId int, args []string) []st
 
 	return append(args, fmt.Sprintf("%s=%s", config, socketAddr))
 }
+
+// AdvertiseAddr returns the address that can be used to access the given listening addr,
+// the unspecified host(e.g. '0.0.0.0') is replaced by the loopback address.
+func AdvertiseAddr(addr string) string {
+	host, port, err := net.SplitHostPort(addr)
+	if err != nil {
+		return addr
+	}
+
+	if ip := net.ParseIP(host); len(host) == 0 || (ip != nil && ip.IsUnspecified()) {
+		host = "127.0.0.1"
+	}
+
+	return net.JoinHostPort(host, port)
+}
+
+// GenerateUserProviderArg pushes the user provider arg into args array, the configured user provider takes
+// precedence over the users file that is managed by gtctl, which is only used if it exists.
+func GenerateUserProviderArg(userProvider, usersFile string, args []string) []string {
+	if len(userProvider) == 0 && len(usersFile) > 0 {
+		if exists, _ := fileutils.IsFileExists(usersFile); exists {
+			userProvider = users.FileProviderPrefix + usersFile
+		}
+	}
+	if len(userProvider) == 0 {
+		return args
+	}
+	return append(args, fmt.Sprintf("--user-provider=%s", userProvider))
+}
@@ -28,6 +28,35 @@ type BareMetalClusterMetadata struct {
 	CreationDate  time.Time               `yaml:"creationDate"`
 	ClusterDir    string                  `yaml:"clusterDir"`
 	ForegroundPid int                     `yaml:"foregroundPid"`
+
+	// SupervisorPid is the pid of the background supervisor if the cluster runs in detached mode.
+	SupervisorPid int `yaml:"supervisorPid,omitempty"`
+
+	// UseMemoryMeta indicates whether the metasrv uses the memory storage instead of etcd.
+	UseMemoryMeta bool `yaml:"useMemoryMeta,omitempty"`
+
+	// GreptimeBinPath and EtcdBinPath are the binaries that the cluster runs with,
+	// they are pinned so the cluster is resumed with the same binaries.
+	GreptimeBinPath string `yaml:"greptimeBinPath,omitempty"`
+	EtcdBinPath     string `yaml:"etcdBinPath,omitempty"`
+
+	// State is the lifecycle state of the cluster, it's updated by the process that runs the cluster.
+	State *ClusterState `yaml:"state,omitempty"`
+
+	// DatanodeDirs are the directories that each datanode replica stores its data in,
+	// they may be outside ClusterDir if the dataDir, walDir or procedureDir of datanode is configured.
+	DatanodeDirs []DatanodeDirs `yaml:"datanodeDirs,omitempty"`
+}
+
+// DatanodeDirs are the directories of a datanode replica.
+type DatanodeDirs struct {
+	Name         string `yaml:"name"`
+	DataHome     string `yaml:"dataHome"`
+	WalDir       string `yaml:"walDir"`
+	ProcedureDir string `yaml:"procedureDir,omitempty"`
+
+	// Host is the remote host that the directories are on, it's empty if they are local.
+	Host string `yaml:"host,omitempty"`
 }
 
 // BareMetalClusterConfig is the desired state of a GreptimeDB cluster on bare metal.
@@ -37,15 +66,104 @@ type BareMetalClusterMetadata struct {
 //
 // Each field of BareMetalClusterConfig can also have its own exported method `Validate`.
 type BareMetalClusterConfig struct {
+	// Topology is the topology of the cluster, default is distributed.
+	Topology Topology `yaml:"topology,omitempty" validate:"omitempty,oneof=distributed standalone"`
+
 	Cluster *BareMetalClusterComponentsConfig `yaml:"cluster" validate:"required"`
-	Etcd    *Etcd                             `yaml:"etcd" validate:"required"`
+
+	// Etcd is required in distributed topology.
+	Etcd *Etcd `yaml:"etcd,omitempty"`
+
+	// Log is the rotation of the log files of all the components.
+	Log *Log `yaml:"log"`
+
+	// Hosts are the remote hosts that the replicas can run on over SSH, the replica runs on the host
+	// that its 'host' refers to by name, otherwise it runs locally. Etcd always runs locally.
+	Hosts []*Host `yaml:"hosts,omitempty" validate:"omitempty,dive,required"`
+
+	// TLS is the TLS of the servers that the clients connect to.
+	TLS *TLS `yaml:"tls,omitempty"`
 }
 
+// TLS enables the TLS of the MySQL and PostgreSQL servers of frontend, or standalone in standalone topology.
+//
+// The certificates are signed by a self-signed CA that gtctl generates in the cluster directory, and they are
+// valid for the hosts of the configured addresses and the local host. 'gtctl cluster connect' trusts the CA,
+// and the other clients can trust it by the 'certs/ca.crt' in the cluster directory. The replicas with TLS
+// can't run on the remote hosts. The HTTP and gRPC servers still serve plaintext, since the supported
+// GreptimeDB has no TLS options of them.
+type TLS struct {
+	Enabled bool `yaml:"enabled"`
+
+	// ValidFor is the validity of the server certificates, default is one year. They are renewed when the
+	// cluster is created if they expire within a tenth of it, or by 'gtctl cluster certs rotate'.
+	ValidFor time.Duration `yaml:"validFor,omitempty" validate:"gte=0"`
+}
+
+// IsEnabled returns whether the TLS is enabled.
+func (t *TLS) IsEnabled() bool {
+	return t != nil && t.Enabled
+}
+
+// Host is a remote host that is accessed over SSH.
+//
+// The binaries and configs of components are uploaded to Dir of the host, and the data of cluster is
+// stored in the sub directory of Dir named after the cluster. The logs and pids of the replicas are
+// collected into the cluster directory of gtctl.
+type Host struct {
+	// Name is the name that the 'host' of replicas refers to.
+	Name string `yaml:"name" validate:"required"`
+
+	// Address is the address of SSH server, e.g. '192.168.1.10:22'.
+	Address string `yaml:"address" validate:"required,hostname_port"`
+
+	// User is the user to log in as, default is the current user.
+	User string `yaml:"user,omitempty"`
+
+	// IdentityFile is the private key to authenticate with, default is the keys of SSH agent
+	// and '~/.ssh/id_rsa', '~/.ssh/id_ecdsa', '~/.ssh/id_ed25519'.
+	IdentityFile string `yaml:"identityFile,omitempty" validate:"omitempty,filepath"`
+
+	// KnownHostsFile is used to verify the host key of the host, default is '~/.ssh/known_hosts'.
+	KnownHostsFile string `yaml:"knownHostsFile,omitempty" validate:"omitempty,filepath"`
+
+	// InsecureIgnoreHostKey skips the verification of host key, it should only be used for testing.
+	InsecureIgnoreHostKey bool `yaml:"insecureIgnoreHostKey,omitempty"`
+
+	// Dir is the working directory of gtctl on the host, the relative one is under the home of user.
+	// Default is '.gtctl'.
+	Dir string `yaml:"dir,omitempty"`
+}
+
+// IsStandalone returns whether the cluster runs in standalone topology.
+func (c *BareMetalClusterConfig) IsStandalone() bool {
+	return c.Topology == TopologyStandalone
+}
+
+// Topology is the way that the cluster is deployed.
+type Topology string
+
+const (
+	// TopologyDistributed runs metasrv, datanode and frontend separately, and etcd if the metasrv doesn't
+	// use the memory storage.
+	TopologyDistributed Topology = "distributed"
+
+	// TopologyStandalone runs a single 'greptime standalone' process.
+	TopologyStandalone Topology = "standalone"
+)
+
+// BareMetalClusterComponentsConfig is the config of components. The frontend, meta and datanode are
+// required in distributed topology, and standalone is only used in standalone topology.
 type BareMetalClusterComponentsConfig struct {
-	Artifact *Artifact `yaml:"artifact" validate:"required"`
-	Frontend *Frontend `yaml:"frontend" validate:"required"`
-	MetaSrv  *MetaSrv  `yaml:"meta" validate:"required"`
-	Datanode *Datanode `yaml:"datanode" validate:"required"`
+	Artifact   *Artifact   `yaml:"artifact" validate:"required"`
+	Frontend   *Frontend   `yaml:"frontend,omitempty"`
+	MetaSrv    *MetaSrv    `yaml:"meta,omitempty"`
+	Datanode   *Datanode   `yaml:"datanode,omitempty"`
+	Standalone *Standalone `yaml:"standalone,omitempty"`
+
+	// ShutdownGracePeriod is the duration to wait for each replica to exit after sending SIGTERM
+	// when the cluster is shutting down, the replica is killed after it. Default is 10s.
+	ShutdownGracePeriod time.Duration `yaml:"shutdownGracePeriod" validate:"gte=0"`
 }
 
 type Artifact struct {
@@ -58,9 +176,13 @@ type Artifact struct {
 }
 
 type Datanode struct {
-	NodeID       int    `yaml:"nodeID" validate:"gte=0"`
-	RPCAddr      string `yaml:"rpcAddr" validate:"required,hostname_port"`
-	HTTPAddr     string `yaml:"httpAddr" validate:"required,hostname_port"`
+	NodeID   int    `yaml:"nodeID" validate:"gte=0"`
+	RPCAddr  string `yaml:"rpcAddr" validate:"required,hostname_port"`
+	HTTPAddr string `yaml:"httpAddr" validate:"required,hostname_port"`
+
+	// DataDir, WalDir and ProcedureDir are shared by all the replicas, each replica stores
+	// its data in the sub directory named after itself, e.g. '<walDir>/datanode.0'.
+	// The data and WAL are stored in the cluster directory by default.
 	DataDir      string `yaml:"dataDir" validate:"omitempty,dirpath"`
 	WalDir       string `yaml:"walDir" validate:"omitempty,dirpath"`
 	ProcedureDir string `yaml:"procedureDir" validate:"omitempty,dirpath"`
@@ -68,6 +190,19 @@ type Datanode struct {
 	Replicas int    `yaml:"replicas" validate:"gt=0"`
 	Config   string `yaml:"config" validate:"omitempty,filepath"`
 	LogLevel string `yaml:"logLevel"`
+
+	// Storage is the object storage that the replicas store their data in, default is the local data home.
+	Storage *Storage `yaml:"storage,omitempty"`
+
+	// Options are the options in the TOML config of component, see ConfigOptions.
+	Options ConfigOptions `yaml:"options,omitempty" validate:"omitempty,toml"`
+
+	// StartupTimeout is the timeout of waiting for all the replicas to be healthy after they are started,
+	// scaled or upgraded. Default is 1m.
+	StartupTimeout time.Duration `yaml:"startupTimeout" validate:"gte=0"`
+
+	Restart `yaml:",inline"`
+	Process `yaml:",inline"`
 }
 
 type Frontend struct {
@@ -81,10 +216,21 @@ type Frontend struct {
 	Config       string `yaml:"config" validate:"omitempty,filepath"`
 	LogLevel     string `yaml:"logLevel"`
 	UserProvider string `yaml:"userProvider"`
+
+	// Options are the options in the TOML config of component, see ConfigOptions.
+	Options ConfigOptions `yaml:"options,omitempty" validate:"omitempty,toml"`
+
+	// StartupTimeout is the timeout of waiting for all the replicas to be healthy after they are started,
+	// scaled or upgraded. Default is 1m.
+	StartupTimeout time.Duration `yaml:"startupTimeout" validate:"gte=0"`
+
+	Restart `yaml:",inline"`
+	Process `yaml:",inline"`
 }
 
 type MetaSrv struct {
-	StoreAddr  string `yaml:"storeAddr" validate:"hostname_port"`
+	// StoreAddr is the address of etcd, default is the client addresses of all the etcd members.
+	StoreAddr  string `yaml:"storeAddr" validate:"omitempty,hostname_port"`
 	ServerAddr string `yaml:"serverAddr" validate:"hostname_port"`
 	BindAddr   string `yaml:"bindAddr" validate:"omitempty,hostname_port"`
 	HTTPAddr   string `yaml:"httpAddr" validate:"required,hostname_port"`
@@ -92,10 +238,215 @@ type MetaSrv struct {
 	Replicas int    `yaml:"replicas" validate:"gt=0"`
 	Config   string `yaml:"config" validate:"omitempty,filepath"`
 	LogLevel string `yaml:"logLevel"`
+
+	// Options are the options in the TOML config of component, see ConfigOptions.
+	Options ConfigOptions `yaml:"options,omitempty" validate:"omitempty,toml"`
+
+	// StartupTimeout is the timeout of waiting for all the replicas to be healthy after they are started,
+	// scaled or upgraded. Default is 1m.
+	StartupTimeout time.Duration `yaml:"startupTimeout" validate:"gte=0"`
+
+	Restart `yaml:",inline"`
+	Process `yaml:",inline"`
+}
+
+// Standalone runs all the roles of GreptimeDB in one process, it listens on the same kinds of addresses as frontend.
+type Standalone struct {
+	HTTPAddr     string `yaml:"httpAddr" validate:"required,hostname_port"`
+	GRPCAddr     string `yaml:"grpcAddr" validate:"omitempty,hostname_port"`
+	MysqlAddr    string `yaml:"mysqlAddr" validate:"omitempty,hostname_port"`
+	PostgresAddr string `yaml:"postgresAddr" validate:"omitempty,hostname_port"`
+
+	Config       string `yaml:"config" validate:"omitempty,filepath"`
+	LogLevel     string `yaml:"logLevel"`
+	UserProvider string `yaml:"userProvider"`
+
+	// Options are the options in the TOML config of component, see ConfigOptions.
+	Options ConfigOptions `yaml:"options,omitempty" validate:"omitempty,toml"`
+
+	// StartupTimeout is the timeout of waiting for all the replicas to be healthy after they are started,
+	// scaled or upgraded. Default is 1m.
+	StartupTimeout time.Duration `yaml:"startupTimeout" validate:"gte=0"`
+
+	Restart `yaml:",inline"`
+	Process `yaml:",inline"`
+}
+
+// ConfigOptions are the options of component in the structure of its TOML config, e.g.
+//
+//	options:
+//	  storage:
+//	    type: S3
+//	    bucket: mybucket
+//
+// They are rendered into a generated config in the cluster directory, which is passed to the component by '-c'.
+// The config file of component is merged into the generated config, and the options override the same keys in it.
+type ConfigOptions map[string]interface{}
+
+// StorageType is the type of object storage.
+type StorageType string
+
+const (
+	StorageTypeS3    StorageType = "s3"
+	StorageTypeOSS   StorageType = "oss"
+	StorageTypeGCS   StorageType = "gcs"
+	StorageTypeAzure StorageType = "azure"
+)
+
+// Storage is the object storage that datanode stores its data in, it's rendered into the storage options of datanode.
+type Storage struct {
+	Type StorageType `yaml:"type" validate:"required,oneof=s3 oss gcs azure"`
+
+	// Bucket is the bucket that the data is stored in, it's the container in Azure Blob Storage.
+	Bucket string `yaml:"bucket" validate:"required"`
+
+	// Root is the directory in bucket that the data is stored in, default is the root of bucket.
+	Root string `yaml:"root,omitempty"`
+
+	// Endpoint is the endpoint of storage service, it's required by OSS and Azure.
+	// It can point to an S3-compatible server for S3, e.g. 'http://127.0.0.1:9000' of a local MinIO.
+	Endpoint string `yaml:"endpoint,omitempty" validate:"omitempty,url"`
+
+	// Region is the region of bucket, it's only used by S3.
+	Region string `yaml:"region,omitempty"`
+
+	// Credentials is where the credentials of storage are read from when the cluster starts,
+	// the credentials themselves are never persisted.
+	Credentials *StorageCredentials `yaml:"credentials,omitempty"`
+}
+
+// CredentialsSource is where the credentials of storage are read from.
+type CredentialsSource string
+
+const (
+	// CredentialsSourceEnv reads the credentials from the environment variables of gtctl:
+	//   - s3: AWS_ACCESS_KEY_ID and AWS_SECRET_ACCESS_KEY
+	//   - oss: ALIBABA_CLOUD_ACCESS_KEY_ID and ALIBABA_CLOUD_ACCESS_KEY_SECRET
+	//   - azure: AZURE_STORAGE_ACCOUNT and AZURE_STORAGE_KEY
+	//   - gcs: GOOGLE_APPLICATION_CREDENTIALS, which is the path of service account key file
+	CredentialsSourceEnv CredentialsSource = "env"
+
+	// CredentialsSourceFile reads the credentials from the file. The file has the same variables
+	// as the environment in 'KEY=VALUE' lines, except that it's the service account key file for gcs.
+	CredentialsSourceFile CredentialsSource = "file"
+)
+
+// StorageCredentials is the source of credentials of storage.
+type StorageCredentials struct {
+	Source CredentialsSource `yaml:"source" validate:"required,oneof=env file"`
+
+	// File is the file that the credentials are read from, it's required if the source is file.
+	File string `yaml:"file,omitempty" validate:"omitempty,filepath"`
+}
+
+// RestartPolicy decides whether to restart the replica of component after it exits.
+type RestartPolicy string
+
+const (
+	// RestartPolicyNever never restarts the replica, and the whole cluster is stopped if any replica fails.
+	RestartPolicyNever RestartPolicy = "never"
+
+	// RestartPolicyOnFailure restarts the replica only if it exits with error.
+	RestartPolicyOnFailure RestartPolicy = "on-failure"
+
+	// RestartPolicyAlways restarts the replica whenever it exits.
+	RestartPolicyAlways RestartPolicy = "always"
+)
+
+// Restart is the restart configuration of the component, it applies to each replica.
+type Restart struct {
+	// RestartPolicy is the policy of restarting, default is never.
+	RestartPolicy RestartPolicy `yaml:"restartPolicy" validate:"omitempty,oneof=never on-failure always"`
+
+	// MaxRetries is the max times of restarting one replica, 0 means no limit.
+	// The whole cluster is stopped if the replica still fails after the retries.
+	MaxRetries int `yaml:"maxRetries" validate:"gte=0"`
+
+	// RestartBackoff is the delay before the first restart, and it's doubled after each restart.
+	// Default is 1s.
+	RestartBackoff time.Duration `yaml:"restartBackoff" validate:"gte=0"`
+}
+
+// ProcessOptions are the options of the process of replica.
+type ProcessOptions struct {
+	// Env is the environment variables of the process in addition to the ones of gtctl,
+	// e.g. RUST_LOG and RUST_BACKTRACE.
+	Env map[string]string `yaml:"env,omitempty"`
+
+	// ExtraArgs are appended to the args that gtctl generates, e.g. the flags that gtctl doesn't model.
+	ExtraArgs []string `yaml:"extraArgs,omitempty"`
+
+	// WorkingDir is the working directory of the process, default is the one of gtctl.
+	WorkingDir string `yaml:"workingDir,omitempty" validate:"omitempty,dirpath"`
+
+	// Host is the name of the remote host in 'hosts' that the process runs on, default is local.
+	Host string `yaml:"host,omitempty"`
+}
+
+// Process is the process configuration of the component, it applies to each replica.
+type Process struct {
+	ProcessOptions `yaml:",inline"`
+
+	// ReplicaOverrides overrides the process options of the replica by its index. The env is merged into
+	// the one of component, the extra args are appended after the ones of component, and the working dir
+	// and host replace the ones of component.
+	ReplicaOverrides map[int]*ProcessOptions `yaml:"replicaOverrides,omitempty" validate:"omitempty,dive,keys,gte=0,endkeys,required"`
+
+	// Resources limits the resources of each replica by cgroup v2,
+	// the replica runs without limits if cgroup v2 is not available.
+	Resources *Resources `yaml:"resources,omitempty"`
+}
+
+// Resources are the resource limits of one replica.
+type Resources struct {
+	// Memory is the max memory in the form of Kubernetes quantity, e.g. '512Mi' or '2Gi'.
+	Memory string `yaml:"memory,omitempty"`
+
+	// CPU is the max CPU cores in the form of Kubernetes quantity, e.g. '500m' or '2'.
+	CPU string `yaml:"cpu,omitempty"`
+}
+
+// Log is the configuration of the log files of components. The log file is rotated when
+// its size or age exceeds the limit, and the rotated ones are kept as compressed backups.
+type Log struct {
+	// MaxSize is the max size in megabytes of the log file before it's rotated, default is 100.
+	MaxSize int `yaml:"maxSize" validate:"gte=0"`
+
+	// MaxAge is the max age of the log file before it's rotated, 0 means it's never rotated by age.
+	MaxAge time.Duration `yaml:"maxAge" validate:"gte=0"`
+
+	// MaxBackups is the number of the compressed backups to keep, default is 5.
+	MaxBackups int `yaml:"maxBackups" validate:"gte=0"`
 }
 
 type Etcd struct {
 	Artifact *Artifact `yaml:"artifact" validate:"required"`
+
+	// Replicas is the number of etcd members, default is 1.
+	Replicas int `yaml:"replicas" validate:"gte=0"`
+
+	// ClientAddr is the address that the first member listens on for client traffic,
+	// the port of the following members is increased one by one. Default is 127.0.0.1:2379.
+	ClientAddr string `yaml:"clientAddr" validate:"omitempty,hostname_port"`
+
+	// PeerAddr is the address that the first member listens on for peer traffic,
+	// the port of the following members is increased one by one.
+	// Default is the one right after the client addresses of all members, e.g. 127.0.0.1:2380 for one member.
+	PeerAddr string `yaml:"peerAddr" validate:"omitempty,hostname_port"`
+
+	// HealthCheckTimeout is the timeout of waiting for all the etcd members to be healthy after starting,
+	// default is 10s.
+	HealthCheckTimeout time.Duration `yaml:"healthCheckTimeout" validate:"gte=0"`
+}
+
+// DefaultStandaloneConfig returns the standalone config that listens on the same addresses as the default frontend.
+func DefaultStandaloneConfig() *Standalone {
+	return &Standalone{
+		HTTPAddr:     "0.0.0.0:4000",
+		GRPCAddr:     "0.0.0.0:4001",
+		MysqlAddr:    "0.0.0.0:4002",
+		PostgresAddr: "0.0.0.0:4003",
+	}
 }
 
 func DefaultBareMetalConfig() *BareMetalClusterConfig {
@@ -113,7 +464,6 @@ func DefaultBareMetalConfig() *BareMetalClusterConfig {
 			},
 			MetaSrv: &MetaSrv{
 				Replicas:   1,
-				StoreAddr:  "127.0.0.1:2379",
 				ServerAddr: "0.0.0.0:3002",
 				HTTPAddr:   "0.0.0.0:14001",
 			},
@@ -127,6 +477,12 @@ func DefaultBareMetalConfig() *BareMetalClusterConfig {
 			Artifact: &Artifact{
 				Version: artifacts.DefaultEtcdBinVersion,
 			},
+			Replicas:   1,
+			ClientAddr: "127.0.0.1:2379",
+		},
+		Log: &Log{
+			MaxSize:    100,
+			MaxBackups: 5,
 		},
 	}
 }
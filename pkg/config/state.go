@@ -0,0 +1,155 @@
+/*
+ * Copyright 2023 Greptime Team
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package config
+
+import (
+	"fmt"
+	"time"
+)
+
+// ClusterPhase is the phase in the lifecycle of a bare-metal cluster.
+type ClusterPhase string
+
+const (
+	// ClusterPhaseCreating means the components of cluster are being started.
+	ClusterPhaseCreating ClusterPhase = "Creating"
+	// ClusterPhaseRunning means all the components of cluster are healthy.
+	ClusterPhaseRunning ClusterPhase = "Running"
+	// ClusterPhaseDegraded means the cluster is running but some components are unhealthy.
+	ClusterPhaseDegraded ClusterPhase = "Degraded"
+	// ClusterPhaseStopping means the components of cluster are being stopped.
+	ClusterPhaseStopping ClusterPhase = "Stopping"
+	// ClusterPhaseStopped means the cluster is stopped on purpose, it can be started again.
+	ClusterPhaseStopped ClusterPhase = "Stopped"
+	// ClusterPhaseFailed means the cluster failed to start or stopped because of the failure of components,
+	// or the process that ran it was gone.
+	ClusterPhaseFailed ClusterPhase = "Failed"
+)
+
+// ComponentPhase is the phase of a component in a bare-metal cluster.
+type ComponentPhase string
+
+const (
+	ComponentPhaseStarting  ComponentPhase = "Starting"
+	ComponentPhaseRunning   ComponentPhase = "Running"
+	ComponentPhaseUnhealthy ComponentPhase = "Unhealthy"
+	ComponentPhaseStopped   ComponentPhase = "Stopped"
+	ComponentPhaseFailed    ComponentPhase = "Failed"
+)
+
+// clusterTransitions are the phases that the cluster can transit to from each phase.
+// The cluster that has no state is regarded as stopped.
+var clusterTransitions = map[ClusterPhase][]ClusterPhase{
+	ClusterPhaseCreating: {ClusterPhaseRunning, ClusterPhaseStopping, ClusterPhaseFailed},
+	ClusterPhaseRunning:  {ClusterPhaseDegraded, ClusterPhaseStopping, ClusterPhaseFailed},
+	ClusterPhaseDegraded: {ClusterPhaseRunning, ClusterPhaseStopping, ClusterPhaseFailed},
+	ClusterPhaseStopping: {ClusterPhaseStopped, ClusterPhaseFailed},
+	ClusterPhaseStopped:  {ClusterPhaseCreating},
+	ClusterPhaseFailed:   {ClusterPhaseCreating},
+}
+
+// ClusterState is the persisted lifecycle state of a bare-metal cluster.
+type ClusterState struct {
+	Phase ClusterPhase `yaml:"phase"`
+	// Reason explains why the cluster is in the phase, e.g. the error that fails the cluster.
+	Reason string `yaml:"reason,omitempty"`
+	// Since is the time that the cluster entered the phase.
+	Since time.Time `yaml:"since"`
+	// UpdatedAt is the last time that the state was updated.
+	UpdatedAt time.Time `yaml:"updatedAt"`
+
+	// Pid is the process that runs the cluster in its active phases, which is either the foreground process
+	// or the background supervisor.
+	Pid int `yaml:"pid,omitempty"`
+
+	// Components are the states of components in the order of starting.
+	Components []*ComponentState `yaml:"components,omitempty"`
+}
+
+// ComponentState is the state of a component in the cluster.
+type ComponentState struct {
+	Name   string         `yaml:"name"`
+	Phase  ComponentPhase `yaml:"phase"`
+	Reason string         `yaml:"reason,omitempty"`
+	Since  time.Time      `yaml:"since"`
+}
+
+// IsActive returns true if the cluster is run by a process, i.e. it's creating, running, degraded or stopping.
+func (s *ClusterState) IsActive() bool {
+	switch s.Phase {
+	case ClusterPhaseCreating, ClusterPhaseRunning, ClusterPhaseDegraded, ClusterPhaseStopping:
+		return true
+	default:
+		return false
+	}
+}
+
+// Transit moves the cluster to the phase with the reason, it fails if the transition is not allowed.
+// Only the reason is updated if the cluster is already in the phase.
+func (s *ClusterState) Transit(phase ClusterPhase, reason string, now time.Time) error {
+	current := s.Phase
+	if len(current) == 0 {
+		current = ClusterPhaseStopped
+	}
+
+	if current != phase {
+		allowed := false
+		for _, next := range clusterTransitions[current] {
+			if next == phase {
+				allowed = true
+				break
+			}
+		}
+		if !allowed {
+			return fmt.Errorf("cluster can not transit from '%s' to '%s'", current, phase)
+		}
+		s.Since = now
+	}
+
+	s.Phase, s.Reason, s.UpdatedAt = phase, reason, now
+	return nil
+}
+
+// Component returns the state of component by its name, it's nil if the component has no state.
+func (s *ClusterState) Component(name string) *ComponentState {
+	for _, component := range s.Components {
+		if component.Name == name {
+			return component
+		}
+	}
+	return nil
+}
+
+// SetComponent records the phase of component with the reason, and returns whether the state is changed.
+// The component that has no state is appended to the end.
+func (s *ClusterState) SetComponent(name string, phase ComponentPhase, reason string, now time.Time) bool {
+	component := s.Component(name)
+	if component == nil {
+		component = &ComponentState{Name: name}
+		s.Components = append(s.Components, component)
+	}
+	if component.Phase == phase && component.Reason == reason {
+		return false
+	}
+
+	if component.Phase != phase {
+		component.Since = now
+	}
+	component.Phase, component.Reason = phase, reason
+	s.UpdatedAt = now
+	return true
+}
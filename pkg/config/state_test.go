@@ -0,0 +1,94 @@
+/*
+ * Copyright 2023 Greptime Team
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package config
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestClusterStateTransit(t *testing.T) {
+	var (
+		state = &ClusterState{}
+		begin = time.Date(2023, 8, 1, 10, 0, 0, 0, time.UTC)
+	)
+
+	// The cluster that has no state is stopped, so it can only be created.
+	assert.Error(t, state.Transit(ClusterPhaseRunning, "", begin))
+	assert.NoError(t, state.Transit(ClusterPhaseCreating, "", begin))
+	assert.True(t, state.IsActive())
+	assert.Equal(t, begin, state.Since)
+
+	steps := []struct {
+		phase ClusterPhase
+		ok    bool
+	}{
+		{ClusterPhaseRunning, true},
+		{ClusterPhaseCreating, false},
+		{ClusterPhaseDegraded, true},
+		{ClusterPhaseRunning, true},
+		{ClusterPhaseStopping, true},
+		{ClusterPhaseRunning, false},
+		{ClusterPhaseStopped, true},
+		{ClusterPhaseFailed, false},
+		{ClusterPhaseCreating, true},
+		{ClusterPhaseFailed, true},
+		{ClusterPhaseCreating, true},
+	}
+	for i, step := range steps {
+		err := state.Transit(step.phase, "", begin.Add(time.Duration(i+1)*time.Minute))
+		if step.ok {
+			assert.NoError(t, err, "step %d: %s", i, step.phase)
+			assert.Equal(t, step.phase, state.Phase)
+		} else {
+			assert.Error(t, err, "step %d: %s", i, step.phase)
+		}
+	}
+	assert.True(t, state.IsActive())
+
+	// Only the reason and update time are changed in the same phase.
+	since := state.Since
+	assert.NoError(t, state.Transit(ClusterPhaseCreating, "retrying", since.Add(time.Hour)))
+	assert.Equal(t, since, state.Since)
+	assert.Equal(t, "retrying", state.Reason)
+	assert.Equal(t, since.Add(time.Hour), state.UpdatedAt)
+}
+
+func TestClusterStateSetComponent(t *testing.T) {
+	var (
+		state = &ClusterState{}
+		begin = time.Date(2023, 8, 1, 10, 0, 0, 0, time.UTC)
+	)
+
+	assert.True(t, state.SetComponent("metasrv", ComponentPhaseStarting, "", begin))
+	assert.True(t, state.SetComponent("datanode", ComponentPhaseStarting, "", begin))
+	assert.True(t, state.SetComponent("metasrv", ComponentPhaseRunning, "", begin.Add(time.Minute)))
+	assert.False(t, state.SetComponent("metasrv", ComponentPhaseRunning, "", begin.Add(2*time.Minute)))
+
+	// The order of starting is kept.
+	assert.Equal(t, []*ComponentState{
+		{Name: "metasrv", Phase: ComponentPhaseRunning, Since: begin.Add(time.Minute)},
+		{Name: "datanode", Phase: ComponentPhaseStarting, Since: begin},
+	}, state.Components)
+
+	assert.True(t, state.SetComponent("metasrv", ComponentPhaseRunning, "slow", begin.Add(3*time.Minute)))
+	assert.Equal(t, begin.Add(time.Minute), state.Component("metasrv").Since)
+	assert.Equal(t, begin.Add(3*time.Minute), state.UpdatedAt)
+	assert.Nil(t, state.Component("frontend"))
+}
@@ -18,8 +18,11 @@ package config
 
 import (
 	"fmt"
+	"io"
 
+	"github.com/BurntSushi/toml"
 	"github.com/go-playground/validator/v10"
+	"k8s.io/apimachinery/pkg/api/resource"
 )
 
 var validate *validator.Validate
@@ -35,6 +38,25 @@ func ValidateConfig(config *BareMetalClusterConfig) error {
 	// Register custom validation method for Artifact.
 	validate.RegisterStructValidation(ValidateArtifact, Artifact{})
 
+	// Register custom validation method for Resources.
+	validate.RegisterStructValidation(ValidateResources, Resources{})
+
+	// Register custom validation method for Storage.
+	validate.RegisterStructValidation(ValidateStorage, Storage{})
+
+	// Register custom validation tag for the options that are rendered into TOML config.
+	if err := validate.RegisterValidation("toml", ValidateTOML); err != nil {
+		return err
+	}
+
+	// Register custom validation method for the components that required by topology,
+	// and the hosts that the components run on.
+	validate.RegisterStructValidation(func(sl validator.StructLevel) {
+		ValidateTopology(sl)
+		ValidateHosts(sl)
+		ValidateTLS(sl)
+	}, BareMetalClusterConfig{})
+
 	err := validate.Struct(config)
 	if err != nil {
 		return err
@@ -49,3 +71,142 @@ func ValidateArtifact(sl validator.StructLevel) {
 		sl.ReportError(sl.Current().Interface(), "Artifact", "Version/Local", "", "")
 	}
 }
+
+func ValidateResources(sl validator.StructLevel) {
+	resources := sl.Current().Interface().(Resources)
+	if len(resources.Memory) > 0 {
+		if q, err := resource.ParseQuantity(resources.Memory); err != nil || q.Sign() <= 0 {
+			sl.ReportError(resources.Memory, "Memory", "Memory", "quantity", "")
+		}
+	}
+	if len(resources.CPU) > 0 {
+		if q, err := resource.ParseQuantity(resources.CPU); err != nil || q.Sign() <= 0 {
+			sl.ReportError(resources.CPU, "CPU", "CPU", "quantity", "")
+		}
+	}
+}
+
+func ValidateStorage(sl validator.StructLevel) {
+	storage := sl.Current().Interface().(Storage)
+	switch storage.Type {
+	case StorageTypeOSS, StorageTypeAzure:
+		if len(storage.Endpoint) == 0 {
+			sl.ReportError(storage.Endpoint, "Endpoint", "Endpoint", "required", "")
+		}
+	}
+	if len(storage.Region) > 0 && storage.Type != StorageTypeS3 {
+		sl.ReportError(storage.Region, "Region", "Region", "s3_only", "")
+	}
+
+	if credentials := storage.Credentials; credentials != nil {
+		fileSource := credentials.Source == CredentialsSourceFile
+		if fileSource != (len(credentials.File) > 0) {
+			sl.ReportError(credentials.File, "Credentials.File", "File", "required_if_file_source", "")
+		}
+	}
+}
+
+// ValidateTOML checks whether the field can be encoded in TOML, e.g. the keys of tables must be strings.
+func ValidateTOML(fl validator.FieldLevel) bool {
+	return toml.NewEncoder(io.Discard).Encode(fl.Field().Interface()) == nil
+}
+
+func ValidateTopology(sl validator.StructLevel) {
+	config := sl.Current().Interface().(BareMetalClusterConfig)
+	if config.Cluster == nil {
+		return
+	}
+
+	if config.IsStandalone() {
+		if config.Cluster.Standalone == nil {
+			sl.ReportError(config.Cluster.Standalone, "Cluster.Standalone", "Standalone", "required", "")
+		}
+		return
+	}
+
+	if config.Cluster.Frontend == nil {
+		sl.ReportError(config.Cluster.Frontend, "Cluster.Frontend", "Frontend", "required", "")
+	}
+	if config.Cluster.MetaSrv == nil {
+		sl.ReportError(config.Cluster.MetaSrv, "Cluster.MetaSrv", "MetaSrv", "required", "")
+	}
+	if config.Cluster.Datanode == nil {
+		sl.ReportError(config.Cluster.Datanode, "Cluster.Datanode", "Datanode", "required", "")
+	}
+	if config.Etcd == nil {
+		sl.ReportError(config.Etcd, "Etcd", "Etcd", "required", "")
+	}
+}
+
+func ValidateHosts(sl validator.StructLevel) {
+	config := sl.Current().Interface().(BareMetalClusterConfig)
+
+	names := make(map[string]bool, len(config.Hosts))
+	for i, host := range config.Hosts {
+		if host == nil {
+			continue
+		}
+		if names[host.Name] {
+			sl.ReportError(host.Name, fmt.Sprintf("Hosts[%d].Name", i), "Name", "unique", "")
+		}
+		names[host.Name] = true
+	}
+
+	if config.Cluster == nil {
+		return
+	}
+
+	checkHost := func(field, host string) {
+		if len(host) > 0 && !names[host] {
+			sl.ReportError(host, field, "Host", "host", "")
+		}
+	}
+	checkProcess := func(component string, process *Process) {
+		checkHost(fmt.Sprintf("Cluster.%s.Process.Host", component), process.Host)
+		for replica, override := range process.ReplicaOverrides {
+			if override != nil {
+				checkHost(fmt.Sprintf("Cluster.%s.Process.ReplicaOverrides[%d].Host", component, replica), override.Host)
+			}
+		}
+	}
+
+	if config.Cluster.Frontend != nil {
+		checkProcess("Frontend", &config.Cluster.Frontend.Process)
+	}
+	if config.Cluster.MetaSrv != nil {
+		checkProcess("MetaSrv", &config.Cluster.MetaSrv.Process)
+	}
+	if config.Cluster.Datanode != nil {
+		checkProcess("Datanode", &config.Cluster.Datanode.Process)
+	}
+	if config.Cluster.Standalone != nil {
+		checkProcess("Standalone", &config.Cluster.Standalone.Process)
+	}
+}
+
+// ValidateTLS validates that the replicas with TLS run locally, where their certificates are.
+func ValidateTLS(sl validator.StructLevel) {
+	config := sl.Current().Interface().(BareMetalClusterConfig)
+	if !config.TLS.IsEnabled() || config.Cluster == nil {
+		return
+	}
+
+	checkProcess := func(component string, process *Process) {
+		if len(process.Host) > 0 {
+			sl.ReportError(process.Host, fmt.Sprintf("Cluster.%s.Process.Host", component), "Host", "local_with_tls", "")
+		}
+		for replica, override := range process.ReplicaOverrides {
+			if override != nil && len(override.Host) > 0 {
+				sl.ReportError(override.Host, fmt.Sprintf("Cluster.%s.Process.ReplicaOverrides[%d].Host", component, replica),
+					"Host", "local_with_tls", "")
+			}
+		}
+	}
+
+	if config.Cluster.Frontend != nil {
+		checkProcess("Frontend", &config.Cluster.Frontend.Process)
+	}
+	if config.Cluster.Standalone != nil {
+		checkProcess("Standalone", &config.Cluster.Standalone.Process)
+	}
+}
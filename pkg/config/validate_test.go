@@ -36,6 +36,19 @@ func TestValidateConfig(t *testing.T) {
 			name:   "valid_config",
 			expect: true,
 		},
+		{
+			name:   "valid_standalone",
+			expect: true,
+		},
+		{
+			name:   "invalid_topology",
+			expect: false,
+			errKey: []string{
+				"Config.Cluster.MetaSrv",
+				"Config.Cluster.Datanode",
+				"Config.Etcd",
+			},
+		},
 		{
 			name:   "invalid_hostname_port",
 			expect: false,
@@ -52,6 +65,64 @@ func TestValidateConfig(t *testing.T) {
 				"Config.Cluster.Datanode.Replicas",
 			},
 		},
+		{
+			name:   "invalid_restart",
+			expect: false,
+			errKey: []string{
+				"Config.Cluster.Datanode.Restart.RestartPolicy",
+				"Config.Cluster.Datanode.Restart.MaxRetries",
+			},
+		},
+		{
+			name:   "invalid_process",
+			expect: false,
+			errKey: []string{
+				"Config.Cluster.Datanode.Process.ReplicaOverrides[-1]",
+				"Config.Cluster.Datanode.Process.ReplicaOverrides[1]",
+			},
+		},
+		{
+			name:   "invalid_resources",
+			expect: false,
+			errKey: []string{
+				"Config.Cluster.Frontend.Process.Resources.Memory",
+				"Config.Cluster.Datanode.Process.Resources.CPU",
+			},
+		},
+		{
+			name:   "invalid_options",
+			expect: false,
+			errKey: []string{
+				"Config.Cluster.Datanode.Options",
+			},
+		},
+		{
+			name:   "invalid_storage",
+			expect: false,
+			errKey: []string{
+				"Config.Cluster.Datanode.Storage.Endpoint",
+				"Config.Cluster.Datanode.Storage.Region",
+				"Config.Cluster.Datanode.Storage.Credentials.File",
+			},
+		},
+		{
+			name:   "invalid_hosts",
+			expect: false,
+			errKey: []string{
+				"Config.Hosts[1].Address",
+				"Config.Hosts[1].Name",
+				"Config.Cluster.Frontend.Process.Host",
+				"Config.Cluster.Datanode.Process.ReplicaOverrides[2].Host",
+			},
+		},
+		{
+			name:   "invalid_tls",
+			expect: false,
+			errKey: []string{
+				"Config.Cluster.Frontend.Process.ReplicaOverrides[1].Host",
+				"Config.TLS.ValidFor",
+			},
+		},
 		{
 			name:   "invalid_artifact",
 			expect: false,
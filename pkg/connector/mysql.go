@@ -38,13 +38,19 @@ const (
 
 	mySQLPortArg = "-P"
 	mySQLHostArg = "-h"
+	mySQLUserArg = "-u"
+
+	mySQLPasswordEnv = "MYSQL_PWD="
+
+	mySQLSSLCAArg   = "--ssl-ca="
+	mySQLSSLModeArg = "--ssl-mode=VERIFY_IDENTITY"
 
 	kubectl     = "kubectl"
 	portForward = "port-forward"
 )
 
 // Mysql connects to a GreptimeDB cluster using mysql protocol.
-func Mysql(port, clusterName string, l logger.Logger) error {
+func Mysql(port, clusterName string, options *ClientOptions, l logger.Logger) error {
 	waitGroup := sync.WaitGroup{}
 
 	// TODO: is there any elegant way to enable port-forward?
@@ -74,8 +80,8 @@ func Mysql(port, clusterName string, l logger.Logger) error {
 		cfg := mysql.Config{
 			Net:                  mySQLDefaultNet,
 			Addr:                 net.JoinHostPort(mySQLDefaultAddr, port),
-			User:                 "",
-			Passwd:               "",
+			User:                 options.User,
+			Passwd:               options.Password,
 			DBName:               "",
 			AllowNativePasswords: true,
 		}
@@ -99,7 +105,7 @@ func Mysql(port, clusterName string, l logger.Logger) error {
 		break
 	}
 
-	cmd = mysqlCommand(port)
+	cmd = mysqlCommand(mySQLDefaultAddr, port, options)
 	cmd.Stdout = os.Stdout
 	cmd.Stderr = os.Stderr
 	cmd.Stdin = os.Stdin
@@ -126,6 +132,41 @@ func Mysql(port, clusterName string, l logger.Logger) error {
 	return nil
 }
 
-func mysqlCommand(port string) *exec.Cmd {
-	return exec.Command(mySQLDriver, mySQLHostArg, mySQLDefaultAddr, mySQLPortArg, port)
+// MysqlWithAddr connects to a GreptimeDB cluster using mysql protocol by the address that frontend listens on.
+// The port-forwarding is not needed because the address is reachable directly, like the cluster in bare-metal.
+func MysqlWithAddr(addr string, options *ClientOptions, l logger.Logger) error {
+	host, port, err := net.SplitHostPort(addr)
+	if err != nil {
+		return err
+	}
+
+	cmd := mysqlCommand(host, port, options)
+	cmd.Stdout = os.Stdout
+	cmd.Stderr = os.Stderr
+	cmd.Stdin = os.Stdin
+	if err = cmd.Start(); err != nil {
+		l.Errorf("Error starting mysql client: %v", err)
+		return err
+	}
+
+	if err = cmd.Wait(); err != nil {
+		l.Errorf("Error waiting for mysql client to finish: %v", err)
+		return err
+	}
+
+	return nil
+}
+
+// mysqlCommand returns the mysql client command, the password is passed by the environment variable
+// so that it's not visible in the process list.
+func mysqlCommand(host, port string, options *ClientOptions) *exec.Cmd {
+	cmd := exec.Command(mySQLDriver, mySQLHostArg, host, mySQLPortArg, port)
+	if len(options.CAFile) > 0 {
+		cmd.Args = append(cmd.Args, mySQLSSLCAArg+options.CAFile, mySQLSSLModeArg)
+	}
+	if len(options.User) > 0 {
+		cmd.Args = append(cmd.Args, mySQLUserArg, options.User)
+		cmd.Env = append(os.Environ(), mySQLPasswordEnv+options.Password)
+	}
+	return cmd
 }
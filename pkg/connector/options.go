@@ -0,0 +1,27 @@
+/*
+ * Copyright 2023 Greptime Team
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package connector
+
+// ClientOptions are the options of the client that connects to a GreptimeDB cluster.
+type ClientOptions struct {
+	// CAFile is the CA that the certificate of server is verified by, the client connects with TLS if it's set.
+	CAFile string
+
+	// User and Password are the credentials that the client authenticates with if User is set.
+	User     string
+	Password string
+}
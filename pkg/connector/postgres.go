@@ -39,10 +39,15 @@ const (
 	postgresSQLHostArg     = "-h"
 	postgresSQLPortArg     = "-p"
 	postgresSQLDatabaseArg = "-d"
+	postgresSQLUserArg     = "-U"
+
+	postgresSQLSSLModeEnv     = "PGSSLMODE=verify-full"
+	postgresSQLSSLRootCertEnv = "PGSSLROOTCERT="
+	postgresSQLPasswordEnv    = "PGPASSWORD="
 )
 
 // PostgresSQL connects to a GreptimeDB cluster using postgres protocol.
-func PostgresSQL(port, clusterName string, l logger.Logger) error {
+func PostgresSQL(port, clusterName string, options *ClientOptions, l logger.Logger) error {
 	waitGroup := sync.WaitGroup{}
 
 	// TODO: is there any elegant way to enable port-forward?
@@ -81,6 +86,8 @@ func PostgresSQL(port, clusterName string, l logger.Logger) error {
 			Addr:     net.JoinHostPort(postgresSQLDefaultAddr, port),
 			Network:  postgresSQLDefaultNet,
 			Database: postgresSQLDatabaseName,
+			User:     options.User,
+			Password: options.Password,
 		}
 		db := pg.Connect(opt)
 		if _, err := db.Exec("SELECT 1"); err == nil {
@@ -88,7 +95,7 @@ func PostgresSQL(port, clusterName string, l logger.Logger) error {
 		}
 	}
 
-	cmd = postgresSQLCommand(port)
+	cmd = postgresSQLCommand(postgresSQLDefaultAddr, port, options)
 	cmd.Stdout = os.Stdout
 	cmd.Stderr = os.Stderr
 	cmd.Stdin = os.Stdin
@@ -115,7 +122,47 @@ func PostgresSQL(port, clusterName string, l logger.Logger) error {
 	return nil
 }
 
-func postgresSQLCommand(port string) *exec.Cmd {
-	return exec.Command(postgresSQLDriver, postgresSQLHostArg, postgresSQLDefaultAddr,
+// PostgresSQLWithAddr connects to a GreptimeDB cluster using postgres protocol by the address that frontend listens on.
+// The port-forwarding is not needed because the address is reachable directly, like the cluster in bare-metal.
+func PostgresSQLWithAddr(addr string, options *ClientOptions, l logger.Logger) error {
+	host, port, err := net.SplitHostPort(addr)
+	if err != nil {
+		return err
+	}
+
+	cmd := postgresSQLCommand(host, port, options)
+	cmd.Stdout = os.Stdout
+	cmd.Stderr = os.Stderr
+	cmd.Stdin = os.Stdin
+	if err = cmd.Start(); err != nil {
+		l.Errorf("Error starting pg: %v", err)
+		return err
+	}
+
+	if err = cmd.Wait(); err != nil {
+		l.Errorf("Error waiting for pg client to finish: %v", err)
+		return err
+	}
+
+	return nil
+}
+
+// postgresSQLCommand returns the psql command, the password is passed by the environment variable
+// so that it's not visible in the process list.
+func postgresSQLCommand(host, port string, options *ClientOptions) *exec.Cmd {
+	cmd := exec.Command(postgresSQLDriver, postgresSQLHostArg, host,
 		postgresSQLPortArg, port, postgresSQLDatabaseArg, postgresSQLDatabaseName)
+
+	var env []string
+	if len(options.CAFile) > 0 {
+		env = append(env, postgresSQLSSLModeEnv, postgresSQLSSLRootCertEnv+options.CAFile)
+	}
+	if len(options.User) > 0 {
+		cmd.Args = append(cmd.Args, postgresSQLUserArg, options.User)
+		env = append(env, postgresSQLPasswordEnv+options.Password)
+	}
+	if len(env) > 0 {
+		cmd.Env = append(os.Environ(), env...)
+	}
+	return cmd
 }
@@ -20,6 +20,7 @@ import (
 	"bytes"
 	"context"
 	"fmt"
+	"io"
 	"path/filepath"
 	"strings"
 	"sync"
@@ -75,7 +76,7 @@ func NewClient(kubeconfig string) (*Client, error) {
 	// use the current context in kubeconfig
 	config, err := clientcmd.BuildConfigFromFlags("", kubeconfig)
 	if err != nil {
-		panic(err.Error())
+		return nil, err
 	}
 
 	kubeClient, err := kubernetes.NewForConfig(config)
@@ -222,6 +223,39 @@ func (c *Client) DeleteEtcdCluster(ctx context.Context, name, namespace string)
 	return nil
 }
 
+// GetSecret gets the secret, the error is NotFound if it doesn't exist.
+func (c *Client) GetSecret(ctx context.Context, name, namespace string) (*corev1.Secret, error) {
+	return c.kubeClient.CoreV1().Secrets(namespace).Get(ctx, name, metav1.GetOptions{})
+}
+
+// CreateOrUpdateSecret creates the secret, or updates it if it already exists.
+func (c *Client) CreateOrUpdateSecret(ctx context.Context, secret *corev1.Secret) error {
+	secrets := c.kubeClient.CoreV1().Secrets(secret.Namespace)
+	_, err := secrets.Create(ctx, secret, metav1.CreateOptions{})
+	if errors.IsAlreadyExists(err) {
+		_, err = secrets.Update(ctx, secret, metav1.UpdateOptions{})
+	}
+	return err
+}
+
+// DeleteSecret deletes the secret, it's not an error if the secret doesn't exist.
+func (c *Client) DeleteSecret(ctx context.Context, name, namespace string) error {
+	if err := c.kubeClient.CoreV1().Secrets(namespace).Delete(ctx, name, metav1.DeleteOptions{}); err != nil && !errors.IsNotFound(err) {
+		return err
+	}
+	return nil
+}
+
+// ListPods lists the pods in namespace that match the label selector, e.g. 'app.greptime.io/component=mycluster-datanode'.
+func (c *Client) ListPods(ctx context.Context, namespace, labelSelector string) (*corev1.PodList, error) {
+	return c.kubeClient.CoreV1().Pods(namespace).List(ctx, metav1.ListOptions{LabelSelector: labelSelector})
+}
+
+// StreamPodLogs opens the log stream of the pod, the stream keeps open if it's following the logs.
+func (c *Client) StreamPodLogs(ctx context.Context, name, namespace string, options *corev1.PodLogOptions) (io.ReadCloser, error) {
+	return c.kubeClient.CoreV1().Pods(namespace).GetLogs(name, options).Stream(ctx)
+}
+
 func (c *Client) WaitForDeploymentReady(ctx context.Context, name, namespace string, timeout time.Duration) error {
 	conditionFunc := func() (bool, error) {
 		return c.isDeploymentReady(ctx, name, namespace)
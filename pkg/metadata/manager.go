@@ -21,6 +21,8 @@ import (
 	"os"
 	"path"
 	"path/filepath"
+	"strings"
+	"syscall"
 	"time"
 
 	"gopkg.in/yaml.v3"
@@ -47,11 +49,31 @@ type Manager interface {
 	GetWorkingDir() string
 
 	// CreateClusterScopeDirs creates cluster scope directories and config path that allocated by AllocateClusterScopeDirs.
+	// If the metadata of the cluster already exists, only its config and foreground pid are updated,
+	// so the cluster can be resumed with its data and pinned binaries.
 	CreateClusterScopeDirs(cfg *config.BareMetalClusterConfig) error
 
 	// GetClusterScopeDirs returns the cluster scope directory of current cluster.
 	GetClusterScopeDirs() *ClusterScopeDirs
 
+	// GetClusterMetadata reads the metadata of current cluster from its config path.
+	GetClusterMetadata() (*config.BareMetalClusterMetadata, error)
+
+	// UpdateClusterMetadata reads the metadata of current cluster, applies the update func to it
+	// and then writes it back to the config path. The update is done under an exclusive file lock,
+	// so the concurrent updates from different processes, e.g. the supervisor and the cli, are not lost.
+	UpdateClusterMetadata(update func(md *config.BareMetalClusterMetadata)) error
+
+	// LockCluster runs f under the exclusive file lock that UpdateClusterMetadata takes, so the other files of
+	// current cluster, e.g. the users file, can be updated without being lost by the concurrent updates.
+	// The metadata must not be updated in f, since the lock is not reentrant.
+	LockCluster(f func() error) error
+
+	// ListClustersMetadata returns the metadata of all the clusters under the working directory,
+	// which is stored in ${HomeDir}/${BaseDir}/${ClusterName}/${ClusterName}.yaml. The metadata that can't be
+	// parsed is skipped, and the other clusters are returned along with the *CorruptedMetadataError of it.
+	ListClustersMetadata() ([]*config.BareMetalClusterMetadata, error)
+
 	// Clean cleans up all the metadata. It will remove the working directory.
 	Clean() error
 }
@@ -64,6 +86,18 @@ const (
 	ClusterLogsDir = "logs"
 	ClusterDataDir = "data"
 	ClusterPidsDir = "pids"
+
+	// ClusterConfigsDir stores the configs of components, which are generated from their options or restored from backup.
+	ClusterConfigsDir = "configs"
+
+	// ClusterCertsDir stores the self-signed CA and the server certificates of a cluster with TLS.
+	ClusterCertsDir = "certs"
+
+	// ClusterUsersFile stores the static users that the frontend of a cluster authenticates the clients with.
+	ClusterUsersFile = "users"
+
+	// ClusterSupervisorSocket is the unix socket that the supervisor of a detached cluster listens on.
+	ClusterSupervisorSocket = "supervisor.sock"
 )
 
 type ClusterScopeDirs struct {
@@ -71,7 +105,11 @@ type ClusterScopeDirs struct {
 	LogsDir    string
 	DataDir    string
 	PidsDir    string
+	ConfigsDir string
+	CertsDir   string
+	UsersFile  string
 	ConfigPath string
+	SocketPath string
 }
 
 type manager struct {
@@ -108,8 +146,16 @@ func (m *manager) AllocateClusterScopeDirs(clusterName string) {
 	csd.DataDir = path.Join(csd.BaseDir, ClusterDataDir)
 	// ${HomeDir}/${BaseDir}/${ClusterName}/pids
 	csd.PidsDir = path.Join(csd.BaseDir, ClusterPidsDir)
+	// ${HomeDir}/${BaseDir}/${ClusterName}/configs
+	csd.ConfigsDir = path.Join(csd.BaseDir, ClusterConfigsDir)
+	// ${HomeDir}/${BaseDir}/${ClusterName}/certs
+	csd.CertsDir = path.Join(csd.BaseDir, ClusterCertsDir)
+	// ${HomeDir}/${BaseDir}/${ClusterName}/users
+	csd.UsersFile = path.Join(csd.BaseDir, ClusterUsersFile)
 	// ${HomeDir}/${BaseDir}/${ClusterName}/${ClusterName}.yaml
 	csd.ConfigPath = filepath.Join(csd.BaseDir, fmt.Sprintf("%s.yaml", clusterName))
+	// ${HomeDir}/${BaseDir}/${ClusterName}/supervisor.sock
+	csd.SocketPath = path.Join(csd.BaseDir, ClusterSupervisorSocket)
 
 	m.clusterDir = csd
 }
@@ -151,32 +197,162 @@ func (m *manager) CreateClusterScopeDirs(cfg *config.BareMetalClusterConfig) err
 		}
 	}
 
-	f, err := os.Create(m.clusterDir.ConfigPath)
+	exists, err := fileutils.IsFileExists(m.clusterDir.ConfigPath)
 	if err != nil {
 		return err
 	}
+	if exists {
+		return m.UpdateClusterMetadata(func(md *config.BareMetalClusterMetadata) {
+			md.Config = cfg
+			md.ForegroundPid = os.Getpid()
+		})
+	}
 
-	metaConfig := config.BareMetalClusterMetadata{
+	metaConfig := &config.BareMetalClusterMetadata{
 		Config:        cfg,
 		CreationDate:  time.Now(),
 		ClusterDir:    m.clusterDir.BaseDir,
 		ForegroundPid: os.Getpid(),
 	}
 
-	out, err := yaml.Marshal(metaConfig)
+	return m.writeClusterMetadata(metaConfig)
+}
+
+func (m *manager) GetClusterMetadata() (*config.BareMetalClusterMetadata, error) {
+	if m.clusterDir == nil {
+		return nil, fmt.Errorf("unallocated cluster dir, please initialize a metadata manager with cluster name provided")
+	}
+
+	in, err := os.ReadFile(m.clusterDir.ConfigPath)
+	if err != nil {
+		return nil, err
+	}
+
+	var md config.BareMetalClusterMetadata
+	if err = yaml.Unmarshal(in, &md); err != nil {
+		return nil, err
+	}
+
+	return &md, nil
+}
+
+func (m *manager) UpdateClusterMetadata(update func(md *config.BareMetalClusterMetadata)) error {
+	if m.clusterDir == nil {
+		return fmt.Errorf("unallocated cluster dir, please initialize a metadata manager with cluster name provided")
+	}
+
+	return m.LockCluster(func() error {
+		md, err := m.GetClusterMetadata()
+		if err != nil {
+			return err
+		}
+
+		update(md)
+
+		return m.writeClusterMetadata(md)
+	})
+}
+
+func (m *manager) LockCluster(f func() error) error {
+	if m.clusterDir == nil {
+		return fmt.Errorf("unallocated cluster dir, please initialize a metadata manager with cluster name provided")
+	}
+
+	unlock, err := lockFile(m.clusterDir.ConfigPath + ".lock")
 	if err != nil {
 		return err
 	}
+	defer unlock()
+
+	return f()
+}
+
+func (m *manager) ListClustersMetadata() ([]*config.BareMetalClusterMetadata, error) {
+	entries, err := os.ReadDir(m.workingDir)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var (
+		clusters  []*config.BareMetalClusterMetadata
+		corrupted = &CorruptedMetadataError{}
+	)
+	for _, entry := range entries {
+		if !entry.IsDir() {
+			continue
+		}
+
+		// The directories that have no metadata file are not clusters, like the artifacts directory.
+		configPath := filepath.Join(m.workingDir, entry.Name(), fmt.Sprintf("%s.yaml", entry.Name()))
+		in, err := os.ReadFile(configPath)
+		if os.IsNotExist(err) {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		var md config.BareMetalClusterMetadata
+		if err = yaml.Unmarshal(in, &md); err != nil {
+			corrupted.Errors = append(corrupted.Errors, fmt.Errorf("failed to parse the metadata in '%s': %v", configPath, err))
+			continue
+		}
+		clusters = append(clusters, &md)
+	}
+
+	if len(corrupted.Errors) > 0 {
+		return clusters, corrupted
+	}
+	return clusters, nil
+}
+
+// CorruptedMetadataError is the error of the cluster metadata that can't be parsed when listing the clusters.
+type CorruptedMetadataError struct {
+	Errors []error
+}
+
+func (e *CorruptedMetadataError) Error() string {
+	msgs := make([]string, 0, len(e.Errors))
+	for _, err := range e.Errors {
+		msgs = append(msgs, err.Error())
+	}
+	return strings.Join(msgs, "; ")
+}
 
-	if _, err = f.Write(out); err != nil {
+// writeClusterMetadata writes the metadata to a temporary file first and then renames it to the config path,
+// so that the readers will never see a partially written metadata.
+func (m *manager) writeClusterMetadata(md *config.BareMetalClusterMetadata) error {
+	out, err := yaml.Marshal(md)
+	if err != nil {
 		return err
 	}
 
-	if err = f.Close(); err != nil {
+	tmpPath := m.clusterDir.ConfigPath + ".tmp"
+	if err = os.WriteFile(tmpPath, out, 0644); err != nil {
 		return err
 	}
 
-	return nil
+	return os.Rename(tmpPath, m.clusterDir.ConfigPath)
+}
+
+// lockFile acquires the exclusive lock of the file, which is created if not exists, and returns the func to release it.
+func lockFile(name string) (func(), error) {
+	f, err := os.OpenFile(name, os.O_CREATE|os.O_RDWR, 0644)
+	if err != nil {
+		return nil, err
+	}
+	if err = syscall.Flock(int(f.Fd()), syscall.LOCK_EX); err != nil {
+		_ = f.Close()
+		return nil, fmt.Errorf("failed to lock '%s': %v", name, err)
+	}
+
+	return func() {
+		_ = syscall.Flock(int(f.Fd()), syscall.LOCK_UN)
+		_ = f.Close()
+	}, nil
 }
 
 func (m *manager) SetHomeDir(dir string) error {
@@ -19,6 +19,7 @@ package metadata
 import (
 	"os"
 	"path/filepath"
+	"sync"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
@@ -145,3 +146,136 @@ func TestMetadataManagerWithClusterConfigPath(t *testing.T) {
 	err = m.Clean()
 	assert.NoError(t, err)
 }
+
+func TestUpdateClusterMetadata(t *testing.T) {
+	tempDir, err := os.MkdirTemp("/tmp", "gtctl-ut-")
+	assert.NoError(t, err)
+	defer os.RemoveAll(tempDir)
+
+	m, err := New(tempDir)
+	assert.NoError(t, err)
+
+	m.AllocateClusterScopeDirs("test")
+	err = m.CreateClusterScopeDirs(config.DefaultBareMetalConfig())
+	assert.NoError(t, err)
+
+	err = m.UpdateClusterMetadata(func(md *config.BareMetalClusterMetadata) {
+		md.SupervisorPid = 123
+		md.UseMemoryMeta = true
+	})
+	assert.NoError(t, err)
+
+	actual, err := m.GetClusterMetadata()
+	assert.NoError(t, err)
+	assert.Equal(t, 123, actual.SupervisorPid)
+	assert.True(t, actual.UseMemoryMeta)
+	assert.Equal(t, os.Getpid(), actual.ForegroundPid)
+	assert.Equal(t, config.DefaultBareMetalConfig(), actual.Config)
+}
+
+func TestConcurrentUpdateClusterMetadata(t *testing.T) {
+	tempDir, err := os.MkdirTemp("/tmp", "gtctl-ut-")
+	assert.NoError(t, err)
+	defer os.RemoveAll(tempDir)
+
+	m, err := New(tempDir)
+	assert.NoError(t, err)
+	m.AllocateClusterScopeDirs("test")
+	err = m.CreateClusterScopeDirs(config.DefaultBareMetalConfig())
+	assert.NoError(t, err)
+
+	// Each manager opens its own lock file like the different processes do, and no update is lost.
+	var wg sync.WaitGroup
+	for i := 0; i < 4; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			m, err := New(tempDir)
+			assert.NoError(t, err)
+			m.AllocateClusterScopeDirs("test")
+			for j := 0; j < 10; j++ {
+				assert.NoError(t, m.UpdateClusterMetadata(func(md *config.BareMetalClusterMetadata) {
+					md.SupervisorPid++
+				}))
+			}
+		}()
+	}
+	wg.Wait()
+
+	actual, err := m.GetClusterMetadata()
+	assert.NoError(t, err)
+	assert.Equal(t, 40, actual.SupervisorPid)
+}
+
+func TestListClustersMetadata(t *testing.T) {
+	tempDir, err := os.MkdirTemp("/tmp", "gtctl-ut-")
+	assert.NoError(t, err)
+	defer os.RemoveAll(tempDir)
+
+	m, err := New(tempDir)
+	assert.NoError(t, err)
+
+	clusters, err := m.ListClustersMetadata()
+	assert.NoError(t, err)
+	assert.Empty(t, clusters)
+
+	for _, name := range []string{"a", "b"} {
+		m.AllocateClusterScopeDirs(name)
+		err = m.CreateClusterScopeDirs(config.DefaultBareMetalConfig())
+		assert.NoError(t, err)
+	}
+	// The directory without metadata file is not a cluster.
+	err = os.MkdirAll(filepath.Join(tempDir, BaseDir, "artifacts"), 0755)
+	assert.NoError(t, err)
+
+	clusters, err = m.ListClustersMetadata()
+	assert.NoError(t, err)
+	assert.Len(t, clusters, 2)
+	assert.Equal(t, filepath.Join(tempDir, BaseDir, "a"), clusters[0].ClusterDir)
+	assert.Equal(t, filepath.Join(tempDir, BaseDir, "b"), clusters[1].ClusterDir)
+
+	// The corrupted metadata is skipped, and the other clusters are still listed.
+	err = os.WriteFile(filepath.Join(tempDir, BaseDir, "a", "a.yaml"), []byte("config: ["), 0644)
+	assert.NoError(t, err)
+	clusters, err = m.ListClustersMetadata()
+	var corrupted *CorruptedMetadataError
+	assert.ErrorAs(t, err, &corrupted)
+	assert.Len(t, corrupted.Errors, 1)
+	assert.ErrorContains(t, err, filepath.Join(tempDir, BaseDir, "a", "a.yaml"))
+	assert.Len(t, clusters, 1)
+	assert.Equal(t, filepath.Join(tempDir, BaseDir, "b"), clusters[0].ClusterDir)
+}
+
+func TestCreateClusterScopeDirsWithExistingMetadata(t *testing.T) {
+	tempDir, err := os.MkdirTemp("/tmp", "gtctl-ut-")
+	assert.NoError(t, err)
+	defer os.RemoveAll(tempDir)
+
+	m, err := New(tempDir)
+	assert.NoError(t, err)
+
+	m.AllocateClusterScopeDirs("test")
+	err = m.CreateClusterScopeDirs(config.DefaultBareMetalConfig())
+	assert.NoError(t, err)
+
+	err = m.UpdateClusterMetadata(func(md *config.BareMetalClusterMetadata) {
+		md.ForegroundPid = 123
+		md.GreptimeBinPath = "/path/to/greptime"
+	})
+	assert.NoError(t, err)
+	expected, err := m.GetClusterMetadata()
+	assert.NoError(t, err)
+
+	// The existing metadata is kept except the config and foreground pid.
+	cfg := config.DefaultBareMetalConfig()
+	cfg.Cluster.Datanode.Replicas = 1
+	err = m.CreateClusterScopeDirs(cfg)
+	assert.NoError(t, err)
+
+	actual, err := m.GetClusterMetadata()
+	assert.NoError(t, err)
+	assert.Equal(t, os.Getpid(), actual.ForegroundPid)
+	assert.Equal(t, cfg, actual.Config)
+	assert.Equal(t, expected.CreationDate, actual.CreationDate)
+	assert.Equal(t, expected.GreptimeBinPath, actual.GreptimeBinPath)
+}
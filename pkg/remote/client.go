@@ -0,0 +1,409 @@
+/*
+ * Copyright 2023 Greptime Team
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package remote
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"net"
+	"os"
+	"os/user"
+	"path"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/pkg/sftp"
+	"golang.org/x/crypto/ssh"
+	"golang.org/x/crypto/ssh/agent"
+	"golang.org/x/crypto/ssh/knownhosts"
+
+	"github.com/GreptimeTeam/gtctl/pkg/config"
+	"github.com/GreptimeTeam/gtctl/pkg/logger"
+)
+
+const (
+	// DefaultDir is the working directory of gtctl on the remote host, it's relative to the home of user.
+	DefaultDir = ".gtctl"
+
+	defaultDialTimeout = 10 * time.Second
+
+	// binDir is the directory in the working directory that the uploaded binaries are stored in,
+	// each binary is stored in the sub directory named after its checksum so they can be shared by clusters.
+	binDir = "bin"
+)
+
+// Client runs commands on and uploads files to the remote host over SSH.
+// It connects to the host on its first use, and reconnects if the connection is lost.
+type Client struct {
+	host    *config.Host
+	cluster string
+	logger  logger.Logger
+
+	mu   sync.Mutex
+	ssh  *ssh.Client
+	sftp *sftp.Client
+	// dir is the absolute working directory of gtctl on the host, it's resolved on connecting.
+	dir string
+	// binaries are the uploaded binaries, keyed by their local paths.
+	binaries map[string]string
+}
+
+// NewClient returns the client of host for the cluster.
+func NewClient(host *config.Host, cluster string, l logger.Logger) *Client {
+	return &Client{
+		host:     host,
+		cluster:  cluster,
+		logger:   l,
+		binaries: make(map[string]string),
+	}
+}
+
+// Name returns the name of host.
+func (c *Client) Name() string {
+	return c.host.Name
+}
+
+// Hostname returns the hostname of host, it's used to access the addresses that the replicas listen on.
+func (c *Client) Hostname() string {
+	hostname, _, err := net.SplitHostPort(c.host.Address)
+	if err != nil {
+		return c.host.Address
+	}
+	return hostname
+}
+
+// Connect connects to the host if it's not connected.
+func (c *Client) Connect() error {
+	_, _, err := c.connect()
+	return err
+}
+
+func (c *Client) connect() (*ssh.Client, *sftp.Client, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.ssh != nil {
+		return c.ssh, c.sftp, nil
+	}
+
+	sshConfig, err := c.clientConfig()
+	if err != nil {
+		return nil, nil, err
+	}
+
+	c.logger.V(3).Infof("connecting to host '%s' (%s@%s)", c.host.Name, sshConfig.User, c.host.Address)
+	sshClient, err := ssh.Dial("tcp", c.host.Address, sshConfig)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to connect to host '%s' (%s): %v", c.host.Name, c.host.Address, err)
+	}
+	sftpClient, err := sftp.NewClient(sshClient)
+	if err != nil {
+		_ = sshClient.Close()
+		return nil, nil, fmt.Errorf("failed to start sftp on host '%s': %v", c.host.Name, err)
+	}
+
+	// The relative dir is under the working directory of sftp, which is the home of user.
+	dir := c.host.Dir
+	if len(dir) == 0 {
+		dir = DefaultDir
+	}
+	if !path.IsAbs(dir) {
+		home, err := sftpClient.Getwd()
+		if err != nil {
+			_ = sftpClient.Close()
+			_ = sshClient.Close()
+			return nil, nil, fmt.Errorf("failed to get the home of host '%s': %v", c.host.Name, err)
+		}
+		dir = path.Join(home, dir)
+	}
+
+	c.ssh, c.sftp, c.dir = sshClient, sftpClient, dir
+
+	// Reset the connection once it's lost, so the next use reconnects to the host.
+	go func() {
+		_ = sshClient.Wait()
+		c.mu.Lock()
+		defer c.mu.Unlock()
+		if c.ssh == sshClient {
+			_ = c.sftp.Close()
+			c.ssh, c.sftp = nil, nil
+		}
+	}()
+
+	return sshClient, sftpClient, nil
+}
+
+// clientConfig returns the SSH config of host, the keys of SSH agent and the default identity files
+// are used to authenticate if the identity file is not specified.
+func (c *Client) clientConfig() (*ssh.ClientConfig, error) {
+	username := c.host.User
+	if len(username) == 0 {
+		current, err := user.Current()
+		if err != nil {
+			return nil, err
+		}
+		username = current.Username
+	}
+
+	home, _ := os.UserHomeDir()
+
+	var signers []ssh.Signer
+	identityFiles := []string{c.host.IdentityFile}
+	if len(c.host.IdentityFile) == 0 {
+		if sock := os.Getenv("SSH_AUTH_SOCK"); len(sock) > 0 {
+			if conn, err := net.Dial("unix", sock); err == nil {
+				if agentSigners, err := agent.NewClient(conn).Signers(); err == nil {
+					signers = append(signers, agentSigners...)
+				}
+			}
+		}
+		identityFiles = []string{
+			filepath.Join(home, ".ssh", "id_rsa"),
+			filepath.Join(home, ".ssh", "id_ecdsa"),
+			filepath.Join(home, ".ssh", "id_ed25519"),
+		}
+	}
+	for _, file := range identityFiles {
+		key, err := os.ReadFile(file)
+		if err != nil {
+			if os.IsNotExist(err) && len(c.host.IdentityFile) == 0 {
+				continue
+			}
+			return nil, fmt.Errorf("failed to read identity file of host '%s': %v", c.host.Name, err)
+		}
+		signer, err := ssh.ParsePrivateKey(key)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse identity file '%s' of host '%s': %v", file, c.host.Name, err)
+		}
+		signers = append(signers, signer)
+	}
+	if len(signers) == 0 {
+		return nil, fmt.Errorf("no identity to authenticate with host '%s', specify it by 'identityFile'", c.host.Name)
+	}
+
+	hostKeyCallback := ssh.InsecureIgnoreHostKey()
+	if !c.host.InsecureIgnoreHostKey {
+		knownHostsFile := c.host.KnownHostsFile
+		if len(knownHostsFile) == 0 {
+			knownHostsFile = filepath.Join(home, ".ssh", "known_hosts")
+		}
+		callback, err := knownhosts.New(knownHostsFile)
+		if err != nil {
+			return nil, fmt.Errorf("failed to load known hosts to verify host '%s': %v", c.host.Name, err)
+		}
+		hostKeyCallback = callback
+	}
+
+	return &ssh.ClientConfig{
+		User:            username,
+		Auth:            []ssh.AuthMethod{ssh.PublicKeys(signers...)},
+		HostKeyCallback: hostKeyCallback,
+		Timeout:         defaultDialTimeout,
+	}, nil
+}
+
+// ClusterDir returns the directory of the cluster on the host.
+func (c *Client) ClusterDir() (string, error) {
+	if _, _, err := c.connect(); err != nil {
+		return "", err
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return path.Join(c.dir, c.cluster), nil
+}
+
+// Run runs the command on the host and returns its combined output.
+func (c *Client) Run(cmd string) ([]byte, error) {
+	sshClient, _, err := c.connect()
+	if err != nil {
+		return nil, err
+	}
+
+	session, err := sshClient.NewSession()
+	if err != nil {
+		return nil, err
+	}
+	defer session.Close()
+
+	c.logger.V(5).Infof("run '%s' on host '%s'", cmd, c.host.Name)
+	output, err := session.CombinedOutput(cmd)
+	if err != nil {
+		return output, fmt.Errorf("failed to run '%s' on host '%s': %v: %s",
+			cmd, c.host.Name, err, bytes.TrimSpace(output))
+	}
+	return output, nil
+}
+
+// Start starts the command on the host in a new session, the session is closed after the command exits.
+func (c *Client) Start(cmd string, stdout, stderr io.Writer) (*ssh.Session, error) {
+	sshClient, _, err := c.connect()
+	if err != nil {
+		return nil, err
+	}
+
+	session, err := sshClient.NewSession()
+	if err != nil {
+		return nil, err
+	}
+	session.Stdout = stdout
+	session.Stderr = stderr
+
+	if err = session.Start(cmd); err != nil {
+		_ = session.Close()
+		return nil, fmt.Errorf("failed to start '%s' on host '%s': %v", cmd, c.host.Name, err)
+	}
+	return session, nil
+}
+
+// MkdirAll creates the directories on the host along with their parents.
+func (c *Client) MkdirAll(dirs ...string) error {
+	_, sftpClient, err := c.connect()
+	if err != nil {
+		return err
+	}
+
+	for _, dir := range dirs {
+		if err = sftpClient.MkdirAll(dir); err != nil {
+			return fmt.Errorf("failed to create '%s' on host '%s': %v", dir, c.host.Name, err)
+		}
+	}
+	return nil
+}
+
+// RemoveAll removes the directory on the host and everything it contains.
+func (c *Client) RemoveAll(dir string) error {
+	_, err := c.Run(fmt.Sprintf("rm -rf %s", Quote(dir)))
+	return err
+}
+
+// Upload uploads the local file to dst on the host with mode. The file is uploaded to a temporary file
+// and then renamed to dst, so the running binary won't be overwritten.
+func (c *Client) Upload(src, dst string, mode os.FileMode) error {
+	_, sftpClient, err := c.connect()
+	if err != nil {
+		return err
+	}
+
+	if err = sftpClient.MkdirAll(path.Dir(dst)); err != nil {
+		return fmt.Errorf("failed to create '%s' on host '%s': %v", path.Dir(dst), c.host.Name, err)
+	}
+
+	local, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+	defer local.Close()
+
+	tmp := dst + ".tmp"
+	remote, err := sftpClient.Create(tmp)
+	if err != nil {
+		return fmt.Errorf("failed to create '%s' on host '%s': %v", tmp, c.host.Name, err)
+	}
+	if _, err = io.Copy(remote, local); err != nil {
+		_ = remote.Close()
+		return fmt.Errorf("failed to upload '%s' to host '%s': %v", src, c.host.Name, err)
+	}
+	if err = remote.Close(); err != nil {
+		return err
+	}
+	if err = sftpClient.Chmod(tmp, mode); err != nil {
+		return err
+	}
+	if err = sftpClient.PosixRename(tmp, dst); err != nil {
+		return fmt.Errorf("failed to rename '%s' to '%s' on host '%s': %v", tmp, dst, c.host.Name, err)
+	}
+
+	c.logger.V(3).Infof("uploaded '%s' to '%s' on host '%s'", src, dst, c.host.Name)
+	return nil
+}
+
+// UploadBinary uploads the local binary to the host and returns its path on the host.
+// The binary is skipped if the one with the same checksum has been uploaded.
+func (c *Client) UploadBinary(binary string) (string, error) {
+	c.mu.Lock()
+	uploaded, ok := c.binaries[binary]
+	c.mu.Unlock()
+	if ok {
+		return uploaded, nil
+	}
+
+	sum, size, err := checksum(binary)
+	if err != nil {
+		return "", err
+	}
+
+	_, sftpClient, err := c.connect()
+	if err != nil {
+		return "", err
+	}
+
+	c.mu.Lock()
+	dst := path.Join(c.dir, binDir, sum[:12], filepath.Base(binary))
+	c.mu.Unlock()
+
+	if info, err := sftpClient.Stat(dst); err != nil || info.Size() != size {
+		if err = c.Upload(binary, dst, 0755); err != nil {
+			return "", err
+		}
+	}
+
+	c.mu.Lock()
+	c.binaries[binary] = dst
+	c.mu.Unlock()
+
+	return dst, nil
+}
+
+// Close closes the connection to the host.
+func (c *Client) Close() error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.ssh == nil {
+		return nil
+	}
+	_ = c.sftp.Close()
+	err := c.ssh.Close()
+	c.ssh, c.sftp = nil, nil
+	return err
+}
+
+func checksum(file string) (string, int64, error) {
+	f, err := os.Open(file)
+	if err != nil {
+		return "", 0, err
+	}
+	defer f.Close()
+
+	h := sha256.New()
+	size, err := io.Copy(h, f)
+	if err != nil {
+		return "", 0, err
+	}
+	return hex.EncodeToString(h.Sum(nil)), size, nil
+}
+
+// Quote quotes s as one word of POSIX shell.
+func Quote(s string) string {
+	return "'" + strings.ReplaceAll(s, "'", `'\''`) + "'"
+}
@@ -0,0 +1,128 @@
+/*
+ * Copyright 2023 Greptime Team
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package remote
+
+import (
+	"bytes"
+	"io"
+	"os"
+	"path"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"golang.org/x/crypto/ssh/knownhosts"
+
+	"github.com/GreptimeTeam/gtctl/pkg/config"
+	"github.com/GreptimeTeam/gtctl/pkg/logger"
+	"github.com/GreptimeTeam/gtctl/pkg/remote/remotetest"
+)
+
+func newTestClient(t *testing.T, server *remotetest.Server) *Client {
+	host := &config.Host{
+		Name:                  "node-1",
+		Address:               server.Addr,
+		User:                  "greptime",
+		IdentityFile:          server.IdentityFile,
+		InsecureIgnoreHostKey: true,
+	}
+	client := NewClient(host, "mycluster", logger.New(io.Discard, 0))
+	t.Cleanup(func() { _ = client.Close() })
+	return client
+}
+
+func TestClientRun(t *testing.T) {
+	server := remotetest.NewServer(t)
+	client := newTestClient(t, server)
+
+	output, err := client.Run("echo hello")
+	assert.NoError(t, err)
+	assert.Equal(t, "hello\n", string(output))
+
+	_, err = client.Run("echo oops >&2; exit 3")
+	assert.ErrorContains(t, err, "oops")
+
+	// The relative dir is under the home of user.
+	clusterDir, err := client.ClusterDir()
+	assert.NoError(t, err)
+	assert.Equal(t, path.Join(server.Home, DefaultDir, "mycluster"), clusterDir)
+
+	assert.NoError(t, client.MkdirAll(path.Join(clusterDir, "data")))
+	assert.DirExists(t, path.Join(clusterDir, "data"))
+	assert.NoError(t, client.RemoveAll(clusterDir))
+	assert.NoDirExists(t, clusterDir)
+}
+
+func TestClientStart(t *testing.T) {
+	server := remotetest.NewServer(t)
+	client := newTestClient(t, server)
+
+	var stdout, stderr bytes.Buffer
+	session, err := client.Start("echo out; echo err >&2", &stdout, &stderr)
+	assert.NoError(t, err)
+	assert.NoError(t, session.Wait())
+	assert.Equal(t, "out\n", stdout.String())
+	assert.Equal(t, "err\n", stderr.String())
+}
+
+func TestClientUploadBinary(t *testing.T) {
+	server := remotetest.NewServer(t)
+	client := newTestClient(t, server)
+
+	binary := filepath.Join(t.TempDir(), "greptime")
+	assert.NoError(t, os.WriteFile(binary, []byte("#!/bin/sh\necho uploaded\n"), 0755))
+
+	uploaded, err := client.UploadBinary(binary)
+	assert.NoError(t, err)
+	assert.Equal(t, path.Join(server.Home, DefaultDir, binDir), path.Dir(path.Dir(uploaded)))
+	assert.Equal(t, "greptime", path.Base(uploaded))
+
+	output, err := client.Run(Quote(uploaded))
+	assert.NoError(t, err)
+	assert.Equal(t, "uploaded\n", string(output))
+
+	// The same binary is uploaded only once.
+	again, err := client.UploadBinary(binary)
+	assert.NoError(t, err)
+	assert.Equal(t, uploaded, again)
+}
+
+func TestClientKnownHosts(t *testing.T) {
+	server := remotetest.NewServer(t)
+
+	host := &config.Host{
+		Name:           "node-1",
+		Address:        server.Addr,
+		IdentityFile:   server.IdentityFile,
+		KnownHostsFile: filepath.Join(t.TempDir(), "known_hosts"),
+	}
+	assert.NoError(t, os.WriteFile(host.KnownHostsFile, nil, 0600))
+
+	// The unknown host is rejected.
+	client := NewClient(host, "mycluster", logger.New(io.Discard, 0))
+	assert.Error(t, client.Connect())
+
+	line := knownhosts.Line([]string{knownhosts.Normalize(server.Addr)}, server.HostKey)
+	assert.NoError(t, os.WriteFile(host.KnownHostsFile, []byte(line+"\n"), 0600))
+	assert.NoError(t, client.Connect())
+	assert.NoError(t, client.Close())
+}
+
+func TestQuote(t *testing.T) {
+	assert.Equal(t, `'a b'`, Quote("a b"))
+	assert.Equal(t, `'it'\''s'`, Quote("it's"))
+}
@@ -0,0 +1,251 @@
+/*
+ * Copyright 2023 Greptime Team
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+// Package remotetest provides an in-process SSH server for testing the deployment on remote hosts.
+// It runs the commands by 'sh -c' in its home directory and serves sftp, like OpenSSH on localhost does.
+package remotetest
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"encoding/pem"
+	"errors"
+	"net"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"sync"
+	"syscall"
+	"testing"
+
+	"github.com/pkg/sftp"
+	"golang.org/x/crypto/ssh"
+)
+
+// Server is an SSH server that listens on localhost.
+type Server struct {
+	// Addr is the address that the server listens on.
+	Addr string
+	// Home is the home directory of the user, which is the working directory of the commands and sftp.
+	Home string
+	// IdentityFile is the private key that the server accepts.
+	IdentityFile string
+	// HostKey is the public key of the server.
+	HostKey ssh.PublicKey
+
+	listener net.Listener
+	wg       sync.WaitGroup
+
+	mu    sync.Mutex
+	conns map[net.Conn]struct{}
+}
+
+// NewServer starts the server, it's closed when the test finishes.
+func NewServer(t *testing.T) *Server {
+	t.Helper()
+
+	dir := t.TempDir()
+	s := &Server{
+		Home:         filepath.Join(dir, "home"),
+		IdentityFile: filepath.Join(dir, "id_ecdsa"),
+		conns:        make(map[net.Conn]struct{}),
+	}
+	if err := os.MkdirAll(s.Home, 0755); err != nil {
+		t.Fatal(err)
+	}
+
+	hostSigner, _ := newKey(t)
+	s.HostKey = hostSigner.PublicKey()
+
+	userSigner, userKey := newKey(t)
+	if err := os.WriteFile(s.IdentityFile, userKey, 0600); err != nil {
+		t.Fatal(err)
+	}
+	authorized := string(userSigner.PublicKey().Marshal())
+
+	config := &ssh.ServerConfig{
+		PublicKeyCallback: func(_ ssh.ConnMetadata, key ssh.PublicKey) (*ssh.Permissions, error) {
+			if string(key.Marshal()) != authorized {
+				return nil, errors.New("unauthorized key")
+			}
+			return nil, nil
+		},
+	}
+	config.AddHostKey(hostSigner)
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	s.listener, s.Addr = listener, listener.Addr().String()
+
+	s.wg.Add(1)
+	go func() {
+		defer s.wg.Done()
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			go s.serveConn(conn, config)
+		}
+	}()
+	t.Cleanup(s.Close)
+
+	return s
+}
+
+// Close stops accepting the new connections.
+func (s *Server) Close() {
+	_ = s.listener.Close()
+	s.wg.Wait()
+}
+
+// DropConnections closes all the connections to the server like the network is broken,
+// the commands that run in their sessions are not stopped.
+func (s *Server) DropConnections() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for conn := range s.conns {
+		_ = conn.Close()
+	}
+	s.conns = make(map[net.Conn]struct{})
+}
+
+func (s *Server) serveConn(conn net.Conn, config *ssh.ServerConfig) {
+	s.mu.Lock()
+	s.conns[conn] = struct{}{}
+	s.mu.Unlock()
+
+	_, channels, requests, err := ssh.NewServerConn(conn, config)
+	if err != nil {
+		_ = conn.Close()
+		return
+	}
+	go ssh.DiscardRequests(requests)
+
+	for newChannel := range channels {
+		if newChannel.ChannelType() != "session" {
+			_ = newChannel.Reject(ssh.UnknownChannelType, "unknown channel type")
+			continue
+		}
+		channel, requests, err := newChannel.Accept()
+		if err != nil {
+			continue
+		}
+		go s.serveSession(channel, requests)
+	}
+}
+
+func (s *Server) serveSession(channel ssh.Channel, requests <-chan *ssh.Request) {
+	for req := range requests {
+		switch req.Type {
+		case "exec":
+			var payload struct{ Command string }
+			if err := ssh.Unmarshal(req.Payload, &payload); err != nil {
+				_ = req.Reply(false, nil)
+				continue
+			}
+			_ = req.Reply(true, nil)
+			go s.exec(channel, payload.Command)
+		case "subsystem":
+			var payload struct{ Name string }
+			if err := ssh.Unmarshal(req.Payload, &payload); err != nil || payload.Name != "sftp" {
+				_ = req.Reply(false, nil)
+				continue
+			}
+			_ = req.Reply(true, nil)
+			go func() {
+				defer channel.Close()
+				server, err := sftp.NewServer(channel, sftp.WithServerWorkingDirectory(s.Home))
+				if err != nil {
+					return
+				}
+				_ = server.Serve()
+			}()
+		default:
+			_ = req.Reply(false, nil)
+		}
+	}
+}
+
+// exec runs the command and reports its exit status or the signal that killed it, like OpenSSH.
+func (s *Server) exec(channel ssh.Channel, command string) {
+	defer channel.Close()
+
+	cmd := exec.Command("sh", "-c", command)
+	cmd.Dir = s.Home
+	cmd.Env = append(os.Environ(), "HOME="+s.Home)
+	cmd.Stdout = channel
+	cmd.Stderr = channel.Stderr()
+
+	err := cmd.Run()
+	var exitErr *exec.ExitError
+	if errors.As(err, &exitErr) {
+		if status, ok := exitErr.Sys().(syscall.WaitStatus); ok && status.Signaled() {
+			signal := strings.TrimPrefix(signalName(status.Signal()), "SIG")
+			_, _ = channel.SendRequest("exit-signal", false, ssh.Marshal(struct {
+				Signal     string
+				CoreDumped bool
+				Error      string
+				Lang       string
+			}{Signal: signal}))
+			return
+		}
+		_, _ = channel.SendRequest("exit-status", false, ssh.Marshal(struct{ Status uint32 }{uint32(exitErr.ExitCode())}))
+		return
+	}
+	if err != nil {
+		_, _ = channel.SendRequest("exit-status", false, ssh.Marshal(struct{ Status uint32 }{127}))
+		return
+	}
+	_, _ = channel.SendRequest("exit-status", false, ssh.Marshal(struct{ Status uint32 }{0}))
+}
+
+func signalName(sig syscall.Signal) string {
+	switch sig {
+	case syscall.SIGKILL:
+		return "SIGKILL"
+	case syscall.SIGTERM:
+		return "SIGTERM"
+	case syscall.SIGINT:
+		return "SIGINT"
+	default:
+		return sig.String()
+	}
+}
+
+func newKey(t *testing.T) (ssh.Signer, []byte) {
+	t.Helper()
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	signer, err := ssh.NewSignerFromKey(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	der, err := x509.MarshalECPrivateKey(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return signer, pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: der})
+}
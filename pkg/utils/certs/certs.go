@@ -0,0 +1,266 @@
+/*
+ * Copyright 2023 Greptime Team
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+// Package certs manages a self-signed CA and the server certificates signed by it.
+package certs
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"fmt"
+	"math/big"
+	"net"
+	"os"
+	"path/filepath"
+	"time"
+
+	fileutils "github.com/GreptimeTeam/gtctl/pkg/utils/file"
+)
+
+const (
+	// CAName is the name of the CA certificate and key files.
+	CAName = "ca"
+
+	// DefaultValidFor is the default validity of server certificates.
+	DefaultValidFor = 365 * 24 * time.Hour
+
+	// caValidFor is the validity of CA, it's long enough that the clients need not trust a new CA frequently.
+	caValidFor = 10 * 365 * 24 * time.Hour
+)
+
+// Server is the server that a certificate is issued for.
+type Server struct {
+	// Name is the name of certificate and key files.
+	Name string
+
+	// Hosts are the DNS names and IP addresses that the certificate is valid for.
+	Hosts []string
+}
+
+// Files returns the certificate and key files of the name in dir.
+func Files(dir, name string) (certFile, keyFile string) {
+	return filepath.Join(dir, name+".crt"), filepath.Join(dir, name+".key")
+}
+
+// Ensure makes sure the CA and the certificates of servers are in dir. The CA is created if it doesn't exist,
+// and the certificate of server is issued if it doesn't exist, is not signed by the CA, doesn't cover its hosts
+// or expires within a tenth of validFor.
+func Ensure(dir string, servers []Server, validFor time.Duration) error {
+	ca, caKey, err := loadOrCreateCA(dir, false)
+	if err != nil {
+		return err
+	}
+
+	for _, server := range servers {
+		valid, err := isValid(dir, server, ca, validFor/10)
+		if err != nil {
+			return err
+		}
+		if valid {
+			continue
+		}
+		if err = issue(dir, server, ca, caKey, validFor); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Rotate issues new certificates of servers in dir. The CA is recreated before if rotateCA is true,
+// otherwise the existing CA signs them.
+func Rotate(dir string, servers []Server, validFor time.Duration, rotateCA bool) error {
+	ca, caKey, err := loadOrCreateCA(dir, rotateCA)
+	if err != nil {
+		return err
+	}
+
+	for _, server := range servers {
+		if err = issue(dir, server, ca, caKey, validFor); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// LoadCertificate loads the certificate of the name in dir.
+func LoadCertificate(dir, name string) (*x509.Certificate, error) {
+	certFile, _ := Files(dir, name)
+	data, err := os.ReadFile(certFile)
+	if err != nil {
+		return nil, err
+	}
+
+	block, _ := pem.Decode(data)
+	if block == nil || block.Type != "CERTIFICATE" {
+		return nil, fmt.Errorf("no certificate in '%s'", certFile)
+	}
+	return x509.ParseCertificate(block.Bytes)
+}
+
+func loadOrCreateCA(dir string, recreate bool) (*x509.Certificate, *ecdsa.PrivateKey, error) {
+	if err := fileutils.EnsureDir(dir); err != nil {
+		return nil, nil, err
+	}
+
+	certFile, keyFile := Files(dir, CAName)
+	exists, err := fileutils.IsFileExists(certFile)
+	if err != nil {
+		return nil, nil, err
+	}
+	if exists && !recreate {
+		return loadCA(dir)
+	}
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		return nil, nil, err
+	}
+	template, err := newTemplate("gtctl self-signed CA", caValidFor)
+	if err != nil {
+		return nil, nil, err
+	}
+	template.IsCA = true
+	template.BasicConstraintsValid = true
+	template.KeyUsage = x509.KeyUsageCertSign | x509.KeyUsageCRLSign | x509.KeyUsageDigitalSignature
+
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	if err != nil {
+		return nil, nil, err
+	}
+	if err = writeKeyPair(certFile, keyFile, der, key); err != nil {
+		return nil, nil, err
+	}
+
+	ca, err := x509.ParseCertificate(der)
+	return ca, key, err
+}
+
+func loadCA(dir string) (*x509.Certificate, *ecdsa.PrivateKey, error) {
+	ca, err := LoadCertificate(dir, CAName)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	_, keyFile := Files(dir, CAName)
+	data, err := os.ReadFile(keyFile)
+	if err != nil {
+		return nil, nil, err
+	}
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return nil, nil, fmt.Errorf("no private key in '%s'", keyFile)
+	}
+	key, err := x509.ParseECPrivateKey(block.Bytes)
+	if err != nil {
+		return nil, nil, err
+	}
+	return ca, key, nil
+}
+
+// isValid returns whether the certificate of server exists, is signed by the CA, covers all its hosts
+// and doesn't expire within renewBefore.
+func isValid(dir string, server Server, ca *x509.Certificate, renewBefore time.Duration) (bool, error) {
+	certFile, _ := Files(dir, server.Name)
+	if exists, err := fileutils.IsFileExists(certFile); err != nil || !exists {
+		return false, err
+	}
+
+	cert, err := LoadCertificate(dir, server.Name)
+	if err != nil {
+		return false, nil
+	}
+	if err = cert.CheckSignatureFrom(ca); err != nil {
+		return false, nil
+	}
+	if time.Now().Add(renewBefore).After(cert.NotAfter) {
+		return false, nil
+	}
+	for _, host := range server.Hosts {
+		if err = cert.VerifyHostname(host); err != nil {
+			return false, nil
+		}
+	}
+	return true, nil
+}
+
+func issue(dir string, server Server, ca *x509.Certificate, caKey *ecdsa.PrivateKey, validFor time.Duration) error {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		return err
+	}
+	template, err := newTemplate(server.Name, validFor)
+	if err != nil {
+		return err
+	}
+	template.KeyUsage = x509.KeyUsageDigitalSignature | x509.KeyUsageKeyEncipherment
+	template.ExtKeyUsage = []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth}
+	for _, host := range server.Hosts {
+		if ip := net.ParseIP(host); ip != nil {
+			template.IPAddresses = append(template.IPAddresses, ip)
+		} else {
+			template.DNSNames = append(template.DNSNames, host)
+		}
+	}
+
+	der, err := x509.CreateCertificate(rand.Reader, template, ca, &key.PublicKey, caKey)
+	if err != nil {
+		return err
+	}
+	certFile, keyFile := Files(dir, server.Name)
+	return writeKeyPair(certFile, keyFile, der, key)
+}
+
+func newTemplate(commonName string, validFor time.Duration) (*x509.Certificate, error) {
+	serial, err := rand.Int(rand.Reader, new(big.Int).Lsh(big.NewInt(1), 128))
+	if err != nil {
+		return nil, err
+	}
+
+	// The certificate is valid a little earlier in case of the clock skew.
+	now := time.Now()
+	return &x509.Certificate{
+		SerialNumber: serial,
+		Subject:      pkix.Name{CommonName: commonName, Organization: []string{"gtctl"}},
+		NotBefore:    now.Add(-time.Hour),
+		NotAfter:     now.Add(validFor),
+	}, nil
+}
+
+// writeKeyPair writes the certificate and its key, the key is only readable by the user.
+func writeKeyPair(certFile, keyFile string, der []byte, key *ecdsa.PrivateKey) error {
+	keyDer, err := x509.MarshalECPrivateKey(key)
+	if err != nil {
+		return err
+	}
+
+	if err = writeFile(keyFile, pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDer}), 0600); err != nil {
+		return err
+	}
+	return writeFile(certFile, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}), 0644)
+}
+
+// writeFile writes the file atomically by renaming a temporary file to it.
+func writeFile(name string, data []byte, mode os.FileMode) error {
+	tmp := name + ".tmp"
+	if err := os.WriteFile(tmp, data, mode); err != nil {
+		return err
+	}
+	return os.Rename(tmp, name)
+}
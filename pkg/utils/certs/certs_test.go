@@ -0,0 +1,83 @@
+/*
+ * Copyright 2023 Greptime Team
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package certs
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestEnsureAndRotate(t *testing.T) {
+	dir := t.TempDir()
+	servers := []Server{{Name: "frontend", Hosts: []string{"localhost", "127.0.0.1", "192.168.1.10"}}}
+
+	assert.NoError(t, Ensure(dir, servers, DefaultValidFor))
+	ca, err := LoadCertificate(dir, CAName)
+	assert.NoError(t, err)
+	assert.True(t, ca.IsCA)
+
+	// The certificate is trusted by the CA for all its hosts.
+	verify := func() *x509.Certificate {
+		cert, err := LoadCertificate(dir, "frontend")
+		assert.NoError(t, err)
+		pool := x509.NewCertPool()
+		pool.AddCert(ca)
+		for _, host := range servers[0].Hosts {
+			_, err = cert.Verify(x509.VerifyOptions{DNSName: host, Roots: pool})
+			assert.NoError(t, err, host)
+		}
+		return cert
+	}
+	cert := verify()
+
+	// The key is only readable by the user, and it pairs with the certificate.
+	certFile, keyFile := Files(dir, "frontend")
+	info, err := os.Stat(keyFile)
+	assert.NoError(t, err)
+	assert.Equal(t, os.FileMode(0600), info.Mode().Perm())
+	_, err = tls.LoadX509KeyPair(certFile, keyFile)
+	assert.NoError(t, err)
+
+	// The valid certificate is kept, and it's issued again once it doesn't cover the hosts.
+	assert.NoError(t, Ensure(dir, servers, DefaultValidFor))
+	assert.Equal(t, cert.SerialNumber, verify().SerialNumber)
+	servers[0].Hosts = append(servers[0].Hosts, "greptime.local")
+	assert.NoError(t, Ensure(dir, servers, DefaultValidFor))
+	assert.NotEqual(t, cert.SerialNumber, verify().SerialNumber)
+
+	// The certificate that expires soon is renewed.
+	assert.NoError(t, Rotate(dir, servers, time.Hour, false))
+	cert = verify()
+	assert.NoError(t, Ensure(dir, servers, 30*24*time.Hour))
+	assert.NotEqual(t, cert.SerialNumber, verify().SerialNumber)
+
+	// The CA is kept by rotating unless it's rotated too.
+	assert.NoError(t, Rotate(dir, servers, DefaultValidFor, false))
+	cert = verify()
+	assert.NoError(t, Rotate(dir, servers, DefaultValidFor, true))
+	newCA, err := LoadCertificate(dir, CAName)
+	assert.NoError(t, err)
+	assert.NotEqual(t, ca.SerialNumber, newCA.SerialNumber)
+	assert.Error(t, cert.CheckSignatureFrom(newCA))
+	ca = newCA
+	verify()
+}
@@ -0,0 +1,133 @@
+/*
+ * Copyright 2023 Greptime Team
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+// Package users manages the static users that frontend authenticates the clients with.
+package users
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/rand"
+	"fmt"
+	"math/big"
+	"os"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+const (
+	// FileProviderPrefix is the prefix of the user provider that reads the users from a file,
+	// which has a 'name=password' line for each user.
+	FileProviderPrefix = "static_user_provider:file:"
+
+	// CmdProviderPrefix is the prefix of the user provider that reads the users from the command line,
+	// e.g. 'static_user_provider:cmd:alice=pwd1,bob=pwd2'.
+	CmdProviderPrefix = "static_user_provider:cmd:"
+
+	passwordLength  = 16
+	passwordLetters = "abcdefghijkmnopqrstuvwxyzABCDEFGHJKLMNPQRSTUVWXYZ23456789"
+)
+
+// nameRegexp is the valid name of user, it's also a valid key of the Kubernetes Secret.
+var nameRegexp = regexp.MustCompile(`^[a-zA-Z0-9][-._a-zA-Z0-9]*$`)
+
+// Validate validates the name and password of user, they can't contain the separators of user providers.
+func Validate(name, password string) error {
+	if !nameRegexp.MatchString(name) {
+		return fmt.Errorf("invalid user name '%s', it should only contain letters, digits, '-', '_' and '.', "+
+			"and start with a letter or digit", name)
+	}
+	if len(password) == 0 {
+		return fmt.Errorf("empty password of user '%s'", name)
+	}
+	if strings.ContainsAny(password, ",\r\n") {
+		return fmt.Errorf("the password of user '%s' should not contain ',' or line breaks", name)
+	}
+	return nil
+}
+
+// GeneratePassword generates a random password without the letters that look alike.
+func GeneratePassword() (string, error) {
+	password := make([]byte, passwordLength)
+	max := big.NewInt(int64(len(passwordLetters)))
+	for i := range password {
+		n, err := rand.Int(rand.Reader, max)
+		if err != nil {
+			return "", err
+		}
+		password[i] = passwordLetters[n.Int64()]
+	}
+	return string(password), nil
+}
+
+// Names returns the sorted names of users.
+func Names(users map[string]string) []string {
+	names := make([]string, 0, len(users))
+	for name := range users {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// ReadFile reads the users from the file of file user provider, no users are read if the file doesn't exist.
+func ReadFile(file string) (map[string]string, error) {
+	users := make(map[string]string)
+	data, err := os.ReadFile(file)
+	if os.IsNotExist(err) {
+		return users, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	for n := 1; scanner.Scan(); n++ {
+		line := strings.TrimSpace(scanner.Text())
+		if len(line) == 0 {
+			continue
+		}
+		name, password, ok := strings.Cut(line, "=")
+		if !ok {
+			return nil, fmt.Errorf("invalid line %d in the users file '%s'", n, file)
+		}
+		users[name] = password
+	}
+	return users, scanner.Err()
+}
+
+// WriteFile writes the users into the file of file user provider, which is only readable by the user.
+// The file is removed if there are no users, so the users file exists only if the authentication is enabled.
+func WriteFile(file string, users map[string]string) error {
+	if len(users) == 0 {
+		if err := os.Remove(file); err != nil && !os.IsNotExist(err) {
+			return err
+		}
+		return nil
+	}
+
+	var buf bytes.Buffer
+	for _, name := range Names(users) {
+		buf.WriteString(fmt.Sprintf("%s=%s\n", name, users[name]))
+	}
+
+	tmp := file + ".tmp"
+	if err := os.WriteFile(tmp, buf.Bytes(), 0600); err != nil {
+		return err
+	}
+	return os.Rename(tmp, file)
+}
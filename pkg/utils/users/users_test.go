@@ -0,0 +1,65 @@
+/*
+ * Copyright 2023 Greptime Team
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package users
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestValidate(t *testing.T) {
+	assert.NoError(t, Validate("greptime_user.1", "pass=word"))
+	assert.ErrorContains(t, Validate("", "password"), "invalid user name")
+	assert.ErrorContains(t, Validate("alice=bob", "password"), "invalid user name")
+	assert.ErrorContains(t, Validate("alice", ""), "empty password")
+	assert.ErrorContains(t, Validate("alice", "pass,word"), "should not contain")
+
+	password, err := GeneratePassword()
+	assert.NoError(t, err)
+	assert.Len(t, password, passwordLength)
+	assert.NoError(t, Validate("alice", password))
+}
+
+func TestReadAndWriteFile(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "users")
+
+	all, err := ReadFile(file)
+	assert.NoError(t, err)
+	assert.Empty(t, all)
+
+	all = map[string]string{"bob": "pass=word", "alice": "secret"}
+	assert.NoError(t, WriteFile(file, all))
+	data, err := os.ReadFile(file)
+	assert.NoError(t, err)
+	assert.Equal(t, "alice=secret\nbob=pass=word\n", string(data))
+	info, err := os.Stat(file)
+	assert.NoError(t, err)
+	assert.Equal(t, os.FileMode(0600), info.Mode().Perm())
+
+	read, err := ReadFile(file)
+	assert.NoError(t, err)
+	assert.Equal(t, all, read)
+	assert.Equal(t, []string{"alice", "bob"}, Names(read))
+
+	// The file is removed with the last user.
+	assert.NoError(t, WriteFile(file, nil))
+	assert.NoFileExists(t, file)
+	assert.NoError(t, WriteFile(file, nil))
+}